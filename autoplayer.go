@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/engine"
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+	"github.com/imyakin/go_hw/internal/uci"
+)
+
+// moveChooser picks the next move for a player on a copy of the game.
+type moveChooser interface {
+	ChooseMove(ctx context.Context, game *model.Game) (*model.Move, error)
+	String() string
+}
+
+// engineChooser plays with the built-in engine at a fixed strength level.
+type engineChooser struct {
+	level engine.Level
+}
+
+func (c engineChooser) ChooseMove(ctx context.Context, game *model.Game) (*model.Move, error) {
+	return engine.ChooseMove(ctx, game, c.level, moveTimeLimit(game))
+}
+
+func (c engineChooser) String() string {
+	return c.level.String()
+}
+
+// uciChooser asks an external UCI engine for the move.
+type uciChooser struct {
+	client *uci.Client
+}
+
+func (c uciChooser) ChooseMove(ctx context.Context, game *model.Game) (*model.Move, error) {
+	text, err := c.client.BestMove(ctx, game, moveTimeLimit(game))
+	if err != nil {
+		return nil, err
+	}
+	if text == "0000" || text == "(none)" {
+		return nil, engine.ErrNoMoves
+	}
+	move, err := game.ParseMoveText(text)
+	if err != nil {
+		return nil, fmt.Errorf("движок %s вернул ход %s: %w", c.client.Name(), text, err)
+	}
+	if err := game.ValidateMove(move); err != nil {
+		return nil, fmt.Errorf("движок %s вернул ход %s: %w", c.client.Name(), text, err)
+	}
+	return move, nil
+}
+
+func (c uciChooser) String() string {
+	return "UCI-движок " + c.client.Name()
+}
+
+func (c uciChooser) Close() error {
+	return c.client.Close()
+}
+
+// autoPlayer describes how automatic moves are made for a player.
+type autoPlayer struct {
+	chooser moveChooser
+	// computer makes every move of this player in gameLoop, not only on Автоход
+	computer bool
+}
+
+var autoPlayers = struct {
+	mu       sync.RWMutex
+	byPlayer map[*model.Player]autoPlayer
+}{byPlayer: make(map[*model.Player]autoPlayer)}
+
+var defaultAutoPlayer = autoPlayer{chooser: engineChooser{level: engine.LevelFull}}
+
+func setAutoPlayer(player *model.Player, config autoPlayer) {
+	autoPlayers.mu.Lock()
+	defer autoPlayers.mu.Unlock()
+	autoPlayers.byPlayer[player] = config
+}
+
+func autoPlayerFor(player *model.Player) autoPlayer {
+	autoPlayers.mu.RLock()
+	defer autoPlayers.mu.RUnlock()
+	if config, ok := autoPlayers.byPlayer[player]; ok {
+		return config
+	}
+	return defaultAutoPlayer
+}
+
+func isComputer(player *model.Player) bool {
+	return autoPlayerFor(player).computer
+}
+
+// closeAutoPlayers shuts down the external engines started for the players.
+func closeAutoPlayers() {
+	autoPlayers.mu.Lock()
+	defer autoPlayers.mu.Unlock()
+	for player, config := range autoPlayers.byPlayer {
+		if closer, ok := config.chooser.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				fmt.Printf("Ошибка остановки движка %s: %v\n", config.chooser, err)
+			}
+		}
+		delete(autoPlayers.byPlayer, player)
+	}
+}
+
+// configurePlayers asks who makes the automatic moves of both players — the
+// built-in engine at some level or an external UCI engine — and, in
+// single-board mode, which of them the computer plays for. External engines
+// are stopped when ctx is cancelled.
+func configurePlayers(ctx context.Context, game *model.Game, singleBoard bool) bool {
+	for _, player := range []*model.Player{game.WhitePlayer, game.BlackPlayer} {
+		fmt.Printf("Автоход для %s (0 — %s, 1 — %s, 2 — %s, 3 — %s или путь к UCI-движку): ",
+			player.GetDisplayName(), engine.LevelRandom, engine.LevelGreedy, engine.LevelShallow, engine.LevelFull)
+		chooser, err := parseChooser(ctx, readLine())
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return false
+		}
+		setAutoPlayer(player, autoPlayer{chooser: chooser})
+	}
+
+	if !singleBoard {
+		return true
+	}
+	fmt.Print("За кого играет компьютер? (никто/белые/черные/оба): ")
+	var computerColors []model.PlayerColor
+	switch strings.ToLower(readLine()) {
+	case "никто":
+	case "белые":
+		computerColors = []model.PlayerColor{model.White}
+	case "черные":
+		computerColors = []model.PlayerColor{model.Black}
+	case "оба":
+		computerColors = []model.PlayerColor{model.White, model.Black}
+	default:
+		fmt.Println("Ошибка: ответьте никто, белые, черные или оба")
+		return false
+	}
+	for _, color := range computerColors {
+		player := game.WhitePlayer
+		if color == model.Black {
+			player = game.BlackPlayer
+		}
+		config := autoPlayerFor(player)
+		config.computer = true
+		setAutoPlayer(player, config)
+	}
+	return true
+}
+
+// parseChooser treats a number as a built-in engine level and anything else
+// as the command line of a UCI engine to launch.
+func parseChooser(ctx context.Context, input string) (moveChooser, error) {
+	if _, err := strconv.Atoi(input); err == nil {
+		level, err := engine.ParseLevel(input)
+		if err != nil {
+			return nil, err
+		}
+		return engineChooser{level: level}, nil
+	}
+
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("не указан уровень или движок")
+	}
+	client, err := uci.Start(ctx, fields[0], fields[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось запустить движок %s: %w", fields[0], err)
+	}
+	fmt.Printf("Подключен движок %s\n", client.Name())
+	return uciChooser{client: client}, nil
+}
+
+// autoMoveTimeLimit is the thinking time the engine gets for one automatic move.
+const autoMoveTimeLimit = 2 * time.Second
+
+// clockMovesToGo is the number of moves the remaining clock time is spread over.
+const clockMovesToGo = 30
+
+// moveTimeLimit is autoMoveTimeLimit, shortened so that the engine does not
+// lose on time when the game is played with clocks.
+func moveTimeLimit(game *model.Game) time.Duration {
+	if game.Clock == nil {
+		return autoMoveTimeLimit
+	}
+	left := game.TimeLeft(game.CurrentPlayer.Color, time.Now())
+	limit := left/clockMovesToGo + game.Clock.Control.Increment/2 + game.Clock.Control.Delay/2
+	return max(min(limit, left/2, autoMoveTimeLimit), time.Millisecond)
+}
+
+func autoMove(ctx context.Context, repo repository.Repository, game *model.Game) (time.Duration, string, *model.Player, error) {
+	startTime := time.Now()
+
+	// Search on a copy so that the board can be rendered while the engine thinks
+	game.Mu.RLock()
+	position := game.Clone()
+	player := game.CurrentPlayer
+	game.Mu.RUnlock()
+
+	best, err := autoPlayerFor(player).chooser.ChooseMove(ctx, position)
+	if ctx.Err() != nil {
+		return 0, "", nil, ctx.Err()
+	}
+	if err != nil {
+		return 0, "", player, fmt.Errorf("нет доступных ходов для %s: %w", player.GetDisplayName(), err)
+	}
+
+	game.Mu.Lock()
+	move := model.NewMove(best.From.Row, best.From.Col, best.To.Row, best.To.Col, player, best.Piece)
+	move.Promotion = best.Promotion
+	err = game.MakeMove(move)
+	if err == nil {
+		move.ThinkTime = time.Since(startTime)
+	}
+	game.Mu.Unlock()
+	if err != nil {
+		return 0, "", player, err
+	}
+	storeMove(repo, game, move)
+
+	duration := move.ThinkTime
+	notation := fmt.Sprintf("Автоход: %s", move.GetNotation())
+	return duration, notation, player, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+const queryDateLayout = "2006-01-02"
+
+// listGames implements "go_hw games [flags]": it prints the stored games that
+// match the filters, one page at a time.
+func listGames(repo repository.Repository, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("games", flag.ContinueOnError)
+	fs.SetOutput(out)
+	var q repository.GameQuery
+	var color, status, result, outcome, from, to, sortBy string
+	fs.StringVar(&q.Player, "player", "", "player name")
+	fs.StringVar(&color, "color", "", "color of -player: white or black")
+	fs.StringVar(&status, "status", "", "not_started, in_progress or finished")
+	fs.StringVar(&result, "result", "", "1-0, 0-1 or 1/2-1/2")
+	fs.StringVar(&outcome, "outcome", "", "win, loss or draw for -player or -color")
+	fs.StringVar(&from, "from", "", "first day, "+queryDateLayout)
+	fs.StringVar(&to, "to", "", "last day, "+queryDateLayout)
+	fs.IntVar(&q.BoardSize, "size", 0, "board size")
+	fs.StringVar(&q.Opening, "opening", "", `first moves in SAN, e.g. "e4 e5 Nf3"`)
+	fs.IntVar(&q.MinMoves, "min-moves", 0, "minimum number of half-moves")
+	fs.StringVar(&sortBy, "sort", string(repository.SortByCreated), "created, updated or moves")
+	fs.BoolVar(&q.Descending, "desc", false, "sort in descending order")
+	fs.IntVar(&q.Offset, "offset", 0, "number of games to skip")
+	fs.IntVar(&q.Limit, "limit", 20, "page size, 0 for all games")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch model.PlayerColor(color) {
+	case "", model.White, model.Black:
+		q.Color = model.PlayerColor(color)
+	default:
+		return fmt.Errorf("неизвестный цвет %q", color)
+	}
+	switch model.GameStatus(status) {
+	case "", model.StatusNotStarted, model.StatusInProgress, model.StatusFinished:
+		q.Status = model.GameStatus(status)
+	default:
+		return fmt.Errorf("неизвестный статус %q", status)
+	}
+	switch model.GameResult(result) {
+	case model.ResultNone, model.ResultWhiteWins, model.ResultBlackWins, model.ResultDraw:
+		q.Result = model.GameResult(result)
+	default:
+		return fmt.Errorf("неизвестный результат %q", result)
+	}
+	switch repository.Outcome(outcome) {
+	case repository.OutcomeAny, repository.OutcomeWin, repository.OutcomeLoss, repository.OutcomeDraw:
+		q.Outcome = repository.Outcome(outcome)
+	default:
+		return fmt.Errorf("неизвестный исход %q", outcome)
+	}
+	switch repository.SortField(sortBy) {
+	case repository.SortByCreated, repository.SortByUpdated, repository.SortByMoves:
+		q.SortBy = repository.SortField(sortBy)
+	default:
+		return fmt.Errorf("неизвестная сортировка %q", sortBy)
+	}
+	q.Offset = max(q.Offset, 0)
+	var err error
+	if q.From, err = parseQueryDate(from); err != nil {
+		return err
+	}
+	if q.To, err = parseQueryDate(to); err != nil {
+		return err
+	}
+	// -to names the last day to include
+	if !q.To.IsZero() {
+		q.To = q.To.AddDate(0, 0, 1)
+	}
+
+	page := repo.FindGames(q)
+	for _, game := range page.Games {
+		game.Mu.RLock()
+		fmt.Fprintf(out, "[%.8s] %s  %s — %s  %dx%d  ходов: %d  %s\n",
+			game.ID,
+			game.CreatedAt.Local().Format("02.01.2006 15:04"),
+			game.WhitePlayer.Name,
+			game.BlackPlayer.Name,
+			game.Board.Size, game.Board.Size,
+			game.GetMoveCount(),
+			describeResult(game),
+		)
+		game.Mu.RUnlock()
+	}
+	if len(page.Games) == 0 {
+		fmt.Fprintf(out, "Партий не найдено (всего подходит: %d)\n", page.Total)
+		return nil
+	}
+	first := min(q.Offset, page.Total) + 1
+	fmt.Fprintf(out, "Показаны %d–%d из %d\n", first, first+len(page.Games)-1, page.Total)
+	return nil
+}
+
+func parseQueryDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(queryDateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверная дата %q, ожидается ГГГГ-ММ-ДД", s)
+	}
+	return t, nil
+}
+
+// describeResult returns the result of a finished game or its status; the caller holds game.Mu.
+func describeResult(game *model.Game) string {
+	if game.Result == model.ResultNone {
+		return string(game.Status)
+	}
+	if game.ResultReason == "" {
+		return string(game.Result)
+	}
+	return fmt.Sprintf("%s (%s)", game.Result, game.ResultReason)
+}
@@ -0,0 +1,113 @@
+package engine
+
+import "github.com/imyakin/go_hw/internal/model"
+
+var pieceValues = map[model.PieceKind]int{
+	model.Pawn:   100,
+	model.Knight: 320,
+	model.Bishop: 330,
+	model.Rook:   500,
+	model.Queen:  900,
+	model.King:   0,
+}
+
+// Таблицы позиционных бонусов для белых на доске 8x8; строка 0 — восьмая
+// горизонталь, как и в model.Board. Для черных таблица отражается по вертикали.
+var pieceSquareTables = map[model.PieceKind][8][8]int{
+	model.Pawn: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	model.Knight: {
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	},
+	model.Bishop: {
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	},
+	model.Rook: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	},
+	model.Queen: {
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	},
+	model.King: {
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	},
+}
+
+// Evaluate оценивает позицию в сантипешках с точки зрения игрока, чья
+// очередь хода: материал плюс позиционные бонусы фигур.
+func Evaluate(game *model.Game) int {
+	board := game.Board
+	score := 0
+	for row := 0; row < board.Size; row++ {
+		for col := 0; col < board.Size; col++ {
+			piece, ok := model.ParsePiece(board.GetCell(row, col))
+			if !ok {
+				continue
+			}
+			value := pieceValues[piece.Kind] + squareBonus(board.Size, piece, row, col)
+			if piece.Color == model.White {
+				score += value
+			} else {
+				score -= value
+			}
+		}
+	}
+	if game.CurrentPlayer.IsBlack() {
+		return -score
+	}
+	return score
+}
+
+func squareBonus(size int, piece model.Piece, row, col int) int {
+	if size != model.StandardBoardSize {
+		return 0
+	}
+	if piece.Color == model.Black {
+		row = size - 1 - row
+	}
+	table := pieceSquareTables[piece.Kind]
+	return table[row][col]
+}
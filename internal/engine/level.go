@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Level задает силу игры движка.
+type Level int
+
+const (
+	LevelRandom  Level = iota // случайный допустимый ход
+	LevelGreedy               // лучший ход на один полуход вперед, прежде всего выгодное взятие
+	LevelShallow              // перебор на небольшую глубину
+	LevelFull                 // полный перебор, ограниченный временем
+)
+
+// shallowDepth — глубина перебора для LevelShallow.
+const shallowDepth = 2
+
+var levelNames = map[Level]string{
+	LevelRandom:  "случайные ходы",
+	LevelGreedy:  "жадные взятия",
+	LevelShallow: "неглубокий поиск",
+	LevelFull:    "полный поиск",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return "уровень " + strconv.Itoa(int(l))
+}
+
+// ParseLevel разбирает номер уровня от 0 до 3.
+func ParseLevel(s string) (Level, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < int(LevelRandom) || n > int(LevelFull) {
+		return 0, fmt.Errorf("уровень должен быть числом от %d до %d", LevelRandom, LevelFull)
+	}
+	return Level(n), nil
+}
+
+// ChooseMove выбирает ход на указанном уровне. Как и Search, работает с
+// копией игры; timeLimit используется только уровнем LevelFull.
+func ChooseMove(ctx context.Context, game *model.Game, level Level, timeLimit time.Duration) (*model.Move, error) {
+	moves := game.LegalMoves()
+	if len(moves) == 0 {
+		return nil, ErrNoMoves
+	}
+
+	switch level {
+	case LevelRandom:
+		return moves[rand.Intn(len(moves))], nil
+	case LevelGreedy:
+		return greedyMove(game, moves), nil
+	case LevelShallow:
+		result, err := Search(ctx, game, Options{MaxDepth: shallowDepth})
+		return result.Move, err
+	}
+	result, err := Search(ctx, game, Options{TimeLimit: timeLimit})
+	return result.Move, err
+}
+
+// greedyMove берет ход с лучшей оценкой сразу после него, то есть самое
+// ценное взятие; среди равных ходов выбирает случайно.
+func greedyMove(game *model.Game, moves []*model.Move) *model.Move {
+	var best []*model.Move
+	bestScore := -infinity
+	for _, move := range moves {
+		game.Push(move)
+		score := -Evaluate(game)
+		game.Pop()
+		switch {
+		case score > bestScore:
+			bestScore = score
+			best = []*model.Move{move}
+		case score == bestScore:
+			best = append(best, move)
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+const (
+	mateScore = 1_000_000
+	infinity  = 2 * mateScore
+
+	// defaultMaxDepth ограничивает перебор, если задан только лимит времени.
+	defaultMaxDepth = 64
+	// maxQuiescencePly ограничивает форсированный перебор взятий.
+	maxQuiescencePly = 6
+	// checkEvery — через сколько узлов проверять время и отмену контекста.
+	checkEvery = 512
+
+	fiftyMoveLimit = 100
+)
+
+var ErrNoMoves = errors.New("нет допустимых ходов")
+
+type Options struct {
+	MaxDepth  int           // 0 — пока хватает времени
+	TimeLimit time.Duration // 0 — без ограничения по времени
+}
+
+type Result struct {
+	Move  *model.Move
+	Score int // оценка в сантипешках с точки зрения ходящего
+	Depth int // глубина последней полностью просчитанной итерации
+	Nodes int
+}
+
+type searcher struct {
+	ctx      context.Context
+	deadline time.Time
+	nodes    int
+	stopped  bool
+}
+
+// Search ищет лучший ход для игрока, чья очередь хода, перебором
+// альфа-бета с итеративным углублением. Во время поиска ходы делаются прямо
+// в game, поэтому вызывающий должен передавать копию (model.Game.Clone).
+// При истечении времени или отмене ctx возвращается лучший ход последней
+// завершенной итерации; отмену вызывающий проверяет через ctx.Err().
+func Search(ctx context.Context, game *model.Game, opts Options) (Result, error) {
+	moves := game.LegalMoves()
+	if len(moves) == 0 {
+		return Result{}, ErrNoMoves
+	}
+	orderMoves(game, moves)
+
+	s := &searcher{ctx: ctx}
+	if opts.TimeLimit > 0 {
+		s.deadline = time.Now().Add(opts.TimeLimit)
+	}
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	best := Result{Move: moves[0]}
+	for depth := 1; depth <= maxDepth; depth++ {
+		alpha := -infinity
+		bestIndex := -1
+		for i, move := range moves {
+			game.Push(move)
+			score := -s.negamax(game, depth-1, -infinity, -alpha, 1)
+			game.Pop()
+			if s.stopped {
+				break
+			}
+			if score > alpha {
+				alpha = score
+				bestIndex = i
+			}
+		}
+		if s.stopped || bestIndex < 0 {
+			break
+		}
+
+		best = Result{Move: moves[bestIndex], Score: alpha, Depth: depth, Nodes: s.nodes}
+		// Лучший ход ставим первым: следующая итерация быстрее отсечет остальные.
+		moves[0], moves[bestIndex] = moves[bestIndex], moves[0]
+
+		if alpha > mateScore-defaultMaxDepth || alpha < -mateScore+defaultMaxDepth {
+			break
+		}
+	}
+	best.Nodes = s.nodes
+	return best, nil
+}
+
+func (s *searcher) checkStop() {
+	if s.nodes%checkEvery != 0 {
+		return
+	}
+	if s.ctx.Err() != nil || (!s.deadline.IsZero() && time.Now().After(s.deadline)) {
+		s.stopped = true
+	}
+}
+
+func (s *searcher) negamax(game *model.Game, depth, alpha, beta, ply int) int {
+	s.nodes++
+	s.checkStop()
+	if s.stopped {
+		return 0
+	}
+	if game.HalfmoveClock >= fiftyMoveLimit {
+		return 0
+	}
+
+	moves := game.LegalMoves()
+	if len(moves) == 0 {
+		if game.IsCheck() {
+			return -mateScore + ply
+		}
+		return 0
+	}
+	if depth <= 0 {
+		return s.quiesce(game, alpha, beta, ply, 0)
+	}
+
+	orderMoves(game, moves)
+	for _, move := range moves {
+		game.Push(move)
+		score := -s.negamax(game, depth-1, -beta, -alpha, ply+1)
+		game.Pop()
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha
+}
+
+// quiesce продолжает перебор только взятиями, чтобы не оценивать позицию
+// посреди размена.
+func (s *searcher) quiesce(game *model.Game, alpha, beta, ply, qply int) int {
+	standPat := Evaluate(game)
+	if standPat >= beta {
+		return beta
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+	if qply >= maxQuiescencePly {
+		return alpha
+	}
+
+	var captures []*model.Move
+	for _, move := range game.LegalMoves() {
+		if isCapture(game.Board, move) || move.Promotion != "" {
+			captures = append(captures, move)
+		}
+	}
+	orderMoves(game, captures)
+
+	for _, move := range captures {
+		s.nodes++
+		s.checkStop()
+		if s.stopped {
+			return 0
+		}
+		game.Push(move)
+		score := -s.quiesce(game, -beta, -alpha, ply+1, qply+1)
+		game.Pop()
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha
+}
+
+func isCapture(board *model.Board, move *model.Move) bool {
+	if board.GetCell(move.To.Row, move.To.Col) != "" {
+		return true
+	}
+	// Взятие на проходе: пешка уходит по диагонали на пустое поле.
+	piece, _ := model.ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+	return piece.Kind == model.Pawn && move.From.Col != move.To.Col
+}
+
+// orderMoves ставит первыми превращения и взятия ценных фигур дешевыми
+// (MVV-LVA): так альфа-бета отсекает больше вариантов.
+func orderMoves(game *model.Game, moves []*model.Move) {
+	board := game.Board
+	priority := func(move *model.Move) int {
+		score := 0
+		if move.Promotion != "" {
+			score += pieceValues[move.Promotion] * 10
+		}
+		if victim, ok := model.ParsePiece(board.GetCell(move.To.Row, move.To.Col)); ok {
+			attacker, _ := model.ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+			score += pieceValues[victim.Kind]*10 - pieceValues[attacker.Kind]
+		}
+		return score
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return priority(moves[i]) > priority(moves[j])
+	})
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+func startFrom(t *testing.T, fen string) *model.Game {
+	t.Helper()
+	game, err := model.NewGameFromFEN("Белые", "Черные", fen)
+	if err != nil {
+		t.Fatalf("NewGameFromFEN(%q): %v", fen, err)
+	}
+	game.Start()
+	return game
+}
+
+func TestSearchFindsBestMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		want  string // ход в записи UCI
+		mate  bool
+	}{
+		{"мат по последней горизонтали", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 2, "a1a8", true},
+		{"мат ферзем черных", "6k1/8/8/8/3q4/8/5PPP/6K1 b - - 0 1", 2, "d4d1", true},
+		{"детский мат", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", 2, "h5f7", true},
+		{"взятие незащищенного ферзя", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", 3, "d2d5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			fen := game.FEN()
+			result, err := Search(context.Background(), game, Options{MaxDepth: tt.depth})
+			if err != nil {
+				t.Fatalf("Search() = %v", err)
+			}
+			if got := result.Move.CoordinateNotation(game.Board.Size); got != tt.want {
+				t.Errorf("ход %s (оценка %d), ожидается %s", got, result.Score, tt.want)
+			}
+			if tt.mate && result.Score < mateScore-defaultMaxDepth {
+				t.Errorf("оценка %d, ожидается мат", result.Score)
+			}
+			if game.FEN() != fen {
+				t.Errorf("поиск изменил позицию: %q, было %q", game.FEN(), fen)
+			}
+		})
+	}
+}
+
+func TestSearchNoMoves(t *testing.T) {
+	for _, fen := range []string{
+		"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",    // пат
+		"R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1", // мат
+	} {
+		game := startFrom(t, fen)
+		if _, err := Search(context.Background(), game, Options{MaxDepth: 2}); !errors.Is(err, ErrNoMoves) {
+			t.Errorf("%s: Search() = %v, ожидается ErrNoMoves", fen, err)
+		}
+	}
+}
+
+func TestSearchStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		opts Options
+	}{
+		{"отмененный контекст", ctx, Options{}},
+		{"лимит времени", context.Background(), Options{TimeLimit: 50 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, model.StartFEN)
+			started := time.Now()
+			result, err := Search(tt.ctx, game, tt.opts)
+			if err != nil {
+				t.Fatalf("Search() = %v", err)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("поиск остановился через %v", elapsed)
+			}
+			if result.Move == nil || game.ValidateMove(result.Move) != nil {
+				t.Errorf("после остановки нет допустимого хода: %v", result.Move)
+			}
+			if game.FEN() != model.StartFEN {
+				t.Errorf("остановленный поиск оставил позицию %q", game.FEN())
+			}
+		})
+	}
+}
+
+func TestChooseMoveLevels(t *testing.T) {
+	for _, level := range []Level{LevelRandom, LevelGreedy, LevelShallow, LevelFull} {
+		t.Run(level.String(), func(t *testing.T) {
+			game := startFrom(t, "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1")
+			move, err := ChooseMove(context.Background(), game, level, 100*time.Millisecond)
+			if err != nil {
+				t.Fatalf("ChooseMove() = %v", err)
+			}
+			if err := game.ValidateMove(move); err != nil {
+				t.Errorf("ChooseMove() = %s: %v", move.CoordinateNotation(game.Board.Size), err)
+			}
+			if level >= LevelGreedy && move.CoordinateNotation(game.Board.Size) != "d2d5" {
+				t.Errorf("уровень %s не взял ферзя: %s", level, move.CoordinateNotation(game.Board.Size))
+			}
+		})
+	}
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	tests := []struct {
+		white, black string // одна позиция с ходом белых и зеркальная с ходом черных
+	}{
+		{model.StartFEN, model.StartFEN},
+		{
+			"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+			"rnbqkb1r/pppp1ppp/5n2/4p3/4P3/2N5/PPPP1PPP/R1BQKBNR b KQkq - 2 3",
+		},
+	}
+	for _, tt := range tests {
+		white := startFrom(t, tt.white)
+		black := startFrom(t, tt.black)
+		if w, b := Evaluate(white), Evaluate(black); w != b {
+			t.Errorf("оценка %d за белых и %d за черных в зеркальной позиции", w, b)
+		}
+	}
+	if score := Evaluate(startFrom(t, "4k3/8/8/8/8/8/8/3QK3 w - - 0 1")); score < 800 {
+		t.Errorf("лишний ферзь оценен в %d", score)
+	}
+}
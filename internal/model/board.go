@@ -1,6 +1,7 @@
 package model
 
 type Board struct {
+	Entity
 	Size  int
 	Cells [][]string
 }
@@ -12,8 +13,9 @@ func NewBoard(size int) *Board {
 	}
 
 	return &Board{
-		Size:  size,
-		Cells: cells,
+		Entity: NewEntity(),
+		Size:   size,
+		Cells:  cells,
 	}
 }
 
@@ -34,6 +36,32 @@ func (b *Board) IsValidPosition(row, col int) bool {
 	return row >= 0 && row < b.Size && col >= 0 && col < b.Size
 }
 
+// applyMove переставляет фигуры на доске, включая ладью при рокировке,
+// пешку, взятую на проходе, и фигуру, в которую превращается пешка.
+func (b *Board) applyMove(move *Move) {
+	switch {
+	case isCastlingMove(b, move):
+		rookCol := queenSideRookCol
+		if move.To.Col > move.From.Col {
+			rookCol = kingSideRookCol
+		}
+		rookTo := (move.From.Col + move.To.Col) / 2
+		b.SetCell(move.From.Row, rookTo, b.GetCell(move.From.Row, rookCol))
+		b.SetCell(move.From.Row, rookCol, "")
+	case isEnPassantMove(b, move):
+		b.SetCell(move.From.Row, move.To.Col, "")
+	}
+
+	piece := b.GetCell(move.From.Row, move.From.Col)
+	if move.Promotion != "" {
+		if p, ok := ParsePiece(piece); ok {
+			piece = PieceSymbol(move.Promotion, p.Color)
+		}
+	}
+	b.SetCell(move.To.Row, move.To.Col, piece)
+	b.SetCell(move.From.Row, move.From.Col, "")
+}
+
 func (b *Board) EntityType() string {
 	return "Board"
 }
@@ -0,0 +1,257 @@
+package model
+
+var (
+	knightSteps   = [][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingSteps     = [][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	straightSteps = [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	diagonalSteps = [][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+)
+
+func (b *Board) Clone() *Board {
+	cells := make([][]string, len(b.Cells))
+	for i, row := range b.Cells {
+		cells[i] = make([]string, len(row))
+		copy(cells[i], row)
+	}
+	return &Board{Entity: b.Entity, Size: b.Size, Cells: cells}
+}
+
+// FindKing возвращает клетку короля указанного цвета.
+func (b *Board) FindKing(color PlayerColor) (Position, bool) {
+	king := PieceSymbol(King, color)
+	for row := 0; row < b.Size; row++ {
+		for col := 0; col < b.Size; col++ {
+			if b.GetCell(row, col) == king {
+				return Position{Row: row, Col: col}, true
+			}
+		}
+	}
+	return Position{}, false
+}
+
+// IsSquareAttacked сообщает, бьет ли хоть одна фигура цвета by клетку pos.
+func (b *Board) IsSquareAttacked(pos Position, by PlayerColor) bool {
+	// Пешки бьют по диагонали навстречу своему направлению движения.
+	pawnRow := pos.Row - pawnDirection(by)
+	for _, dc := range []int{-1, 1} {
+		if p, ok := ParsePiece(b.GetCell(pawnRow, pos.Col+dc)); ok && p == (Piece{Pawn, by}) {
+			return true
+		}
+	}
+
+	for _, step := range knightSteps {
+		if p, ok := ParsePiece(b.GetCell(pos.Row+step[0], pos.Col+step[1])); ok && p == (Piece{Knight, by}) {
+			return true
+		}
+	}
+
+	for _, step := range kingSteps {
+		if p, ok := ParsePiece(b.GetCell(pos.Row+step[0], pos.Col+step[1])); ok && p == (Piece{King, by}) {
+			return true
+		}
+	}
+
+	if b.attackedAlong(pos, by, straightSteps, Rook) || b.attackedAlong(pos, by, diagonalSteps, Bishop) {
+		return true
+	}
+	return false
+}
+
+func (b *Board) attackedAlong(pos Position, by PlayerColor, steps [][2]int, slider PieceKind) bool {
+	for _, step := range steps {
+		row, col := pos.Row+step[0], pos.Col+step[1]
+		for b.IsValidPosition(row, col) {
+			symbol := b.GetCell(row, col)
+			if symbol != "" {
+				p, ok := ParsePiece(symbol)
+				if ok && p.Color == by && (p.Kind == slider || p.Kind == Queen) {
+					return true
+				}
+				break
+			}
+			row += step[0]
+			col += step[1]
+		}
+	}
+	return false
+}
+
+// leavesKingInCheck пробно применяет ход к доске, проверяет шах королю
+// указанного цвета и возвращает доску в исходное состояние. Доска
+// меняется на месте, без копии: проверка идет для каждого хода при переборе
+// и в поиске движка, поэтому читать доску в это время нельзя.
+func (b *Board) leavesKingInCheck(move *Move, color PlayerColor) bool {
+	touched := []Position{move.From, move.To}
+	switch {
+	case isCastlingMove(b, move):
+		touched = append(touched,
+			Position{Row: move.From.Row, Col: queenSideRookCol},
+			Position{Row: move.From.Row, Col: kingSideRookCol},
+			Position{Row: move.From.Row, Col: (move.From.Col + move.To.Col) / 2})
+	case isEnPassantMove(b, move):
+		touched = append(touched, Position{Row: move.From.Row, Col: move.To.Col})
+	}
+	saved := make([]string, len(touched))
+	for i, pos := range touched {
+		saved[i] = b.GetCell(pos.Row, pos.Col)
+	}
+
+	b.applyMove(move)
+	inCheck := b.IsInCheck(color)
+
+	for i, pos := range touched {
+		b.SetCell(pos.Row, pos.Col, saved[i])
+	}
+	return inCheck
+}
+
+// IsInCheck сообщает, атакован ли король указанного цвета.
+func (b *Board) IsInCheck(color PlayerColor) bool {
+	king, ok := b.FindKing(color)
+	if !ok {
+		return false
+	}
+	return b.IsSquareAttacked(king, color.Opponent())
+}
+
+// ValidateMove проверяет ход текущего игрока целиком: правила движения
+// фигуры и то, что после хода собственный король не остается под шахом.
+//
+// Для проверки шаха ход пробно делается на доске партии, поэтому
+// ValidateMove, LegalMoves, IsCheckmate и IsStalemate, а с ними ParseSAN и
+// ParseMoveText вызываются под Mu.Lock партии, а не под RLock, либо на копии
+// (Clone).
+func (g *Game) ValidateMove(move *Move) error {
+	if move.Player != g.CurrentPlayer {
+		return illegal(move, "сейчас ход игрока %s", g.CurrentPlayer.GetDisplayName())
+	}
+	var err error
+	switch {
+	case isCastlingMove(g.Board, move):
+		err = g.validateCastling(move)
+	case isEnPassantMove(g.Board, move):
+		err = g.validateEnPassant(move)
+	default:
+		err = move.Validate(g.Board)
+	}
+	if err != nil {
+		return err
+	}
+	if err := g.validatePromotion(move); err != nil {
+		return err
+	}
+
+	if g.Board.leavesKingInCheck(move, move.Player.Color) {
+		if g.Board.IsInCheck(move.Player.Color) {
+			return illegal(move, "нужно защитить короля от шаха")
+		}
+		return illegal(move, "нельзя оставлять короля под шахом")
+	}
+	return nil
+}
+
+// LegalMoves возвращает все допустимые ходы игрока, чья сейчас очередь.
+// Пробует ходы на доске партии: вызывающий держит Mu.Lock.
+func (g *Game) LegalMoves() []*Move {
+	var result []*Move
+	board := g.Board
+	player := g.CurrentPlayer
+	for row := 0; row < board.Size; row++ {
+		for col := 0; col < board.Size; col++ {
+			symbol := board.GetCell(row, col)
+			piece, ok := ParsePiece(symbol)
+			if !ok || piece.Color != player.Color {
+				continue
+			}
+			from := Position{Row: row, Col: col}
+			for _, to := range candidateTargets(board, from, piece) {
+				move := NewMove(from.Row, from.Col, to.Row, to.Col, player, symbol)
+				if g.ValidateMove(move) != nil {
+					continue
+				}
+				if piece.Kind != Pawn || to.Row != promotionRow(board, piece.Color) {
+					result = append(result, move)
+					continue
+				}
+				for _, kind := range []PieceKind{Queen, Rook, Bishop, Knight} {
+					promotion := *move
+					promotion.Promotion = kind
+					result = append(result, &promotion)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (g *Game) hasLegalMoves() bool {
+	return len(g.LegalMoves()) > 0
+}
+
+// IsCheck сообщает, находится ли король игрока, чья сейчас очередь, под шахом.
+func (g *Game) IsCheck() bool {
+	return g.CurrentPlayer != nil && g.Board.IsInCheck(g.CurrentPlayer.Color)
+}
+
+// IsCheckmate сообщает о мате игроку, чья сейчас очередь; вызывающий
+// держит Mu.Lock, как для LegalMoves.
+func (g *Game) IsCheckmate() bool {
+	return g.IsCheck() && !g.hasLegalMoves()
+}
+
+// IsStalemate сообщает о пате игроку, чья сейчас очередь; вызывающий
+// держит Mu.Lock, как для LegalMoves.
+func (g *Game) IsStalemate() bool {
+	return g.CurrentPlayer != nil && !g.IsCheck() && !g.hasLegalMoves()
+}
+
+// candidateTargets перечисляет клетки, куда фигура могла бы пойти по своей
+// геометрии; окончательную проверку выполняет ValidateMove.
+func candidateTargets(board *Board, from Position, piece Piece) []Position {
+	var targets []Position
+	add := func(row, col int) {
+		if board.IsValidPosition(row, col) {
+			targets = append(targets, Position{Row: row, Col: col})
+		}
+	}
+	slide := func(steps [][2]int) {
+		for _, step := range steps {
+			row, col := from.Row+step[0], from.Col+step[1]
+			for board.IsValidPosition(row, col) {
+				add(row, col)
+				if board.GetCell(row, col) != "" {
+					break
+				}
+				row += step[0]
+				col += step[1]
+			}
+		}
+	}
+
+	switch piece.Kind {
+	case Pawn:
+		dir := pawnDirection(piece.Color)
+		add(from.Row+dir, from.Col)
+		add(from.Row+2*dir, from.Col)
+		add(from.Row+dir, from.Col-1)
+		add(from.Row+dir, from.Col+1)
+	case Knight:
+		for _, step := range knightSteps {
+			add(from.Row+step[0], from.Col+step[1])
+		}
+	case King:
+		for _, step := range kingSteps {
+			add(from.Row+step[0], from.Col+step[1])
+		}
+		add(from.Row, from.Col-castlingKingShift)
+		add(from.Row, from.Col+castlingKingShift)
+	case Bishop:
+		slide(diagonalSteps)
+	case Rook:
+		slide(straightSteps)
+	case Queen:
+		slide(straightSteps)
+		slide(diagonalSteps)
+	}
+	return targets
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+// startFrom начинает игру с позиции fen.
+func startFrom(t *testing.T, fen string) *Game {
+	t.Helper()
+	game, err := NewGameFromFEN("Белые", "Черные", fen)
+	if err != nil {
+		t.Fatalf("NewGameFromFEN(%q): %v", fen, err)
+	}
+	game.Start()
+	return game
+}
+
+// play делает ходы, записанные в любой нотации, которую принимает ParseMoveText.
+func play(t *testing.T, game *Game, moves ...string) {
+	t.Helper()
+	for _, text := range moves {
+		move, err := game.ParseMoveText(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+}
+
+func TestGameEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		result GameResult
+		reason ResultReason
+	}{
+		{
+			name:   "детский мат",
+			fen:    StartFEN,
+			moves:  []string{"e4", "e5", "Bc4", "Nc6", "Qh5", "Nf6", "Qxf7"},
+			result: ResultWhiteWins,
+			reason: ReasonCheckmate,
+		},
+		{
+			name:   "дурацкий мат",
+			fen:    StartFEN,
+			moves:  []string{"f3", "e5", "g4", "Qh4"},
+			result: ResultBlackWins,
+			reason: ReasonCheckmate,
+		},
+		{
+			name:   "мат по последней горизонтали",
+			fen:    "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1",
+			moves:  []string{"Ra8"},
+			result: ResultWhiteWins,
+			reason: ReasonCheckmate,
+		},
+		{
+			name:   "пат",
+			fen:    "7k/8/6Q1/8/8/8/8/4K3 w - - 0 1",
+			moves:  []string{"Qf7"},
+			result: ResultDraw,
+			reason: ReasonStalemate,
+		},
+		{
+			name:   "шах без мата",
+			fen:    StartFEN,
+			moves:  []string{"e4", "f5", "Qh5"},
+			result: ResultNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			play(t, game, tt.moves...)
+			if game.Result != tt.result || game.ResultReason != tt.reason {
+				t.Fatalf("итог %q (%s), ожидается %q (%s)", game.Result, game.ResultReason, tt.result, tt.reason)
+			}
+			if finished := tt.result != ResultNone; game.IsFinished() != finished {
+				t.Errorf("IsFinished() = %v, ожидается %v", game.IsFinished(), finished)
+			}
+			if tt.reason == ReasonCheckmate && (!game.IsCheckmate() || game.Winner == nil) {
+				t.Errorf("IsCheckmate() = %v, победитель %v", game.IsCheckmate(), game.Winner)
+			}
+			if tt.reason == ReasonStalemate && !game.IsStalemate() {
+				t.Errorf("IsStalemate() = false после пата")
+			}
+		})
+	}
+}
+
+func TestKingSafety(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		move  string
+		legal bool
+	}{
+		{"связанный конь", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", "e2c3", false},
+		{"связанный конь вдоль связки", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", "e2c1", false},
+		{"король под бой", "4k3/8/8/8/8/8/3r4/4K3 w - - 0 1", "e1e2", false},
+		{"король бьет незащищенную ладью", "4k3/8/8/8/8/8/3r4/4K3 w - - 0 1", "e1d2", true},
+		{"ход, не защищающий от шаха", "4k3/4r3/8/8/8/8/8/R3K3 w - - 0 1", "a1a2", false},
+		{"ход мимо линии шаха", "4k3/4r3/8/8/8/8/8/3RK3 w - - 0 1", "d1d2", false},
+		{"перекрытие линии шаха ладьей", "4k3/4r3/8/8/8/8/3R4/4K3 w - - 0 1", "d2e2", true},
+		{"уход от шаха", "4k3/4r3/8/8/8/8/8/4K3 w - - 0 1", "e1d1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			move, err := game.ParseMoveText(tt.move)
+			if err == nil {
+				err = game.ValidateMove(move)
+			}
+			if tt.legal && err != nil {
+				t.Errorf("%s: %v, ожидается допустимый ход", tt.move, err)
+			}
+			var illegalErr *IllegalMoveError
+			if !tt.legal && !errors.As(err, &illegalErr) {
+				t.Errorf("%s: %v, ожидается IllegalMoveError", tt.move, err)
+			}
+		})
+	}
+}
+
+// perft считает листья дерева допустимых ходов глубины depth.
+func perft(g *Game, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := g.LegalMoves()
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, m := range moves {
+		g.Push(m)
+		nodes += perft(g, depth-1)
+		g.Pop()
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	// Эталонные числа — из таблиц perft шахматного программирования.
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		nodes int
+	}{
+		{"начальная позиция", StartFEN, 3, 8902},
+		{"kiwipete: рокировки, взятие на проходе, превращения", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 2, 2039},
+		{"эндшпиль со связками и взятием на проходе", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 3, 2812},
+		{"превращения со взятием", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3, 9467},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			if got := perft(game, tt.depth); got != tt.nodes {
+				t.Errorf("perft(%d) = %d, ожидается %d", tt.depth, got, tt.nodes)
+			}
+			if fen := game.FEN(); fen != tt.fen {
+				t.Errorf("после перебора позиция %q, ожидается %q", fen, tt.fen)
+			}
+		})
+	}
+}
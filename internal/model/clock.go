@@ -0,0 +1,204 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTimeControl = errors.New("неверный контроль времени")
+
+// TimePeriod — отрезок контроля времени: Time на Moves ходов.
+// Moves = 0 означает «до конца партии».
+type TimePeriod struct {
+	Moves int
+	Time  time.Duration
+}
+
+// TimeControl описывает контроль времени партии. Последний период с
+// ограничением по числу ходов повторяется, пока партия не закончится.
+type TimeControl struct {
+	Periods   []TimePeriod
+	Increment time.Duration // добавка Фишера после каждого хода
+	Delay     time.Duration // задержка Бронштейна: возвращается потраченное время, но не больше Delay
+}
+
+// ParseTimeControl разбирает запись вида «5+3» (минуты и добавка в секундах),
+// «5d3» (задержка Бронштейна 3 секунды) или «40/90:30+30» (90 минут на 40
+// ходов, затем 30 минут до конца партии, добавка 30 секунд).
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	spec := strings.TrimSpace(s)
+
+	if i := strings.IndexAny(spec, "+d"); i >= 0 {
+		seconds, err := strconv.ParseFloat(spec[i+1:], 64)
+		if err != nil || seconds < 0 {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+		}
+		if spec[i] == '+' {
+			tc.Increment = floatDuration(seconds, time.Second)
+		} else {
+			tc.Delay = floatDuration(seconds, time.Second)
+		}
+		spec = spec[:i]
+	}
+
+	for _, part := range strings.Split(spec, ":") {
+		var period TimePeriod
+		if moves, minutes, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(moves)
+			if err != nil || n <= 0 {
+				return TimeControl{}, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+			}
+			period.Moves = n
+			part = minutes
+		}
+		minutes, err := strconv.ParseFloat(part, 64)
+		if err != nil || minutes <= 0 {
+			return TimeControl{}, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+		}
+		period.Time = floatDuration(minutes, time.Minute)
+		tc.Periods = append(tc.Periods, period)
+	}
+	return tc, nil
+}
+
+func floatDuration(value float64, unit time.Duration) time.Duration {
+	return time.Duration(value * float64(unit))
+}
+
+// String возвращает контроль времени в записи, которую понимает ParseTimeControl.
+func (tc TimeControl) String() string {
+	parts := make([]string, 0, len(tc.Periods))
+	for _, period := range tc.Periods {
+		minutes := strconv.FormatFloat(period.Time.Minutes(), 'f', -1, 64)
+		if period.Moves > 0 {
+			minutes = strconv.Itoa(period.Moves) + "/" + minutes
+		}
+		parts = append(parts, minutes)
+	}
+	s := strings.Join(parts, ":")
+	switch {
+	case tc.Increment > 0:
+		s += "+" + strconv.FormatFloat(tc.Increment.Seconds(), 'f', -1, 64)
+	case tc.Delay > 0:
+		s += "d" + strconv.FormatFloat(tc.Delay.Seconds(), 'f', -1, 64)
+	}
+	return s
+}
+
+// periodBonus возвращает время следующего периода, если ход с номером
+// moveNumber (номер хода партии, как в FEN) завершает текущий период.
+func (tc TimeControl) periodBonus(moveNumber int) time.Duration {
+	total := 0
+	for i, period := range tc.Periods {
+		if period.Moves == 0 {
+			return 0
+		}
+		total += period.Moves
+		switch {
+		case moveNumber == total && i+1 < len(tc.Periods):
+			return tc.Periods[i+1].Time
+		case moveNumber < total:
+			return 0
+		}
+	}
+	// Последний период повторяется.
+	last := tc.Periods[len(tc.Periods)-1]
+	if (moveNumber-total)%last.Moves == 0 {
+		return last.Time
+	}
+	return 0
+}
+
+// Clock хранит оставшееся время сторон на момент начала текущего хода.
+type Clock struct {
+	Control TimeControl
+	White   time.Duration
+	Black   time.Duration
+}
+
+func NewClock(control TimeControl) *Clock {
+	var initial time.Duration
+	if len(control.Periods) > 0 {
+		initial = control.Periods[0].Time
+	}
+	return &Clock{Control: control, White: initial, Black: initial}
+}
+
+func (c *Clock) remaining(color PlayerColor) *time.Duration {
+	if color == White {
+		return &c.White
+	}
+	return &c.Black
+}
+
+// SetTimeControl включает шахматные часы; отсчет времени начинается сейчас.
+func (g *Game) SetTimeControl(control TimeControl) {
+	g.Clock = NewClock(control)
+	g.MoveStartTime = time.Now()
+	g.Touch()
+}
+
+// Resume продолжает отложенную партию: время текущего хода отсчитывается
+// заново, время, пока программа не работала, не учитывается.
+func (g *Game) Resume() {
+	g.MoveStartTime = time.Now()
+	g.Touch()
+}
+
+// TimeLeft возвращает время стороны на момент now: у игрока, чья очередь
+// хода, вычитается время, прошедшее с начала хода.
+func (g *Game) TimeLeft(color PlayerColor, now time.Time) time.Duration {
+	if g.Clock == nil {
+		return 0
+	}
+	left := *g.Clock.remaining(color)
+	if g.IsInProgress() && g.CurrentPlayer.Color == color && !g.MoveStartTime.IsZero() {
+		left -= now.Sub(g.MoveStartTime)
+	}
+	return max(left, 0)
+}
+
+// CheckFlag завершает игру, если у игрока, чья очередь хода, упал флажок:
+// поражение, либо ничья, если у соперника не хватает материала для мата.
+func (g *Game) CheckFlag(now time.Time) bool {
+	if g.Clock == nil || !g.IsInProgress() || g.TimeLeft(g.CurrentPlayer.Color, now) > 0 {
+		return false
+	}
+	*g.Clock.remaining(g.CurrentPlayer.Color) = 0
+	opponent := g.CurrentPlayer.Color.Opponent()
+	if g.Board.HasMatingMaterial(opponent) {
+		g.FinishWith(WinFor(opponent), ReasonTimeout)
+	} else {
+		g.FinishWith(ResultDraw, ReasonTimeout)
+	}
+	return true
+}
+
+// chargeClock списывает с часов сделавшего ход время, прошедшее с начала
+// хода, и начисляет добавку, задержку и время следующего периода.
+func (g *Game) chargeClock(move *Move, now time.Time) {
+	defer func() { g.MoveStartTime = now }()
+	if g.Clock == nil {
+		return
+	}
+	var spent time.Duration
+	if !g.MoveStartTime.IsZero() {
+		spent = now.Sub(g.MoveStartTime)
+	}
+
+	remaining := g.Clock.remaining(move.Player.Color)
+	move.PrevRemaining = *remaining
+	*remaining -= spent
+	*remaining += min(spent, g.Clock.Control.Delay) + g.Clock.Control.Increment
+
+	// Ход уже применен: после хода черных номер хода увеличен.
+	moveNumber := g.FullmoveNumber
+	if move.Player.IsBlack() {
+		moveNumber--
+	}
+	*remaining += g.Clock.Control.periodBonus(moveNumber)
+}
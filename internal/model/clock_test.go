@@ -0,0 +1,136 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodBonus(t *testing.T) {
+	tc, err := ParseTimeControl("40/90:20/60:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		moveNumber int
+		want       time.Duration
+	}{
+		{1, 0},
+		{39, 0},
+		{40, 60 * time.Minute},
+		{41, 0},
+		{60, 30 * time.Minute},
+		{61, 0},
+	}
+	for _, tt := range tests {
+		if got := tc.periodBonus(tt.moveNumber); got != tt.want {
+			t.Errorf("periodBonus(%d) = %v, ожидается %v", tt.moveNumber, got, tt.want)
+		}
+	}
+
+	repeating, err := ParseTimeControl("40/90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{40, 80, 120} {
+		if got := repeating.periodBonus(n); got != 90*time.Minute {
+			t.Errorf("повторяющийся период: periodBonus(%d) = %v, ожидается 1h30m", n, got)
+		}
+	}
+}
+
+func TestPeriodBonusFromFEN(t *testing.T) {
+	// Позиция на 40-м ходу: контроль наступает на первом же ходе каждой
+	// стороны, хотя в самой партии это их первые ходы.
+	game, err := NewGameFromFEN("Белые", "Черные", "4k3/8/8/8/8/8/8/R3K3 w - - 0 40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, err := ParseTimeControl("40/90:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.SetTimeControl(tc)
+	game.Start()
+
+	for _, text := range []string{"Ra2", "Kd7", "Ra3"} {
+		move, err := game.ParseMoveText(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	const period = 90 * time.Minute
+	if game.Clock.White <= period || game.Clock.White > period+30*time.Minute {
+		t.Errorf("у белых %v, ожидается 1h30m плюс 30m за 40-й ход", game.Clock.White)
+	}
+	if game.Clock.Black <= period || game.Clock.Black > period+30*time.Minute {
+		t.Errorf("у черных %v, ожидается 1h30m плюс 30m за 40-й ход", game.Clock.Black)
+	}
+}
+
+func TestChargeClock(t *testing.T) {
+	type step struct {
+		move  string
+		spent time.Duration
+		left  time.Duration // время сделавшего ход после хода
+	}
+	tests := []struct {
+		control string
+		steps   []step
+	}{
+		{"5+3", []step{
+			{"e4", 10 * time.Second, 293 * time.Second},
+			{"e5", 20 * time.Second, 283 * time.Second},
+			{"Nf3", time.Second, 295 * time.Second},
+			{"Nc6", 4 * time.Second, 282 * time.Second},
+		}},
+		// Задержка возвращает потраченное время, но не больше 3 секунд.
+		{"5d3", []step{
+			{"e4", 2 * time.Second, 300 * time.Second},
+			{"e5", 10 * time.Second, 293 * time.Second},
+			{"Nf3", 5 * time.Second, 298 * time.Second},
+			{"Nc6", time.Second, 293 * time.Second},
+		}},
+	}
+	// Время хода отсчитывается по настоящим часам, поэтому списывается
+	// чуть больше заданного.
+	const slack = 100 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.control, func(t *testing.T) {
+			game := startFrom(t, StartFEN)
+			control, err := ParseTimeControl(tt.control)
+			if err != nil {
+				t.Fatal(err)
+			}
+			game.SetTimeControl(control)
+
+			var before []Clock
+			for _, s := range tt.steps {
+				before = append(before, *game.Clock)
+				color := game.CurrentPlayer.Color
+				game.MoveStartTime = time.Now().Add(-s.spent)
+				play(t, game, s.move)
+				if got := *game.Clock.remaining(color); got > s.left || got < s.left-slack {
+					t.Errorf("после %s у %s %v, ожидается %v", s.move, color, got, s.left)
+				}
+			}
+
+			// Отмена возвращает часы сделавшего ход к времени до хода.
+			for i := len(tt.steps) - 1; i >= 0; i-- {
+				move, err := game.Undo()
+				if err != nil {
+					t.Fatal(err)
+				}
+				color := move.Player.Color
+				if got, want := *game.Clock.remaining(color), *before[i].remaining(color); got != want || move.PrevRemaining != want {
+					t.Errorf("после отмены %s у %s %v (PrevRemaining %v), ожидается %v", tt.steps[i].move, color, got, move.PrevRemaining, want)
+				}
+			}
+			if game.Clock.White != 5*time.Minute || game.Clock.Black != 5*time.Minute {
+				t.Errorf("после отмены всех ходов часы %v и %v, ожидается по 5m", game.Clock.White, game.Clock.Black)
+			}
+		})
+	}
+}
@@ -1,5 +1,39 @@
 package model
 
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
 type GameEntity interface {
 	EntityType() string
 }
+
+// Entity — общие поля сохраняемых сущностей: постоянный идентификатор и
+// время создания и последнего изменения.
+type Entity struct {
+	ID        string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewEntity создает поля сущности с новым идентификатором.
+func NewEntity() Entity {
+	now := time.Now()
+	return Entity{ID: NewID(), CreatedAt: now, UpdatedAt: now}
+}
+
+// NewID возвращает случайный идентификатор в формате UUID версии 4.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Touch отмечает, что сущность изменилась.
+func (e *Entity) Touch() {
+	e.UpdatedAt = time.Now()
+}
@@ -0,0 +1,249 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StartFEN — стандартная начальная позиция.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var ErrInvalidFEN = errors.New("некорректный FEN")
+
+var fenLetters = map[PieceKind]byte{
+	King:   'k',
+	Queen:  'q',
+	Rook:   'r',
+	Bishop: 'b',
+	Knight: 'n',
+	Pawn:   'p',
+}
+
+func fenLetter(p Piece) byte {
+	letter := fenLetters[p.Kind]
+	if p.Color == White {
+		return byte(unicode.ToUpper(rune(letter)))
+	}
+	return letter
+}
+
+func pieceFromFEN(letter rune) (Piece, bool) {
+	color := Black
+	if unicode.IsUpper(letter) {
+		color = White
+	}
+	lower := byte(unicode.ToLower(letter))
+	for kind, l := range fenLetters {
+		if l == lower {
+			return Piece{Kind: kind, Color: color}, true
+		}
+	}
+	return Piece{}, false
+}
+
+// PlacementFEN возвращает расстановку фигур в формате первого поля FEN.
+// Ряды перечисляются сверху вниз, как они хранятся в Cells.
+func (b *Board) PlacementFEN() string {
+	var sb strings.Builder
+	for row := 0; row < b.Size; row++ {
+		if row > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for col := 0; col < b.Size; col++ {
+			p, ok := ParsePiece(b.GetCell(row, col))
+			if !ok {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteByte(fenLetter(p))
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+	}
+	return sb.String()
+}
+
+// ParsePlacement строит доску по первому полю FEN. Размер доски равен
+// числу рядов; каждый ряд должен содержать столько же клеток.
+func ParsePlacement(placement string) (*Board, error) {
+	ranks := strings.Split(placement, "/")
+	board := NewBoard(len(ranks))
+	for row, rank := range ranks {
+		col := 0
+		runes := []rune(rank)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			if unicode.IsDigit(r) {
+				// Число пустых клеток может быть многозначным на больших досках.
+				j := i
+				for j < len(runes) && unicode.IsDigit(runes[j]) {
+					j++
+				}
+				n, _ := strconv.Atoi(string(runes[i:j]))
+				col += n
+				i = j - 1
+				continue
+			}
+			p, ok := pieceFromFEN(r)
+			if !ok {
+				return nil, fmt.Errorf("%w: неизвестная фигура %q", ErrInvalidFEN, r)
+			}
+			if col >= board.Size {
+				return nil, fmt.Errorf("%w: в ряду %d больше %d клеток", ErrInvalidFEN, board.Size-row, board.Size)
+			}
+			board.SetCell(row, col, p.Symbol())
+			col++
+		}
+		if col != board.Size {
+			return nil, fmt.Errorf("%w: в ряду %d должно быть %d клеток", ErrInvalidFEN, board.Size-row, board.Size)
+		}
+	}
+	return board, nil
+}
+
+// FEN возвращает текущую позицию игры в нотации Форсайта-Эдвардса.
+func (g *Game) FEN() string {
+	side := "w"
+	if g.CurrentPlayer != nil && g.CurrentPlayer.IsBlack() {
+		side = "b"
+	}
+
+	castling := ""
+	if g.Castling.WhiteKingSide {
+		castling += "K"
+	}
+	if g.Castling.WhiteQueenSide {
+		castling += "Q"
+	}
+	if g.Castling.BlackKingSide {
+		castling += "k"
+	}
+	if g.Castling.BlackQueenSide {
+		castling += "q"
+	}
+	if castling == "" {
+		castling = "-"
+	}
+
+	enPassant := "-"
+	if g.EnPassant != nil {
+		enPassant = g.EnPassant.Square(g.Board.Size)
+	}
+
+	return strings.Join([]string{
+		g.Board.PlacementFEN(),
+		side,
+		castling,
+		enPassant,
+		strconv.Itoa(g.HalfmoveClock),
+		strconv.Itoa(g.FullmoveNumber),
+	}, " ")
+}
+
+// LoadFEN заменяет позицию игры на заданную. Счетчики ходов можно опустить,
+// тогда используются значения 0 и 1.
+func (g *Game) LoadFEN(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return fmt.Errorf("%w: ожидается не менее 4 полей, получено %d", ErrInvalidFEN, len(fields))
+	}
+
+	board, err := ParsePlacement(fields[0])
+	if err != nil {
+		return err
+	}
+
+	var current *Player
+	switch fields[1] {
+	case "w":
+		current = g.WhitePlayer
+	case "b":
+		current = g.BlackPlayer
+	default:
+		return fmt.Errorf("%w: очередь хода должна быть w или b", ErrInvalidFEN)
+	}
+
+	var castling CastlingRights
+	if fields[2] != "-" {
+		for _, r := range fields[2] {
+			switch r {
+			case 'K':
+				castling.WhiteKingSide = true
+			case 'Q':
+				castling.WhiteQueenSide = true
+			case 'k':
+				castling.BlackKingSide = true
+			case 'q':
+				castling.BlackQueenSide = true
+			default:
+				return fmt.Errorf("%w: неизвестное право на рокировку %q", ErrInvalidFEN, r)
+			}
+		}
+	}
+
+	var enPassant *Position
+	if fields[3] != "-" {
+		pos, ok := ParseSquare(fields[3], board.Size)
+		if !ok {
+			return fmt.Errorf("%w: неверное поле взятия на проходе %s", ErrInvalidFEN, fields[3])
+		}
+		enPassant = &pos
+	}
+
+	halfmove, fullmove := 0, 1
+	if len(fields) > 4 {
+		if halfmove, err = strconv.Atoi(fields[4]); err != nil || halfmove < 0 {
+			return fmt.Errorf("%w: неверный счетчик полуходов %s", ErrInvalidFEN, fields[4])
+		}
+	}
+	if len(fields) > 5 {
+		if fullmove, err = strconv.Atoi(fields[5]); err != nil || fullmove < 1 {
+			return fmt.Errorf("%w: неверный номер хода %s", ErrInvalidFEN, fields[5])
+		}
+	}
+
+	g.Board = board
+	g.CurrentPlayer = current
+	g.Castling = castling
+	g.EnPassant = enPassant
+	g.HalfmoveClock = halfmove
+	g.FullmoveNumber = fullmove
+	g.initialKey = g.positionKey()
+	g.StartFEN = g.FEN()
+	return nil
+}
+
+// NewGameFromFEN создает игру, начинающуюся с позиции, заданной в FEN.
+func NewGameFromFEN(whitePlayerName, blackPlayerName, fen string) (*Game, error) {
+	game := NewGame(whitePlayerName, blackPlayerName, 0)
+	if err := game.LoadFEN(fen); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// ParseSquare разбирает имя клетки вида e4 на доске указанного размера.
+func ParseSquare(square string, boardSize int) (Position, bool) {
+	if len(square) < 2 {
+		return Position{}, false
+	}
+	col := int(unicode.ToLower(rune(square[0])) - 'a')
+	rank, err := strconv.Atoi(square[1:])
+	if err != nil {
+		return Position{}, false
+	}
+	pos := Position{Row: boardSize - rank, Col: col}
+	if pos.Row < 0 || pos.Row >= boardSize || col < 0 || col >= boardSize {
+		return Position{}, false
+	}
+	return pos, true
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"начальная позиция", StartFEN},
+		{"ход черных и поле взятия на проходе", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{"частичные права на рокировку", "r3k2r/8/8/8/8/8/8/R3K2R w Kq - 5 23"},
+		{"без рокировок", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"},
+		{"большие счетчики", "4k3/8/8/8/8/8/8/4K2R b - - 99 150"},
+		{"доска 10×10", "k9/10/10/10/10/10/10/10/10/9K w - - 0 1"},
+		{"многозначный пропуск", "k8r/10/10/10/10/10/10/10/10/R8K b - - 3 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := NewGameFromFEN("Белые", "Черные", tt.fen)
+			if err != nil {
+				t.Fatalf("NewGameFromFEN() = %v", err)
+			}
+			if got := game.FEN(); got != tt.fen {
+				t.Errorf("FEN() = %q, ожидается %q", got, tt.fen)
+			}
+			if game.StartFEN != tt.fen {
+				t.Errorf("StartFEN = %q, ожидается %q", game.StartFEN, tt.fen)
+			}
+			board, err := ParsePlacement(game.Board.PlacementFEN())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if board.Size != game.Board.Size || board.PlacementFEN() != game.Board.PlacementFEN() {
+				t.Errorf("расстановка не пережила повторный разбор: %q", board.PlacementFEN())
+			}
+		})
+	}
+}
+
+func TestFENAfterMoves(t *testing.T) {
+	tests := []struct {
+		moves []string
+		want  string
+	}{
+		{[]string{"e4"}, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{[]string{"e4", "c5"}, "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"},
+		{[]string{"e4", "c5", "Nf3"}, "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"},
+		{[]string{"Nf3", "Nf6", "Rg1"}, "rnbqkb1r/pppppppp/5n2/8/8/5N2/PPPPPPPP/RNBQKBR1 b Qkq - 3 2"},
+	}
+	for _, tt := range tests {
+		game := startFrom(t, StartFEN)
+		play(t, game, tt.moves...)
+		if got := game.FEN(); got != tt.want {
+			t.Errorf("после %v: FEN() = %q, ожидается %q", tt.moves, got, tt.want)
+		}
+		if _, err := NewGameFromFEN("Белые", "Черные", game.FEN()); err != nil {
+			t.Errorf("FEN после %v не читается: %v", tt.moves, err)
+		}
+	}
+}
+
+func TestLoadFENErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"мало полей", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w"},
+		{"неизвестная фигура", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1"},
+		{"короткий ряд", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN w KQkq - 0 1"},
+		{"длинный ряд", "rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"очередь хода", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1"},
+		{"право на рокировку", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1"},
+		{"поле взятия на проходе", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq z9 0 1"},
+		{"отрицательный счетчик", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1"},
+		{"нулевой номер хода", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGameFromFEN("Белые", "Черные", tt.fen); !errors.Is(err, ErrInvalidFEN) {
+				t.Errorf("NewGameFromFEN(%q) = %v, ожидается ErrInvalidFEN", tt.fen, err)
+			}
+		})
+	}
+}
+
+func TestLoadFENDefaultsCounters(t *testing.T) {
+	game, err := NewGameFromFEN("Белые", "Черные", "4k3/8/8/8/8/8/8/4K3 b -  -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "4k3/8/8/8/8/8/8/4K3 b - - 0 1"; game.FEN() != want {
+		t.Errorf("FEN() = %q, ожидается %q", game.FEN(), want)
+	}
+}
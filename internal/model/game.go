@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
@@ -14,18 +15,30 @@ const (
 )
 
 type Game struct {
-	WhitePlayer   *Player
-	BlackPlayer   *Player
-	Board         *Board
-	Moves         []*Move
-	CurrentPlayer *Player
-	Status        GameStatus
-	Winner        *Player
-	Mu            sync.RWMutex
-	LastMoveTime  time.Duration
-	LastWhiteTime time.Duration
-	LastBlackTime time.Duration
-	MoveStartTime time.Time
+	Entity
+	WhitePlayer    *Player
+	BlackPlayer    *Player
+	Board          *Board
+	Moves          []*Move
+	CurrentPlayer  *Player
+	Status         GameStatus
+	Winner         *Player
+	Result         GameResult
+	ResultReason   ResultReason
+	HalfmoveClock  int // полуходы с последнего взятия или хода пешкой
+	FullmoveNumber int // номер хода, увеличивается после хода черных
+	Castling       CastlingRights
+	EnPassant      *Position // поле, через которое пешка только что прошла на две клетки
+	Mu             sync.RWMutex
+	LastMoveTime   time.Duration
+	LastWhiteTime  time.Duration
+	LastBlackTime  time.Duration
+	MoveStartTime  time.Time // начало текущего хода, от него считается время на часах
+	Clock          *Clock    // шахматные часы; nil — партия без контроля времени
+	StartFEN       string    // позиция, с которой началась игра
+	StartedAt      time.Time
+	initialKey     string
+	undone         []*Move // отмененные ходы для Redo, последний — на вершине
 }
 
 func NewGame(whitePlayerName, blackPlayerName string, boardSize int) *Game {
@@ -34,17 +47,30 @@ func NewGame(whitePlayerName, blackPlayerName string, boardSize int) *Game {
 	board := NewBoard(boardSize)
 
 	return &Game{
-		WhitePlayer:   whitePlayer,
-		BlackPlayer:   blackPlayer,
-		Board:         board,
-		Moves:         make([]*Move, 0),
-		CurrentPlayer: whitePlayer,
-		Status:        StatusNotStarted,
+		Entity:         NewEntity(),
+		WhitePlayer:    whitePlayer,
+		BlackPlayer:    blackPlayer,
+		Board:          board,
+		Moves:          make([]*Move, 0),
+		CurrentPlayer:  whitePlayer,
+		Status:         StatusNotStarted,
+		Castling:       AllCastlingRights(),
+		FullmoveNumber: 1,
 	}
 }
 
+// Start начинает игру и запоминает начальную позицию, если её еще не задал LoadFEN.
 func (g *Game) Start() {
 	g.Status = StatusInProgress
+	if g.StartedAt.IsZero() {
+		g.StartedAt = time.Now()
+	}
+	g.MoveStartTime = time.Now()
+	g.Touch()
+	if g.StartFEN == "" {
+		g.StartFEN = g.FEN()
+		g.initialKey = g.positionKey()
+	}
 }
 
 func (g *Game) IsInProgress() bool {
@@ -55,22 +81,203 @@ func (g *Game) IsFinished() bool {
 	return g.Status == StatusFinished
 }
 
-func (g *Game) MakeMove(move *Move) bool {
+// MakeMove проверяет ход по правилам, применяет его к доске и передает ход сопернику.
+// Если после хода наступил мат, пат или автоматическая ничья, игра завершается.
+// Новый ход сбрасывает список отмененных ходов, доступных для Redo.
+func (g *Game) MakeMove(move *Move) error {
+	if err := g.playMove(move); err != nil {
+		return err
+	}
+	g.undone = nil
+	return nil
+}
+
+func (g *Game) playMove(move *Move) error {
 	if !g.IsInProgress() {
-		return false
+		return ErrGameNotInProgress
+	}
+	now := time.Now()
+	if g.CheckFlag(now) {
+		return ErrTimeExpired
 	}
 
-	if move.Player != g.CurrentPlayer {
-		return false
+	if err := g.ValidateMove(move); err != nil {
+		return err
 	}
 
-	if !move.IsValid(g.Board) {
-		return false
+	san := g.sanFor(move)
+	g.apply(move)
+	g.chargeClock(move, now)
+	move.positionKey = g.positionKey()
+	g.finishIfOver()
+	move.SAN = san + g.sanSuffix()
+
+	if move.ID == "" {
+		move.Entity = NewEntity()
+	}
+	move.GameID = g.ID
+	move.Ply = len(g.Moves)
+	g.Touch()
+	return nil
+}
+
+// apply применяет уже проверенный ход и запоминает в нем все, что нужно для отмены.
+func (g *Game) apply(move *Move) {
+	move.Piece = g.Board.GetCell(move.From.Row, move.From.Col)
+	if piece, _ := ParsePiece(move.Piece); piece.Kind == Pawn &&
+		move.To.Row == promotionRow(g.Board, piece.Color) && move.Promotion == "" {
+		move.Promotion = Queen
+	}
+	move.CapturedAt = move.To
+	if isEnPassantMove(g.Board, move) {
+		move.CapturedAt = Position{Row: move.From.Row, Col: move.To.Col}
+	}
+	move.Captured = g.Board.GetCell(move.CapturedAt.Row, move.CapturedAt.Col)
+	move.UCI = move.CoordinateNotation(g.Board.Size)
+
+	move.PrevCastling = g.Castling
+	move.PrevEnPassant = g.EnPassant
+	move.PrevHalfmoveClock = g.HalfmoveClock
+	if piece, _ := ParsePiece(move.Piece); piece.Kind == Pawn || move.Captured != "" {
+		g.HalfmoveClock = 0
+	} else {
+		g.HalfmoveClock++
 	}
 
+	g.updateSpecialState(move)
+	g.Board.applyMove(move)
 	g.Moves = append(g.Moves, move)
+	if move.Player.IsBlack() {
+		g.FullmoveNumber++
+	}
 	g.SwitchPlayer()
-	return true
+}
+
+// unapply возвращает доску и состояние игры к позиции до последнего хода.
+func (g *Game) unapply() *Move {
+	move := g.Moves[len(g.Moves)-1]
+	g.Moves = g.Moves[:len(g.Moves)-1]
+
+	board := g.Board
+	if p, _ := ParsePiece(move.Piece); p.Kind == King && abs(move.To.Col-move.From.Col) == castlingKingShift {
+		rookCol := queenSideRookCol
+		if move.To.Col > move.From.Col {
+			rookCol = kingSideRookCol
+		}
+		rookFrom := (move.From.Col + move.To.Col) / 2
+		board.SetCell(move.From.Row, rookCol, board.GetCell(move.From.Row, rookFrom))
+		board.SetCell(move.From.Row, rookFrom, "")
+	}
+	board.SetCell(move.To.Row, move.To.Col, "")
+	board.SetCell(move.CapturedAt.Row, move.CapturedAt.Col, move.Captured)
+	board.SetCell(move.From.Row, move.From.Col, move.Piece)
+
+	g.Castling = move.PrevCastling
+	g.EnPassant = move.PrevEnPassant
+	g.HalfmoveClock = move.PrevHalfmoveClock
+	if move.Player.IsBlack() {
+		g.FullmoveNumber--
+	}
+	g.CurrentPlayer = move.Player
+	return move
+}
+
+// Push применяет ход, полученный из LegalMoves, без повторной проверки и без
+// определения конца игры. Нужен для перебора вариантов; отменяется через Pop.
+func (g *Game) Push(move *Move) {
+	g.apply(move)
+}
+
+// Pop отменяет ход, сделанный через Push.
+func (g *Game) Pop() *Move {
+	return g.unapply()
+}
+
+// Clone возвращает независимую копию игры для анализа позиции.
+// Игроки и записи прошлых ходов общие с оригиналом.
+func (g *Game) Clone() *Game {
+	clone := &Game{
+		Entity:         g.Entity,
+		WhitePlayer:    g.WhitePlayer,
+		BlackPlayer:    g.BlackPlayer,
+		Board:          g.Board.Clone(),
+		Moves:          append([]*Move(nil), g.Moves...),
+		CurrentPlayer:  g.CurrentPlayer,
+		Status:         g.Status,
+		Winner:         g.Winner,
+		Result:         g.Result,
+		ResultReason:   g.ResultReason,
+		HalfmoveClock:  g.HalfmoveClock,
+		FullmoveNumber: g.FullmoveNumber,
+		Castling:       g.Castling,
+		StartFEN:       g.StartFEN,
+		StartedAt:      g.StartedAt,
+		MoveStartTime:  g.MoveStartTime,
+		initialKey:     g.initialKey,
+	}
+	if g.Clock != nil {
+		clock := *g.Clock
+		clock.Control.Periods = slices.Clone(g.Clock.Control.Periods)
+		clone.Clock = &clock
+	}
+	if g.EnPassant != nil {
+		enPassant := *g.EnPassant
+		clone.EnPassant = &enPassant
+	}
+	return clone
+}
+
+// CanUndo сообщает, можно ли отменить последний ход: игра идет или
+// закончилась из-за самого хода (мат, пат, автоматическая ничья).
+func (g *Game) CanUndo() bool {
+	if len(g.Moves) == 0 {
+		return false
+	}
+	if g.IsInProgress() {
+		return true
+	}
+	switch g.ResultReason {
+	case ReasonCheckmate, ReasonStalemate, ReasonFiftyMoves,
+		ReasonThreefoldRepetition, ReasonInsufficientMaterial:
+		return true
+	}
+	return false
+}
+
+// Undo отменяет последний ход и возвращает его. Отмененный ход можно
+// вернуть через Redo, пока не сделан новый ход.
+func (g *Game) Undo() (*Move, error) {
+	if !g.CanUndo() {
+		return nil, ErrNothingToUndo
+	}
+	if g.IsFinished() {
+		g.Status = StatusInProgress
+		g.Result = ResultNone
+		g.ResultReason = ""
+		g.Winner = nil
+	}
+	move := g.unapply()
+	// У ходов, восстановленных из хранилища, время до хода неизвестно.
+	if g.Clock != nil && move.PrevRemaining > 0 {
+		*g.Clock.remaining(move.Player.Color) = move.PrevRemaining
+	}
+	g.MoveStartTime = time.Now()
+	g.Touch()
+	g.undone = append(g.undone, move)
+	return move, nil
+}
+
+// Redo повторяет последний отмененный ход.
+func (g *Game) Redo() (*Move, error) {
+	if len(g.undone) == 0 {
+		return nil, ErrNothingToRedo
+	}
+	move := g.undone[len(g.undone)-1]
+	if err := g.playMove(move); err != nil {
+		return nil, err
+	}
+	g.undone = g.undone[:len(g.undone)-1]
+	return move, nil
 }
 
 func (g *Game) SwitchPlayer() {
@@ -83,6 +290,7 @@ func (g *Game) SwitchPlayer() {
 
 func (g *Game) Finish() {
 	g.Status = StatusFinished
+	g.Touch()
 }
 
 func (g *Game) GetMoveHistory() []*Move {
@@ -98,10 +306,5 @@ func (g *Game) EntityType() string {
 }
 
 func (g *Game) Resign() {
-	if g.CurrentPlayer == g.WhitePlayer {
-		g.Winner = g.BlackPlayer
-	} else {
-		g.Winner = g.WhitePlayer
-	}
-	g.Finish()
+	g.FinishWith(WinFor(g.CurrentPlayer.Color.Opponent()), ReasonResignation)
 }
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRedo(t *testing.T) {
+	game := startFrom(t, StartFEN)
+	play(t, game, "e4", "e5", "Nf3")
+	final := game.FEN()
+	for range 2 {
+		if _, err := game.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"e5", "Nf3"} {
+		move, err := game.Redo()
+		if err != nil {
+			t.Fatalf("Redo() = %v, ожидается %s", err, want)
+		}
+		if move.GetNotation() != want || game.Moves[len(game.Moves)-1] != move {
+			t.Errorf("Redo() = %s, ожидается %s последним ходом партии", move.GetNotation(), want)
+		}
+	}
+	if got := game.FEN(); got != final {
+		t.Errorf("после повтора %s, ожидается %s", got, final)
+	}
+	if _, err := game.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo() без отмененных ходов = %v, ожидается ErrNothingToRedo", err)
+	}
+}
+
+func TestRedoAfterNewMove(t *testing.T) {
+	game := startFrom(t, StartFEN)
+	play(t, game, "e4", "e5")
+	if _, err := game.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	// Новый ход вместо отмененного: повторять больше нечего.
+	play(t, game, "d5")
+	if _, err := game.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo() после нового хода = %v, ожидается ErrNothingToRedo", err)
+	}
+	if n := game.GetMoveCount(); n != 2 || game.Moves[1].GetNotation() != "d5" {
+		t.Errorf("ходов %d, последний %s; ожидается e4 d5", n, game.Moves[n-1].GetNotation())
+	}
+}
+
+func TestRedoMate(t *testing.T) {
+	game := startFrom(t, "7k/8/5K2/8/8/8/8/6Q1 w - - 0 1")
+	play(t, game, "Qg7#")
+	if _, err := game.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if !game.IsInProgress() {
+		t.Fatalf("после отмены мата партия не продолжается: %q", game.Result)
+	}
+	if _, err := game.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if game.Result != ResultWhiteWins || game.ResultReason != ReasonCheckmate {
+		t.Errorf("после повтора итог %q (%s), ожидается мат", game.Result, game.ResultReason)
+	}
+}
@@ -1,15 +1,47 @@
 package model
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StandardBoardSize — размер обычной шахматной доски.
+const StandardBoardSize = 8
+
 type Position struct {
 	Row int
 	Col int
 }
 
+// Square возвращает имя клетки в шахматной нотации (например, e4).
+// Строка 0 — верхний ряд доски, поэтому номер ряда считается снизу.
+func (p Position) Square(boardSize int) string {
+	return string(rune('a'+p.Col)) + strconv.Itoa(boardSize-p.Row)
+}
+
 type Move struct {
-	From   Position
-	To     Position
-	Player *Player
-	Piece  string
+	Entity           // идентификатор и время создания появляются в MakeMove
+	GameID    string // партия, в которой сделан ход
+	Ply       int    // номер полухода в партии, начиная с 1
+	From      Position
+	To        Position
+	Player    *Player
+	Piece     string
+	Promotion PieceKind     // фигура, в которую превращается пешка; пусто для обычного хода
+	Captured  string        // взятая фигура; пусто, если ход без взятия
+	SAN       string        // запись в стандартной алгебраической нотации, заполняется в MakeMove
+	UCI       string        // запись в координатной нотации UCI (e2e4, e7e8q), заполняется в MakeMove
+	ThinkTime time.Duration // время на обдумывание хода; 0 — не измерено
+
+	// Состояние до хода, нужное для его отмены; заполняется в MakeMove.
+	CapturedAt        Position // клетка взятой фигуры, при взятии на проходе отличается от To
+	PrevCastling      CastlingRights
+	PrevEnPassant     *Position
+	PrevHalfmoveClock int
+	PrevRemaining     time.Duration // время на часах сделавшего ход до хода
+
+	positionKey string
 }
 
 func NewMove(fromRow, fromCol, toRow, toCol int, player *Player, piece string) *Move {
@@ -28,20 +60,31 @@ func NewMove(fromRow, fromCol, toRow, toCol int, player *Player, piece string) *
 }
 
 func (m *Move) IsValid(board *Board) bool {
-	return board.IsValidPosition(m.From.Row, m.From.Col) &&
-		board.IsValidPosition(m.To.Row, m.To.Col)
+	return m.Validate(board) == nil
 }
 
 func (m *Move) EntityType() string {
 	return "Move"
 }
 
+// GetNotation возвращает запись хода для истории и журналов: SAN, если ход
+// прошел через MakeMove, иначе координатную запись.
 func (m *Move) GetNotation() string {
-	cols := "abcdefgh"
-	if m.From.Col < len(cols) && m.To.Col < len(cols) {
-		return string(cols[m.From.Col]) + string(rune('1'+m.From.Row)) +
-			"-" +
-			string(cols[m.To.Col]) + string(rune('1'+m.To.Row))
+	switch {
+	case m.SAN != "":
+		return m.SAN
+	case m.UCI != "":
+		return m.UCI
+	}
+	// Старые записи без SAN и UCI относятся к стандартной доске.
+	return m.CoordinateNotation(StandardBoardSize)
+}
+
+// CoordinateNotation возвращает ход в координатной нотации UCI: e2e4, e7e8q.
+func (m *Move) CoordinateNotation(boardSize int) string {
+	notation := m.From.Square(boardSize) + m.To.Square(boardSize)
+	if m.Promotion != "" {
+		notation += strings.ToLower(kindLetters[m.Promotion])
 	}
-	return ""
+	return notation
 }
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const pgnDateLayout = "2006.01.02"
+
+// pgnLineWidth — максимальная длина строки с ходами по стандарту PGN.
+const pgnLineWidth = 79
+
+// pgnReasonTag — собственный тег с точной причиной окончания партии: в
+// стандартном Termination сдача, мат и ничья по соглашению одинаково
+// «normal».
+const pgnReasonTag = "ResultReason"
+
+var (
+	pgnTagPattern      = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+	pgnMoveNumber      = regexp.MustCompile(`^[0-9]+\.+`)
+	pgnResultsByString = map[string]GameResult{
+		"1-0":     ResultWhiteWins,
+		"0-1":     ResultBlackWins,
+		"1/2-1/2": ResultDraw,
+		"*":       ResultNone,
+	}
+)
+
+// PGN возвращает партию в формате Portable Game Notation.
+func (g *Game) PGN() string {
+	date := "????.??.??"
+	if !g.StartedAt.IsZero() {
+		date = g.StartedAt.Format(pgnDateLayout)
+	}
+	result := pgnResult(g.Result)
+
+	var sb strings.Builder
+	writeTag := func(name, value string) {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", name, value)
+	}
+	writeTag("Event", "Casual game")
+	writeTag("Site", "go_hw")
+	writeTag("Date", date)
+	writeTag("Round", "-")
+	writeTag("White", g.WhitePlayer.Name)
+	writeTag("Black", g.BlackPlayer.Name)
+	writeTag("Result", result)
+	if g.StartFEN != "" && g.StartFEN != StartFEN {
+		writeTag("SetUp", "1")
+		writeTag("FEN", g.StartFEN)
+	}
+	if g.ResultReason != "" {
+		writeTag("Termination", pgnTermination(g.ResultReason))
+		writeTag(pgnReasonTag, string(g.ResultReason))
+	}
+	sb.WriteByte('\n')
+
+	number, blackToMove := 1, false
+	if fields := strings.Fields(g.StartFEN); len(fields) == 6 {
+		number, _ = strconv.Atoi(fields[5])
+		blackToMove = fields[1] == "b"
+	}
+
+	var tokens []string
+	for i, move := range g.Moves {
+		switch {
+		case !blackToMove:
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		case i == 0:
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, move.SAN)
+		if blackToMove {
+			number++
+		}
+		blackToMove = !blackToMove
+	}
+	tokens = append(tokens, result)
+
+	lineLen := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLen+1+len(token) > pgnLineWidth {
+				sb.WriteByte('\n')
+				lineLen = 0
+			} else {
+				sb.WriteByte(' ')
+				lineLen++
+			}
+		}
+		sb.WriteString(token)
+		lineLen += len(token)
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+func pgnResult(result GameResult) string {
+	if result == ResultNone {
+		return "*"
+	}
+	return string(result)
+}
+
+// pgnTermination возвращает стандартное значение тега Termination.
+func pgnTermination(reason ResultReason) string {
+	if reason == ReasonTimeout {
+		return "time forfeit"
+	}
+	return "normal"
+}
+
+// pgnReason определяет причину окончания партии, которая не закончилась
+// на доске: по собственному тегу, иначе по Termination. Обычное окончание
+// с результатом — сдача, а при ничьей — соглашение. Раньше в Termination
+// писалась сама причина, такие файлы тоже читаются.
+func pgnReason(tags map[string]string, result GameResult) ResultReason {
+	if reason := ResultReason(tags[pgnReasonTag]); reason.IsValid() {
+		return reason
+	}
+	termination := strings.ToLower(tags["Termination"])
+	switch {
+	case ResultReason(termination).IsValid():
+		return ResultReason(termination)
+	case termination == "time forfeit":
+		return ReasonTimeout
+	case termination == "normal" && result == ResultDraw:
+		return ReasonAgreement
+	case termination == "normal":
+		return ReasonResignation
+	}
+	return ""
+}
+
+// ParsePGN читает одну партию в формате PGN и воспроизводит её ходы через
+// MakeMove, поэтому каждый ход проверяется по правилам.
+func ParsePGN(text string) (*Game, error) {
+	tags := map[string]string{}
+	var movetext strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "%") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			parts := pgnTagPattern.FindStringSubmatch(line)
+			if parts == nil {
+				return nil, fmt.Errorf("неверный тег PGN: %s", line)
+			}
+			value := strings.ReplaceAll(parts[2], `\"`, `"`)
+			tags[parts[1]] = strings.ReplaceAll(value, `\\`, `\`)
+			continue
+		}
+		movetext.WriteString(line)
+		movetext.WriteByte('\n')
+	}
+
+	game := NewGame(tags["White"], tags["Black"], 0)
+	fen := StartFEN
+	if tags["FEN"] != "" {
+		fen = tags["FEN"]
+	}
+	if err := game.LoadFEN(fen); err != nil {
+		return nil, err
+	}
+	if date, err := time.Parse(pgnDateLayout, tags["Date"]); err == nil {
+		game.StartedAt = date
+	}
+	game.Start()
+
+	result := ResultNone
+	if r, ok := pgnResultsByString[tags["Result"]]; ok {
+		result = r
+	}
+	for _, token := range pgnMovetextTokens(movetext.String()) {
+		if r, ok := pgnResultsByString[token]; ok {
+			result = r
+			break
+		}
+		token = pgnMoveNumber.ReplaceAllString(token, "")
+		if token == "" {
+			continue
+		}
+		if !game.IsInProgress() {
+			return nil, fmt.Errorf("ход %s после окончания партии", token)
+		}
+		move, err := game.ParseSAN(token)
+		if err != nil {
+			return nil, fmt.Errorf("ход %d (%s): %w", game.GetMoveCount()+1, token, err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			return nil, fmt.Errorf("ход %d (%s): %w", game.GetMoveCount()+1, token, err)
+		}
+	}
+
+	// Партия могла закончиться сдачей или по времени — это видно только из результата.
+	if game.IsInProgress() && result != ResultNone {
+		game.FinishWith(result, pgnReason(tags, result))
+	}
+	return game, nil
+}
+
+// pgnMovetextTokens разбивает текст ходов на лексемы, отбрасывая
+// комментарии, варианты и числовые аннотации ($1).
+func pgnMovetextTokens(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	depth := 0
+	inComment, inLineComment := false, false
+	for _, r := range text {
+		switch {
+		case inLineComment:
+			if r == '\n' {
+				inLineComment = false
+			}
+		case inComment:
+			if r == '}' {
+				inComment = false
+			}
+		case r == '{':
+			flush()
+			inComment = true
+		case r == ';':
+			flush()
+			inLineComment = true
+		case r == '(':
+			flush()
+			depth++
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case r == ' ' || r == '\n' || r == '\t' || r == '\r':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	result := tokens[:0]
+	for _, token := range tokens {
+		if !strings.HasPrefix(token, "$") {
+			result = append(result, token)
+		}
+	}
+	return result
+}
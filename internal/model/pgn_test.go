@@ -0,0 +1,218 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sanHistory возвращает записи ходов партии в SAN.
+func sanHistory(g *Game) []string {
+	var moves []string
+	for _, m := range g.Moves {
+		moves = append(moves, m.SAN)
+	}
+	return moves
+}
+
+func TestPGNRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		finish func(*Game)
+	}{
+		{
+			name:  "мат",
+			fen:   StartFEN,
+			moves: []string{"e4", "e5", "Bc4", "Nc6", "Qh5", "Nf6", "Qxf7"},
+		},
+		{
+			name:   "сдача",
+			fen:    StartFEN,
+			moves:  []string{"d4", "d5", "c4", "e6", "Nc3", "Nf6"},
+			finish: (*Game).Resign,
+		},
+		{
+			name:   "ничья по соглашению",
+			fen:    StartFEN,
+			moves:  []string{"e4", "c5"},
+			finish: (*Game).AgreeDraw,
+		},
+		{
+			name:   "по времени",
+			fen:    StartFEN,
+			moves:  []string{"e4"},
+			finish: func(g *Game) { g.FinishWith(ResultWhiteWins, ReasonTimeout) },
+		},
+		{
+			name:  "незаконченная партия",
+			fen:   StartFEN,
+			moves: []string{"Nf3"},
+		},
+		{
+			name:  "с позиции, где ходят черные",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 4 30",
+			moves: []string{"O-O-O", "O-O", "Rd2", "Rf2"},
+		},
+		{
+			name:  "превращение",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			moves: []string{"a8=Q+", "Kd7", "Qb7+"},
+		},
+		{
+			name: "оперная партия Морфи",
+			fen:  StartFEN,
+			moves: []string{
+				"e4", "e5", "Nf3", "d6", "d4", "Bg4", "dxe5", "Bxf3", "Qxf3", "dxe5",
+				"Bc4", "Nf6", "Qb3", "Qe7", "Nc3", "c6", "Bg5", "b5", "Nxb5", "cxb5",
+				"Bxb5+", "Nbd7", "O-O-O", "Rd8", "Rxd7", "Rxd7", "Rd1", "Qe6", "Bxd7+", "Nxd7",
+				"Qb8+", "Nxb8", "Rd8#",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := startFrom(t, tt.fen)
+			play(t, original, tt.moves...)
+			if tt.finish != nil {
+				tt.finish(original)
+			}
+
+			pgn := original.PGN()
+			for _, line := range strings.Split(pgn, "\n") {
+				if len(line) > pgnLineWidth {
+					t.Errorf("строка длиннее %d символов: %q", pgnLineWidth, line)
+				}
+			}
+
+			parsed, err := ParsePGN(pgn)
+			if err != nil {
+				t.Fatalf("ParsePGN() = %v\n%s", err, pgn)
+			}
+			if got, want := sanHistory(parsed), sanHistory(original); !slices.Equal(got, want) {
+				t.Errorf("ходы %v, ожидаются %v", got, want)
+			}
+			if parsed.FEN() != original.FEN() {
+				t.Errorf("позиция %q, ожидается %q", parsed.FEN(), original.FEN())
+			}
+			if parsed.Result != original.Result || parsed.ResultReason != original.ResultReason {
+				t.Errorf("итог %q (%s), ожидается %q (%s)",
+					parsed.Result, parsed.ResultReason, original.Result, original.ResultReason)
+			}
+			if parsed.WhitePlayer.Name != "Белые" || parsed.BlackPlayer.Name != "Черные" {
+				t.Errorf("игроки %q и %q", parsed.WhitePlayer.Name, parsed.BlackPlayer.Name)
+			}
+			if again := parsed.PGN(); again != pgn {
+				t.Errorf("повторный экспорт отличается:\n%s\nожидается:\n%s", again, pgn)
+			}
+		})
+	}
+}
+
+func TestParsePGN(t *testing.T) {
+	tests := []struct {
+		name    string
+		pgn     string
+		moves   []string
+		result  GameResult
+		wantErr bool
+	}{
+		{
+			name: "комментарии, варианты и аннотации",
+			pgn: `[Event "Тест"]
+[White "Анна"]
+[Black "Борис"]
+[Result "1-0"]
+
+1. e4 {лучший ход} e5 (1... c5 2. Nf3) 2. Nf3 $1 Nc6 ; строчный комментарий
+3. Bb5 1-0`,
+			moves:  []string{"e4", "e5", "Nf3", "Nc6", "Bb5"},
+			result: ResultWhiteWins,
+		},
+		{
+			name:   "номер хода вплотную к ходу",
+			pgn:    "1.e4 e5 2.Nf3 Nc6 *",
+			moves:  []string{"e4", "e5", "Nf3", "Nc6"},
+			result: ResultNone,
+		},
+		{
+			name:    "недопустимый ход",
+			pgn:     "1. e4 e5 2. Ke3 *",
+			wantErr: true,
+		},
+		{
+			name:    "ход после мата",
+			pgn:     "1. f3 e5 2. g4 Qh4# 3. a3 0-1",
+			wantErr: true,
+		},
+		{
+			name:    "битый тег",
+			pgn:     "[White Анна]\n\n1. e4 *",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParsePGN(tt.pgn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePGN() принял партию: %v", sanHistory(game))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePGN() = %v", err)
+			}
+			var got []string
+			for _, m := range game.Moves {
+				got = append(got, strings.TrimRight(m.SAN, "+#"))
+			}
+			if !slices.Equal(got, tt.moves) {
+				t.Errorf("ходы %v, ожидаются %v", got, tt.moves)
+			}
+			if game.Result != tt.result {
+				t.Errorf("итог %q, ожидается %q", game.Result, tt.result)
+			}
+		})
+	}
+}
+
+func TestPGNTermination(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   string
+		result string
+		reason ResultReason
+	}{
+		{"сдача", `[Termination "normal"]`, "0-1", ReasonResignation},
+		{"ничья по соглашению", `[Termination "normal"]`, "1/2-1/2", ReasonAgreement},
+		{"по времени", `[Termination "time forfeit"]`, "1-0", ReasonTimeout},
+		{"ничья по времени", `[Termination "time forfeit"]`, "1/2-1/2", ReasonTimeout},
+		{"точная причина в своем теге", `[Termination "normal"]` + "\n" + `[ResultReason "agreement"]`, "1-0", ReasonAgreement},
+		{"причина в Termination из старых версий", `[Termination "resignation"]`, "1-0", ReasonResignation},
+		{"неизвестное окончание", `[Termination "abandoned"]`, "1-0", ""},
+		{"без Termination", "", "1-0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgn := tt.tags + "\n\n1. e4 e5 " + tt.result
+			game, err := ParsePGN(pgn)
+			if err != nil {
+				t.Fatalf("ParsePGN() = %v\n%s", err, pgn)
+			}
+			if string(game.Result) != tt.result || game.ResultReason != tt.reason {
+				t.Errorf("итог %q (%s), ожидается %q (%s)", game.Result, game.ResultReason, tt.result, tt.reason)
+			}
+		})
+	}
+
+	mate := startFrom(t, StartFEN)
+	play(t, mate, "f3", "e5", "g4", "Qh4#")
+	pgn := mate.PGN()
+	for _, tag := range []string{`[Termination "normal"]`, `[ResultReason "checkmate"]`} {
+		if !strings.Contains(pgn, tag) {
+			t.Errorf("в PGN нет %s:\n%s", tag, pgn)
+		}
+	}
+}
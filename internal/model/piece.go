@@ -0,0 +1,81 @@
+package model
+
+type PieceKind string
+
+const (
+	King   PieceKind = "king"
+	Queen  PieceKind = "queen"
+	Rook   PieceKind = "rook"
+	Bishop PieceKind = "bishop"
+	Knight PieceKind = "knight"
+	Pawn   PieceKind = "pawn"
+)
+
+type Piece struct {
+	Kind  PieceKind
+	Color PlayerColor
+}
+
+var pieceSymbols = map[PlayerColor]map[PieceKind]string{
+	White: {
+		King:   "♔",
+		Queen:  "♕",
+		Rook:   "♖",
+		Bishop: "♗",
+		Knight: "♘",
+		Pawn:   "♙",
+	},
+	Black: {
+		King:   "♚",
+		Queen:  "♛",
+		Rook:   "♜",
+		Bishop: "♝",
+		Knight: "♞",
+		Pawn:   "♟",
+	},
+}
+
+var symbolPieces = func() map[string]Piece {
+	result := make(map[string]Piece)
+	for color, kinds := range pieceSymbols {
+		for kind, symbol := range kinds {
+			result[symbol] = Piece{Kind: kind, Color: color}
+		}
+	}
+	return result
+}()
+
+var pieceLetters = map[byte]PieceKind{
+	'K': King,
+	'Q': Queen,
+	'R': Rook,
+	'B': Bishop,
+	'N': Knight,
+}
+
+// PieceKindFromLetter возвращает фигуру по английской букве нотации (K, Q, R, B, N).
+func PieceKindFromLetter(letter byte) (PieceKind, bool) {
+	kind, ok := pieceLetters[letter]
+	return kind, ok
+}
+
+// ParsePiece возвращает фигуру по её символу на доске.
+func ParsePiece(symbol string) (Piece, bool) {
+	p, ok := symbolPieces[symbol]
+	return p, ok
+}
+
+func PieceSymbol(kind PieceKind, color PlayerColor) string {
+	return pieceSymbols[color][kind]
+}
+
+func (p Piece) Symbol() string {
+	return PieceSymbol(p.Kind, p.Color)
+}
+
+func (c PlayerColor) Opponent() PlayerColor {
+	if c == White {
+		return Black
+	}
+	return White
+}
@@ -8,9 +8,11 @@ const (
 )
 
 type Player struct {
-	Name   string
-	Color  PlayerColor
-	Symbol string // King symbol for the player (♔ or ♚)
+	Entity
+	Name      string
+	Color     PlayerColor
+	Symbol    string // King symbol for the player (♔ or ♚)
+	ProfileID string // Profile of the person playing this color in the game
 }
 
 func NewPlayer(name string, color PlayerColor) *Player {
@@ -20,6 +22,7 @@ func NewPlayer(name string, color PlayerColor) *Player {
 	}
 
 	return &Player{
+		Entity: NewEntity(),
 		Name:   name,
 		Color:  color,
 		Symbol: symbol,
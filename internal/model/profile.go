@@ -0,0 +1,108 @@
+package model
+
+import (
+	"math"
+	"sync"
+)
+
+// InitialRating — рейтинг Эло нового игрока.
+const InitialRating = 1500
+
+// Profile — постоянный профиль игрока: один на человека, независимо от того,
+// каким цветом он играет в конкретной партии. Player партии ссылается на
+// профиль через ProfileID.
+type Profile struct {
+	Entity
+	Name   string
+	Rating int
+	Wins   int
+	Losses int
+	Draws  int
+	Mu     sync.RWMutex
+}
+
+func NewProfile(name string) *Profile {
+	return &Profile{Entity: NewEntity(), Name: name, Rating: InitialRating}
+}
+
+func (p *Profile) EntityType() string {
+	return "Profile"
+}
+
+// GamesPlayed возвращает число оцененных партий; вызывающий держит p.Mu.
+func (p *Profile) GamesPlayed() int {
+	return p.Wins + p.Losses + p.Draws
+}
+
+// RatingChange — изменение рейтинга игрока после партии.
+type RatingChange struct {
+	Entity
+	ProfileID string
+	GameID    string
+	Before    int
+	After     int
+	Score     float64 // 1 — победа, 0.5 — ничья, 0 — поражение
+}
+
+func (c *RatingChange) EntityType() string {
+	return "RatingChange"
+}
+
+// kFactor возвращает коэффициент K по правилам FIDE: 40 для первых 30
+// партий, 10 для рейтинга от 2400, иначе 20.
+func kFactor(p *Profile) float64 {
+	switch {
+	case p.GamesPlayed() < 30:
+		return 40
+	case p.Rating >= 2400:
+		return 10
+	}
+	return 20
+}
+
+// ExpectedScore возвращает ожидаемый результат игрока с рейтингом rating
+// против соперника с рейтингом opponent.
+func ExpectedScore(rating, opponent int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponent-rating)/400))
+}
+
+// RateGame пересчитывает рейтинги и счет побед, поражений и ничьих после
+// партии с результатом result и возвращает изменения рейтингов белого и
+// черного. Вызывающий держит Mu обоих профилей.
+func RateGame(white, black *Profile, result GameResult, gameID string) (*RatingChange, *RatingChange) {
+	whiteScore := 0.5
+	switch result {
+	case ResultWhiteWins:
+		whiteScore = 1
+	case ResultBlackWins:
+		whiteScore = 0
+	}
+	// Оба изменения считаются по рейтингам до партии.
+	whiteExpected := ExpectedScore(white.Rating, black.Rating)
+	whiteDelta := int(math.Round(kFactor(white) * (whiteScore - whiteExpected)))
+	blackDelta := int(math.Round(kFactor(black) * (whiteExpected - whiteScore)))
+
+	return applyRating(white, whiteScore, whiteDelta, gameID), applyRating(black, 1-whiteScore, blackDelta, gameID)
+}
+
+func applyRating(p *Profile, score float64, delta int, gameID string) *RatingChange {
+	change := &RatingChange{
+		Entity:    NewEntity(),
+		ProfileID: p.ID,
+		GameID:    gameID,
+		Before:    p.Rating,
+		After:     p.Rating + delta,
+		Score:     score,
+	}
+	p.Rating = change.After
+	switch score {
+	case 1:
+		p.Wins++
+	case 0:
+		p.Losses++
+	default:
+		p.Draws++
+	}
+	p.Touch()
+	return change
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"strings"
+)
+
+type GameResult string
+
+const (
+	ResultNone      GameResult = ""
+	ResultWhiteWins GameResult = "1-0"
+	ResultBlackWins GameResult = "0-1"
+	ResultDraw      GameResult = "1/2-1/2"
+)
+
+type ResultReason string
+
+const (
+	ReasonCheckmate            ResultReason = "checkmate"
+	ReasonResignation          ResultReason = "resignation"
+	ReasonStalemate            ResultReason = "stalemate"
+	ReasonFiftyMoves           ResultReason = "fifty_moves"
+	ReasonThreefoldRepetition  ResultReason = "threefold_repetition"
+	ReasonInsufficientMaterial ResultReason = "insufficient_material"
+	ReasonAgreement            ResultReason = "agreement"
+	ReasonTimeout              ResultReason = "timeout"
+)
+
+func (r ResultReason) IsValid() bool {
+	switch r {
+	case ReasonCheckmate, ReasonResignation, ReasonStalemate, ReasonFiftyMoves,
+		ReasonThreefoldRepetition, ReasonInsufficientMaterial, ReasonAgreement, ReasonTimeout:
+		return true
+	}
+	return false
+}
+
+// fiftyMoveLimit — число полуходов без взятий и ходов пешкой, после которого партия — ничья.
+const fiftyMoveLimit = 100
+
+func WinFor(color PlayerColor) GameResult {
+	if color == White {
+		return ResultWhiteWins
+	}
+	return ResultBlackWins
+}
+
+// FinishWith завершает игру с указанным результатом и причиной.
+func (g *Game) FinishWith(result GameResult, reason ResultReason) {
+	g.Result = result
+	g.ResultReason = reason
+	switch result {
+	case ResultWhiteWins:
+		g.Winner = g.WhitePlayer
+	case ResultBlackWins:
+		g.Winner = g.BlackPlayer
+	default:
+		g.Winner = nil
+	}
+	g.Finish()
+}
+
+// AgreeDraw завершает игру ничьей по соглашению сторон.
+func (g *Game) AgreeDraw() {
+	g.FinishWith(ResultDraw, ReasonAgreement)
+}
+
+// finishIfOver проверяет позицию после хода и завершает игру при мате, пате
+// или автоматической ничьей.
+func (g *Game) finishIfOver() {
+	if !g.hasLegalMoves() {
+		if g.IsCheck() {
+			g.FinishWith(WinFor(g.CurrentPlayer.Color.Opponent()), ReasonCheckmate)
+		} else {
+			g.FinishWith(ResultDraw, ReasonStalemate)
+		}
+		return
+	}
+
+	switch {
+	case g.Board.IsInsufficientMaterial():
+		g.FinishWith(ResultDraw, ReasonInsufficientMaterial)
+	case g.HalfmoveClock >= fiftyMoveLimit:
+		g.FinishWith(ResultDraw, ReasonFiftyMoves)
+	case g.RepetitionCount() >= 3:
+		g.FinishWith(ResultDraw, ReasonThreefoldRepetition)
+	}
+}
+
+// RepetitionCount возвращает, сколько раз текущая позиция встречалась в партии.
+// Учитываются только ходы после последнего взятия или хода пешкой: более ранние
+// позиции повториться уже не могут.
+func (g *Game) RepetitionCount() int {
+	current := g.positionKey()
+	count := 1
+	keys := make([]string, 0, len(g.Moves)+1)
+	keys = append(keys, g.initialKey)
+	for _, m := range g.Moves {
+		keys = append(keys, m.positionKey)
+	}
+	// Последний ключ — это текущая позиция, её уже посчитали.
+	window := keys[:len(keys)-1]
+	if g.HalfmoveClock < len(window) {
+		window = window[len(window)-g.HalfmoveClock:]
+	}
+	for _, key := range window {
+		if key == current {
+			count++
+		}
+	}
+	return count
+}
+
+// positionKey описывает позицию для сравнения повторений: расстановку,
+// очередь хода, права на рокировку и возможность взятия на проходе.
+func (g *Game) positionKey() string {
+	var sb strings.Builder
+	for _, row := range g.Board.Cells {
+		for _, cell := range row {
+			if cell == "" {
+				sb.WriteByte('.')
+			} else {
+				sb.WriteString(cell)
+			}
+		}
+		sb.WriteByte('/')
+	}
+	if g.CurrentPlayer != nil {
+		sb.WriteString(string(g.CurrentPlayer.Color))
+	}
+	c := g.Castling
+	for _, allowed := range []bool{c.WhiteKingSide, c.WhiteQueenSide, c.BlackKingSide, c.BlackQueenSide} {
+		if allowed {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	if g.EnPassant != nil && g.canCaptureEnPassant() {
+		sb.WriteString(g.EnPassant.Square(g.Board.Size))
+	}
+	return sb.String()
+}
+
+func (g *Game) canCaptureEnPassant() bool {
+	if g.CurrentPlayer == nil {
+		return false
+	}
+	color := g.CurrentPlayer.Color
+	row := g.EnPassant.Row - pawnDirection(color)
+	pawn := PieceSymbol(Pawn, color)
+	return g.Board.GetCell(row, g.EnPassant.Col-1) == pawn || g.Board.GetCell(row, g.EnPassant.Col+1) == pawn
+}
+
+// IsInsufficientMaterial сообщает, что ни одна сторона не может поставить мат:
+// остались только короли, одна легкая фигура или слоны на полях одного цвета.
+func (b *Board) IsInsufficientMaterial() bool {
+	minors := 0
+	bishopSquares := map[int]bool{}
+	knights := 0
+	for row := 0; row < b.Size; row++ {
+		for col := 0; col < b.Size; col++ {
+			p, ok := ParsePiece(b.GetCell(row, col))
+			if !ok {
+				continue
+			}
+			switch p.Kind {
+			case Pawn, Rook, Queen:
+				return false
+			case Bishop:
+				minors++
+				bishopSquares[(row+col)%2] = true
+			case Knight:
+				minors++
+				knights++
+			}
+		}
+	}
+	return minors <= 1 || (knights == 0 && len(bishopSquares) == 1)
+}
+
+// HasMatingMaterial сообщает, может ли сторона в принципе поставить мат
+// хотя бы при содействии соперника. Мат невозможен, только если у стороны
+// остался один король или на доске в целом не хватает материала
+// (IsInsufficientMaterial). Уже одинокий конь или слон матует, когда
+// у соперника есть фигуры или пешки, загораживающие его короля.
+func (b *Board) HasMatingMaterial(color PlayerColor) bool {
+	for row := 0; row < b.Size; row++ {
+		for col := 0; col < b.Size; col++ {
+			p, ok := ParsePiece(b.GetCell(row, col))
+			if ok && p.Color == color && p.Kind != King {
+				return !b.IsInsufficientMaterial()
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHasMatingMaterial(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement string
+		color     PlayerColor
+		want      bool
+	}{
+		{"один король", "4k3/8/8/8/8/8/8/4K3", White, false},
+		{"король против ферзя", "4k3/8/8/8/8/8/8/3QK3", Black, false},
+		{"слон против голого короля", "4k3/8/8/8/8/8/8/2B1K3", White, false},
+		{"конь против голого короля", "4k3/8/8/8/8/8/8/1N2K3", White, false},
+		{"конь против пешки", "4k3/4p3/8/8/8/8/8/1N2K3", White, true},
+		{"слон против ладьи", "3rk3/8/8/8/8/8/8/2B1K3", White, true},
+		{"слоны на полях одного цвета", "4kb2/8/8/8/8/8/8/2B1K3", White, false},
+		{"слоны на полях разного цвета", "2b1k3/8/8/8/8/8/8/2B1K3", White, true},
+		{"два коня", "4k3/8/8/8/8/8/8/1N2K1N1", White, true},
+		{"ладья", "4k3/8/8/8/8/8/8/R3K3", White, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := ParsePlacement(tt.placement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := board.HasMatingMaterial(tt.color); got != tt.want {
+				t.Errorf("HasMatingMaterial(%s) = %v, ожидается %v", tt.color, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutomaticDraws(t *testing.T) {
+	knightShuffle := []string{"Nf3", "Nf6", "Ng1", "Ng8"}
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		reason ResultReason // пустая — партия продолжается
+	}{
+		{
+			name:   "правило 50 ходов",
+			fen:    "4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80",
+			moves:  []string{"Ra2"},
+			reason: ReasonFiftyMoves,
+		},
+		{
+			name:  "ход пешкой сбрасывает счетчик",
+			fen:   "4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80",
+			moves: []string{"e4"},
+		},
+		{
+			name:  "взятие сбрасывает счетчик",
+			fen:   "4k3/8/8/8/8/8/r3P3/R3K3 w - - 99 80",
+			moves: []string{"Rxa2", "Kd7"},
+		},
+		{
+			name:   "троекратное повторение",
+			fen:    StartFEN,
+			moves:  append(append([]string{}, knightShuffle...), knightShuffle...),
+			reason: ReasonThreefoldRepetition,
+		},
+		{
+			name:  "двукратное повторение",
+			fen:   StartFEN,
+			moves: knightShuffle,
+		},
+		{
+			// Ходы короля теряют право рокировки: позиция с тем же
+			// расположением фигур уже другая.
+			name:  "повторение расстановки без прав рокировки",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves: []string{"Kf1", "Kf8", "Ke1", "Ke8", "Kf1", "Kf8", "Ke1", "Ke8"},
+		},
+		{
+			name:   "король против короля",
+			fen:    "4k3/8/8/8/8/8/3r4/4K3 w - - 0 1",
+			moves:  []string{"Kxd2"},
+			reason: ReasonInsufficientMaterial,
+		},
+		{
+			name:   "король и конь против короля",
+			fen:    "4k3/8/8/8/8/8/3r4/4KN2 w - - 0 1",
+			moves:  []string{"Kxd2"},
+			reason: ReasonInsufficientMaterial,
+		},
+		{
+			name:   "слоны на полях одного цвета",
+			fen:    "4kb2/8/8/8/8/8/3r4/2B1K3 w - - 0 1",
+			moves:  []string{"Kxd2"},
+			reason: ReasonInsufficientMaterial,
+		},
+		{
+			name:  "слоны на полях разного цвета",
+			fen:   "2b1k3/8/8/8/8/8/3r4/2B1K3 w - - 0 1",
+			moves: []string{"Kxd2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			play(t, game, tt.moves...)
+			if game.ResultReason != tt.reason {
+				t.Fatalf("причина %q, ожидается %q", game.ResultReason, tt.reason)
+			}
+			if tt.reason != "" && (game.Result != ResultDraw || game.Winner != nil) {
+				t.Errorf("итог %q, победитель %v, ожидается ничья", game.Result, game.Winner)
+			}
+			if tt.reason == "" && !game.IsInProgress() {
+				t.Errorf("партия закончилась: %q", game.Result)
+			}
+		})
+	}
+}
+
+func TestCheckFlag(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		result GameResult
+	}{
+		{"у соперника ладья", "4k3/8/8/8/8/8/8/R3K3 b - - 0 1", ResultWhiteWins},
+		{"у соперника голый король", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", ResultDraw},
+		{"конь против пешки", "4k3/4p3/8/8/8/8/8/1N2K3 b - - 0 1", ResultWhiteWins},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			control, err := ParseTimeControl("1+0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			game.SetTimeControl(control)
+			if !game.CheckFlag(game.MoveStartTime.Add(2 * time.Minute)) {
+				t.Fatal("CheckFlag() = false после истечения времени")
+			}
+			if game.Result != tt.result || game.ResultReason != ReasonTimeout {
+				t.Errorf("итог %q (%s), ожидается %q (%s)", game.Result, game.ResultReason, tt.result, ReasonTimeout)
+			}
+		})
+	}
+}
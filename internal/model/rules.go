@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrGameNotInProgress = errors.New("игра не идет")
+	ErrNothingToUndo     = errors.New("нет ходов для отмены")
+	ErrNothingToRedo     = errors.New("нет отмененных ходов для повтора")
+	ErrTimeExpired       = errors.New("время на часах истекло")
+)
+
+// IllegalMoveError описывает, почему ход нарушает правила.
+type IllegalMoveError struct {
+	Move   *Move
+	Reason string
+}
+
+func (e *IllegalMoveError) Error() string {
+	return "недопустимый ход: " + e.Reason
+}
+
+func illegal(move *Move, format string, args ...any) error {
+	return &IllegalMoveError{Move: move, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Validate проверяет ход по правилам движения фигуры, стоящей на начальной клетке.
+func (m *Move) Validate(board *Board) error {
+	if !board.IsValidPosition(m.From.Row, m.From.Col) || !board.IsValidPosition(m.To.Row, m.To.Col) {
+		return illegal(m, "координаты вне доски")
+	}
+	if m.From == m.To {
+		return illegal(m, "фигура должна сдвинуться")
+	}
+
+	symbol := board.GetCell(m.From.Row, m.From.Col)
+	if symbol == "" {
+		return illegal(m, "на начальной клетке нет фигуры")
+	}
+	piece, ok := ParsePiece(symbol)
+	if !ok {
+		return illegal(m, "неизвестная фигура %s", symbol)
+	}
+	if m.Player != nil && piece.Color != m.Player.Color {
+		return illegal(m, "нельзя ходить фигурой соперника")
+	}
+
+	if target, ok := ParsePiece(board.GetCell(m.To.Row, m.To.Col)); ok && target.Color == piece.Color {
+		return illegal(m, "клетка занята своей фигурой")
+	}
+
+	dr := m.To.Row - m.From.Row
+	dc := m.To.Col - m.From.Col
+
+	switch piece.Kind {
+	case Pawn:
+		return m.validatePawn(board, piece, dr, dc)
+	case Knight:
+		if abs(dr)*abs(dc) != 2 {
+			return illegal(m, "конь ходит буквой «Г»")
+		}
+	case Bishop:
+		if abs(dr) != abs(dc) {
+			return illegal(m, "слон ходит только по диагонали")
+		}
+		return m.validatePath(board, dr, dc)
+	case Rook:
+		if dr != 0 && dc != 0 {
+			return illegal(m, "ладья ходит только по вертикали и горизонтали")
+		}
+		return m.validatePath(board, dr, dc)
+	case Queen:
+		if dr != 0 && dc != 0 && abs(dr) != abs(dc) {
+			return illegal(m, "ферзь ходит по прямой или по диагонали")
+		}
+		return m.validatePath(board, dr, dc)
+	case King:
+		if abs(dr) > 1 || abs(dc) > 1 {
+			return illegal(m, "король ходит только на одну клетку")
+		}
+	}
+	return nil
+}
+
+func (m *Move) validatePawn(board *Board, piece Piece, dr, dc int) error {
+	dir := pawnDirection(piece.Color)
+	target := board.GetCell(m.To.Row, m.To.Col)
+
+	switch {
+	case dc == 0 && dr == dir:
+		if target != "" {
+			return illegal(m, "пешка не может бить вперед")
+		}
+	case dc == 0 && dr == 2*dir:
+		if m.From.Row != pawnStartRow(board, piece.Color) {
+			return illegal(m, "пешка ходит на две клетки только с начальной позиции")
+		}
+		if target != "" || board.GetCell(m.From.Row+dir, m.From.Col) != "" {
+			return illegal(m, "путь пешки перекрыт")
+		}
+	case abs(dc) == 1 && dr == dir:
+		if target == "" {
+			return illegal(m, "пешка ходит по диагонали только со взятием")
+		}
+	default:
+		return illegal(m, "пешка так не ходит")
+	}
+	return nil
+}
+
+func (m *Move) validatePath(board *Board, dr, dc int) error {
+	stepRow, stepCol := sign(dr), sign(dc)
+	row, col := m.From.Row+stepRow, m.From.Col+stepCol
+	for row != m.To.Row || col != m.To.Col {
+		if board.GetCell(row, col) != "" {
+			return illegal(m, "путь перекрыт фигурой на %s", Position{Row: row, Col: col}.Square(board.Size))
+		}
+		row += stepRow
+		col += stepCol
+	}
+	return nil
+}
+
+// pawnDirection возвращает направление движения пешки по строкам доски:
+// белые стоят внизу (строка Size-1) и идут к строке 0, черные — наоборот.
+func pawnDirection(color PlayerColor) int {
+	if color == White {
+		return -1
+	}
+	return 1
+}
+
+func pawnStartRow(board *Board, color PlayerColor) int {
+	if color == White {
+		return board.Size - 2
+	}
+	return 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+// moveOn строит ход фигуры, стоящей на клетке from, в обозначениях вида «e2».
+func moveOn(t *testing.T, board *Board, from, to string) *Move {
+	t.Helper()
+	f, ok := ParseSquare(from, board.Size)
+	if !ok {
+		t.Fatalf("неверная клетка %q", from)
+	}
+	target, ok := ParseSquare(to, board.Size)
+	if !ok {
+		t.Fatalf("неверная клетка %q", to)
+	}
+	return NewMove(f.Row, f.Col, target.Row, target.Col, nil, board.GetCell(f.Row, f.Col))
+}
+
+func TestMoveValidate(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR"
+	tests := []struct {
+		name      string
+		placement string
+		from, to  string
+		legal     bool
+	}{
+		{"пешка на одно поле", start, "e2", "e3", true},
+		{"пешка на два поля с начальной", start, "e2", "e4", true},
+		{"пешка на пять полей", start, "e2", "e7", false},
+		{"пешка назад", "4k3/8/8/8/4P3/8/8/4K3", "e4", "e3", false},
+		{"пешка на два поля не с начальной", "4k3/8/8/8/8/4P3/8/4K3", "e3", "e5", false},
+		{"пешка бьет вперед", "4k3/8/8/8/4p3/4P3/8/4K3", "e3", "e4", false},
+		{"пешка перепрыгивает фигуру", "4k3/8/8/8/8/4n3/4P3/4K3", "e2", "e4", false},
+		{"пешка бьет по диагонали", "4k3/8/8/8/3p4/4P3/8/4K3", "e3", "d4", true},
+		{"пешка идет по диагонали без взятия", start, "e2", "d3", false},
+		{"черная пешка идет вниз", start, "d7", "d5", true},
+		{"конь прыгает через фигуры", start, "g1", "f3", true},
+		{"конь не буквой Г", start, "g1", "g3", false},
+		{"слон по диагонали", "4k3/8/8/8/8/8/8/2B1K3", "c1", "h6", true},
+		{"слон через фигуру", start, "c1", "e3", false},
+		{"слон по вертикали", "4k3/8/8/8/8/8/8/2B1K3", "c1", "c5", false},
+		{"ладья по вертикали", "4k3/8/8/8/8/8/8/R3K3", "a1", "a8", true},
+		{"ладья по диагонали", "4k3/8/8/8/8/8/8/R3K3", "a1", "c3", false},
+		{"ладья через фигуру", "4k3/8/8/8/8/8/8/R3K3", "a1", "f1", false},
+		{"ферзь по диагонали", "4k3/8/8/8/8/8/8/3QK3", "d1", "h5", true},
+		{"ферзь буквой Г", "4k3/8/8/8/8/8/8/3QK3", "d1", "e3", false},
+		{"король на одно поле", "4k3/8/8/8/8/8/8/4K3", "e1", "f2", true},
+		{"король на два поля без рокировки", "4k3/8/8/8/8/8/8/4K3", "e1", "e3", false},
+		{"взятие своей фигуры", start, "d1", "d2", false},
+		{"пустая клетка", start, "e4", "e5", false},
+		{"ход на месте", start, "e2", "e2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := ParsePlacement(tt.placement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = moveOn(t, board, tt.from, tt.to).Validate(board)
+			if tt.legal && err != nil {
+				t.Errorf("%s-%s: %v, ожидается допустимый ход", tt.from, tt.to, err)
+			}
+			var illegalErr *IllegalMoveError
+			if !tt.legal && !errors.As(err, &illegalErr) {
+				t.Errorf("%s-%s: %v, ожидается IllegalMoveError", tt.from, tt.to, err)
+			}
+		})
+	}
+}
+
+func TestMoveValidateOpponentPiece(t *testing.T) {
+	game := startFrom(t, StartFEN)
+	move := moveOn(t, game.Board, "e7", "e5")
+	move.Player = game.WhitePlayer
+	if err := move.Validate(game.Board); err == nil {
+		t.Errorf("белые сходили черной пешкой")
+	}
+	if err := game.MakeMove(move); err == nil {
+		t.Errorf("MakeMove принял ход фигурой соперника")
+	}
+}
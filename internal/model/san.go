@@ -0,0 +1,204 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var sanPattern = regexp.MustCompile(`^([KQRBN])?([a-wyz])?([0-9]+)?(x)?([a-z])([0-9]+)(?:=?([QRBN]))?$`)
+
+var kindLetters = map[PieceKind]string{
+	King:   "K",
+	Queen:  "Q",
+	Rook:   "R",
+	Bishop: "B",
+	Knight: "N",
+}
+
+// sanFor строит запись хода в стандартной алгебраической нотации без
+// признаков шаха и мата. Вызывается до применения хода к доске.
+func (g *Game) sanFor(move *Move) string {
+	board := g.Board
+	if isCastlingMove(board, move) {
+		if move.To.Col > move.From.Col {
+			return "O-O"
+		}
+		return "O-O-O"
+	}
+
+	piece, _ := ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+	capture := board.GetCell(move.To.Row, move.To.Col) != "" || isEnPassantMove(board, move)
+	fromSquare := move.From.Square(board.Size)
+
+	var sb strings.Builder
+	if piece.Kind == Pawn {
+		if capture {
+			sb.WriteByte(fromSquare[0])
+		}
+	} else {
+		sb.WriteString(kindLetters[piece.Kind])
+		sb.WriteString(g.disambiguation(move, piece))
+	}
+	if capture {
+		sb.WriteByte('x')
+	}
+	sb.WriteString(move.To.Square(board.Size))
+	if move.Promotion != "" {
+		sb.WriteString("=" + kindLetters[move.Promotion])
+	}
+	return sb.String()
+}
+
+// disambiguation возвращает вертикаль, горизонталь или клетку, с которой
+// пошла фигура, если на то же поле может пойти другая такая же фигура.
+func (g *Game) disambiguation(move *Move, piece Piece) string {
+	sameFile, sameRank, others := false, false, false
+	for _, other := range g.LegalMoves() {
+		if other.To != move.To || other.From == move.From {
+			continue
+		}
+		if p, _ := ParsePiece(g.Board.GetCell(other.From.Row, other.From.Col)); p != piece {
+			continue
+		}
+		others = true
+		if other.From.Col == move.From.Col {
+			sameFile = true
+		}
+		if other.From.Row == move.From.Row {
+			sameRank = true
+		}
+	}
+
+	square := move.From.Square(g.Board.Size)
+	switch {
+	case !others:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	}
+	return square
+}
+
+// sanSuffix возвращает признак шаха или мата для позиции после хода.
+func (g *Game) sanSuffix() string {
+	switch {
+	case g.ResultReason == ReasonCheckmate:
+		return "#"
+	case g.IsCheck():
+		return "+"
+	}
+	return ""
+}
+
+var coordinatePattern = regexp.MustCompile(`^([KQRBN])?([a-zA-Z])([0-9]+)[-x:]?([a-zA-Z])([0-9]+)(?:=?([QRBNqrbn]))?$`)
+
+// ParseMoveText разбирает ход текущего игрока в любой из поддерживаемых
+// записей: SAN (Nf3, exd5, O-O), длинная алгебраическая (Ng1-f3, e7-e8=Q)
+// и UCI (e2e4, e7e8q).
+func (g *Game) ParseMoveText(text string) (*Move, error) {
+	trimmed := strings.TrimRight(strings.TrimSpace(text), "+#!?")
+	parts := coordinatePattern.FindStringSubmatch(trimmed)
+	// Без буквы фигуры заглавная вертикаль — скорее фигура в SAN с
+	// уточнением горизонталью (R1a3, N5f4), чем клетка.
+	if parts == nil || parts[1] == "" && (unicode.IsUpper(rune(parts[2][0])) || unicode.IsUpper(rune(parts[4][0]))) {
+		return g.ParseSAN(text)
+	}
+
+	from, okFrom := ParseSquare(strings.ToLower(parts[2])+parts[3], g.Board.Size)
+	to, okTo := ParseSquare(strings.ToLower(parts[4])+parts[5], g.Board.Size)
+	if !okFrom || !okTo {
+		return g.ParseSAN(text)
+	}
+
+	piece := g.Board.GetCell(from.Row, from.Col)
+	if piece == "" {
+		return nil, fmt.Errorf("на клетке %s нет фигуры", from.Square(g.Board.Size))
+	}
+	if parts[1] != "" {
+		kind, _ := PieceKindFromLetter(parts[1][0])
+		if p, _ := ParsePiece(piece); p.Kind != kind {
+			return nil, fmt.Errorf("на клетке %s нет фигуры %s", from.Square(g.Board.Size), parts[1])
+		}
+	}
+
+	move := NewMove(from.Row, from.Col, to.Row, to.Col, g.CurrentPlayer, piece)
+	if parts[6] != "" {
+		move.Promotion, _ = PieceKindFromLetter(strings.ToUpper(parts[6])[0])
+	}
+	return move, nil
+}
+
+// ParseSAN находит допустимый ход текущего игрока по записи в стандартной
+// алгебраической нотации, например Nf3, exd5, Rad1, e8=Q или O-O.
+func (g *Game) ParseSAN(text string) (*Move, error) {
+	san := strings.TrimRight(strings.TrimSpace(text), "+#!?")
+	if san == "" {
+		return nil, fmt.Errorf("пустая запись хода")
+	}
+
+	switch strings.ReplaceAll(san, "0", "O") {
+	case "O-O", "O-O-O":
+		move, ok := CastlingMove(g.Board, g.CurrentPlayer, len(san) == 3)
+		if !ok {
+			return nil, fmt.Errorf("на доске нет короля")
+		}
+		if err := g.ValidateMove(move); err != nil {
+			return nil, err
+		}
+		return move, nil
+	}
+
+	parts := sanPattern.FindStringSubmatch(san)
+	if parts == nil {
+		return nil, fmt.Errorf("неверная запись хода %q", text)
+	}
+
+	kind := Pawn
+	if parts[1] != "" {
+		kind, _ = PieceKindFromLetter(parts[1][0])
+	}
+	to, ok := ParseSquare(parts[5]+parts[6], g.Board.Size)
+	if !ok {
+		return nil, fmt.Errorf("клетка %s%s вне доски", parts[5], parts[6])
+	}
+	fromCol := -1
+	if parts[2] != "" {
+		fromCol = int(parts[2][0] - 'a')
+	}
+	fromRow := -1
+	if parts[3] != "" {
+		rank, _ := strconv.Atoi(parts[3])
+		fromRow = g.Board.Size - rank
+	}
+	var promotion PieceKind
+	if parts[7] != "" {
+		promotion, _ = PieceKindFromLetter(parts[7][0])
+	} else if kind == Pawn && to.Row == promotionRow(g.Board, g.CurrentPlayer.Color) {
+		promotion = Queen
+	}
+
+	var matches []*Move
+	for _, move := range g.LegalMoves() {
+		p, _ := ParsePiece(g.Board.GetCell(move.From.Row, move.From.Col))
+		if p.Kind != kind || move.To != to || move.Promotion != promotion {
+			continue
+		}
+		if (fromCol >= 0 && move.From.Col != fromCol) || (fromRow >= 0 && move.From.Row != fromRow) {
+			continue
+		}
+		matches = append(matches, move)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, &IllegalMoveError{Reason: fmt.Sprintf("ход %s невозможен в этой позиции", text)}
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("ход %s неоднозначен, уточните вертикаль или горизонталь", text)
+}
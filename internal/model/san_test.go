@@ -0,0 +1,113 @@
+package model
+
+import "testing"
+
+func TestSANParsesOwnOutput(t *testing.T) {
+	positions := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 b kq - 0 1",
+		// Три ферзя бьют одно поле: нужны вертикаль, горизонталь и клетка.
+		"1k6/8/8/8/4Q2Q/8/K7/7Q w - - 0 1",
+		"4k3/8/8/8/8/8/8/R3K2R w - - 0 1",
+		"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2",
+	}
+	for _, fen := range positions {
+		game := startFrom(t, fen)
+		seen := map[string]bool{}
+		for _, move := range game.LegalMoves() {
+			fresh := startFrom(t, fen)
+			played := *move
+			played.Player = fresh.CurrentPlayer
+			if err := fresh.MakeMove(&played); err != nil {
+				t.Fatalf("%s: %s: %v", fen, move.UCI, err)
+			}
+			san := played.SAN
+			if seen[san] {
+				t.Errorf("%s: запись %s у двух разных ходов", fen, san)
+			}
+			seen[san] = true
+
+			parsed, err := game.ParseSAN(san)
+			if err != nil {
+				t.Errorf("%s: ParseSAN(%q) = %v", fen, san, err)
+				continue
+			}
+			if parsed.From != move.From || parsed.To != move.To || parsed.Promotion != move.Promotion {
+				t.Errorf("%s: ParseSAN(%q) = %s, ожидается %s", fen, san,
+					parsed.CoordinateNotation(game.Board.Size), move.CoordinateNotation(game.Board.Size))
+			}
+		}
+	}
+}
+
+func TestSANOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string // в записи UCI
+		want string
+	}{
+		{"ход пешкой", StartFEN, "e2e4", "e4"},
+		{"ход конем", StartFEN, "g1f3", "Nf3"},
+		{"взятие пешкой", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "e4d5", "exd5"},
+		{"взятие на проходе", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", "exd6"},
+		{"шах", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "Ra8+"},
+		{"мат", "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "Ra8#"},
+		{"уточнение вертикалью", "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "Rad1"},
+		{"уточнение горизонталью", "4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "a1a3", "R1a3"},
+		{"уточнение клеткой", "1k6/8/8/8/4Q2Q/8/K7/7Q w - - 0 1", "h4e1", "Qh4e1"},
+		{"связанная фигура не уточняется", "4k3/8/8/8/1b6/8/3N4/4K1N1 w - - 0 1", "g1f3", "Nf3"},
+		{"превращение с матом", "6k1/4Pppp/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e8=Q#"},
+		{"короткая рокировка", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"длинная рокировка", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			play(t, game, tt.move)
+			if got := game.Moves[0].SAN; got != tt.want {
+				t.Errorf("SAN = %q, ожидается %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoveTextForms(t *testing.T) {
+	tests := []struct {
+		fen      string
+		text     string
+		from, to string
+	}{
+		{StartFEN, "Nf3", "g1", "f3"},
+		{StartFEN, "Ng1-f3", "g1", "f3"},
+		{StartFEN, "g1f3", "g1", "f3"},
+		{StartFEN, "e2-e4", "e2", "e4"},
+		{StartFEN, "e2e4", "e2", "e4"},
+		{StartFEN, "e4", "e2", "e4"},
+		{StartFEN, "e4!?", "e2", "e4"},
+		// Уточнение горизонталью похоже на запись клетками: R1 — не клетка.
+		{"4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "R1a3", "a1", "a3"},
+		{"4k3/8/6N1/8/8/8/8/4K1N1 w - - 0 1", "N6f4", "g6", "f4"},
+		{"4k3/8/8/6N1/8/8/8/4K1N1 w - - 0 1", "N5f3", "g5", "f3"},
+	}
+	for _, tt := range tests {
+		game := startFrom(t, tt.fen)
+		move, err := game.ParseMoveText(tt.text)
+		if err != nil {
+			t.Errorf("ParseMoveText(%q) = %v", tt.text, err)
+			continue
+		}
+		if got := move.CoordinateNotation(game.Board.Size); got != tt.from+tt.to {
+			t.Errorf("ParseMoveText(%q) = %s, ожидается %s%s", tt.text, got, tt.from, tt.to)
+		}
+	}
+
+	game := startFrom(t, "4k3/8/8/8/8/8/8/R4RK1 w - - 0 1")
+	if _, err := game.ParseSAN("Rd1"); err == nil {
+		t.Errorf("ParseSAN(Rd1) без уточнения принят при двух ладьях")
+	}
+	if _, err := game.ParseMoveText("a1a9"); err == nil {
+		t.Errorf("ParseMoveText(a1a9) принял клетку вне доски")
+	}
+}
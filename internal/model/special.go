@@ -0,0 +1,185 @@
+package model
+
+// Стандартная расстановка: король на вертикали e, ладьи на a и h.
+const (
+	kingStartCol      = 4
+	queenSideRookCol  = 0
+	kingSideRookCol   = 7
+	castlingKingShift = 2
+)
+
+// CastlingRights хранит, какие рокировки еще доступны каждой стороне.
+type CastlingRights struct {
+	WhiteKingSide  bool
+	WhiteQueenSide bool
+	BlackKingSide  bool
+	BlackQueenSide bool
+}
+
+func AllCastlingRights() CastlingRights {
+	return CastlingRights{
+		WhiteKingSide:  true,
+		WhiteQueenSide: true,
+		BlackKingSide:  true,
+		BlackQueenSide: true,
+	}
+}
+
+func (c CastlingRights) Allowed(color PlayerColor, kingSide bool) bool {
+	switch {
+	case color == White && kingSide:
+		return c.WhiteKingSide
+	case color == White:
+		return c.WhiteQueenSide
+	case kingSide:
+		return c.BlackKingSide
+	}
+	return c.BlackQueenSide
+}
+
+func (c *CastlingRights) revoke(color PlayerColor, kingSide bool) {
+	switch {
+	case color == White && kingSide:
+		c.WhiteKingSide = false
+	case color == White:
+		c.WhiteQueenSide = false
+	case kingSide:
+		c.BlackKingSide = false
+	default:
+		c.BlackQueenSide = false
+	}
+}
+
+var promotionKinds = map[PieceKind]bool{Queen: true, Rook: true, Bishop: true, Knight: true}
+
+func homeRow(board *Board, color PlayerColor) int {
+	if color == White {
+		return board.Size - 1
+	}
+	return 0
+}
+
+func promotionRow(board *Board, color PlayerColor) int {
+	return homeRow(board, color.Opponent())
+}
+
+// CastlingMove строит ход короля для рокировки в короткую или длинную сторону.
+func CastlingMove(board *Board, player *Player, kingSide bool) (*Move, bool) {
+	king, ok := board.FindKing(player.Color)
+	if !ok {
+		return nil, false
+	}
+	toCol := king.Col - castlingKingShift
+	if kingSide {
+		toCol = king.Col + castlingKingShift
+	}
+	return NewMove(king.Row, king.Col, king.Row, toCol, player, board.GetCell(king.Row, king.Col)), true
+}
+
+func isCastlingMove(board *Board, move *Move) bool {
+	piece, ok := ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+	return ok && piece.Kind == King && move.From.Row == move.To.Row &&
+		abs(move.To.Col-move.From.Col) == castlingKingShift
+}
+
+func isEnPassantMove(board *Board, move *Move) bool {
+	piece, ok := ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+	return ok && piece.Kind == Pawn && move.From.Col != move.To.Col &&
+		board.GetCell(move.To.Row, move.To.Col) == ""
+}
+
+func (g *Game) validateCastling(move *Move) error {
+	board := g.Board
+	color := move.Player.Color
+	kingSide := move.To.Col > move.From.Col
+
+	if move.From.Row != homeRow(board, color) || move.From.Col != kingStartCol {
+		return illegal(move, "король уже покидал исходную клетку")
+	}
+	if !g.Castling.Allowed(color, kingSide) {
+		return illegal(move, "право на эту рокировку потеряно")
+	}
+
+	rookCol := queenSideRookCol
+	if kingSide {
+		rookCol = kingSideRookCol
+	}
+	if board.GetCell(move.From.Row, rookCol) != PieceSymbol(Rook, color) {
+		return illegal(move, "для рокировки нет ладьи")
+	}
+	step := sign(rookCol - move.From.Col)
+	for col := move.From.Col + step; col != rookCol; col += step {
+		if board.GetCell(move.From.Row, col) != "" {
+			return illegal(move, "между королем и ладьей есть фигуры")
+		}
+	}
+
+	opponent := color.Opponent()
+	for col := move.From.Col; col != move.To.Col+step; col += step {
+		if board.IsSquareAttacked(Position{Row: move.From.Row, Col: col}, opponent) {
+			if col == move.From.Col {
+				return illegal(move, "нельзя рокироваться под шахом")
+			}
+			return illegal(move, "король не может проходить через битое поле")
+		}
+	}
+	return nil
+}
+
+func (g *Game) validateEnPassant(move *Move) error {
+	piece, _ := ParsePiece(g.Board.GetCell(move.From.Row, move.From.Col))
+	if move.Player != nil && piece.Color != move.Player.Color {
+		return illegal(move, "нельзя ходить фигурой соперника")
+	}
+	if g.EnPassant == nil || *g.EnPassant != move.To ||
+		move.To.Row-move.From.Row != pawnDirection(piece.Color) || abs(move.To.Col-move.From.Col) != 1 {
+		return illegal(move, "пешка ходит по диагонали только со взятием")
+	}
+	return nil
+}
+
+func (g *Game) validatePromotion(move *Move) error {
+	piece, ok := ParsePiece(g.Board.GetCell(move.From.Row, move.From.Col))
+	reachesLastRow := ok && piece.Kind == Pawn && move.To.Row == promotionRow(g.Board, piece.Color)
+	if move.Promotion == "" {
+		return nil
+	}
+	if !reachesLastRow {
+		return illegal(move, "превращение возможно только при ходе пешки на последнюю горизонталь")
+	}
+	if !promotionKinds[move.Promotion] {
+		return illegal(move, "пешка может превратиться только в ферзя, ладью, слона или коня")
+	}
+	return nil
+}
+
+// updateSpecialState обновляет права на рокировку и поле взятия на проходе.
+// Вызывается до применения хода к доске.
+func (g *Game) updateSpecialState(move *Move) {
+	board := g.Board
+	piece, _ := ParsePiece(board.GetCell(move.From.Row, move.From.Col))
+
+	if piece.Kind == King {
+		g.Castling.revoke(piece.Color, true)
+		g.Castling.revoke(piece.Color, false)
+	}
+	// Ход ладьи с угла или взятие на угловом поле лишает соответствующей рокировки.
+	for _, pos := range []Position{move.From, move.To} {
+		for _, color := range []PlayerColor{White, Black} {
+			if pos.Row != homeRow(board, color) {
+				continue
+			}
+			switch pos.Col {
+			case kingSideRookCol:
+				g.Castling.revoke(color, true)
+			case queenSideRookCol:
+				g.Castling.revoke(color, false)
+			}
+		}
+	}
+
+	g.EnPassant = nil
+	if piece.Kind == Pawn && abs(move.To.Row-move.From.Row) == 2 {
+		g.EnPassant = &Position{Row: (move.From.Row + move.To.Row) / 2, Col: move.From.Col}
+	}
+}
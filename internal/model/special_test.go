@@ -0,0 +1,182 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSpecialMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string // ходы перед проверяемым
+		move  string
+		// after — расстановка после проверяемого хода; пустая — ход недопустим.
+		after string
+	}{
+		{
+			name:  "короткая рокировка",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			move:  "O-O",
+			after: "r3k2r/8/8/8/8/8/8/R4RK1",
+		},
+		{
+			name:  "длинная рокировка черных",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			move:  "O-O-O",
+			after: "2kr3r/8/8/8/8/8/8/R3K2R",
+		},
+		{
+			name:  "рокировка ходом короля на две клетки",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			move:  "e1g1",
+			after: "r3k2r/8/8/8/8/8/8/R4RK1",
+		},
+		{
+			name: "рокировка без права",
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1",
+			move: "O-O",
+		},
+		{
+			name:  "право теряется после хода ладьи",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves: []string{"Rh2", "Kd8", "Rh1", "Ke8"},
+			move:  "O-O",
+		},
+		{
+			name: "рокировка под шахом",
+			fen:  "r3k2r/8/8/8/8/8/4q3/R3K2R w KQkq - 0 1",
+			move: "O-O",
+		},
+		{
+			name: "рокировка через битое поле",
+			fen:  "4kr2/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			move: "O-O",
+		},
+		{
+			name: "рокировка через фигуру",
+			fen:  "r3k2r/8/8/8/8/8/8/RN2K2R w KQkq - 0 1",
+			move: "O-O-O",
+		},
+		{
+			name:  "длинная рокировка при битом b1",
+			fen:   "1r2k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+			move:  "O-O-O",
+			after: "1r2k3/8/8/8/8/8/8/2KR4",
+		},
+		{
+			name:  "взятие на проходе",
+			fen:   "4k3/3p4/8/4P3/8/8/8/4K3 b - - 0 1",
+			moves: []string{"d5"},
+			move:  "exd6",
+			after: "4k3/8/3P4/8/8/8/8/4K3",
+		},
+		{
+			name:  "взятие на проходе только сразу",
+			fen:   "4k3/3p4/8/4P3/8/8/8/4K3 b - - 0 1",
+			moves: []string{"d5", "Kd2", "Kd8"},
+			move:  "e5d6",
+		},
+		{
+			name:  "превращение в ферзя",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move:  "a8=Q",
+			after: "Q3k3/8/8/8/8/8/8/4K3",
+		},
+		{
+			name:  "превращение в коня в длинной записи",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move:  "a7-a8=N",
+			after: "N3k3/8/8/8/8/8/8/4K3",
+		},
+		{
+			name:  "превращение со взятием",
+			fen:   "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move:  "axb8=R",
+			after: "1R2k3/8/8/8/8/8/8/4K3",
+		},
+		{
+			name:  "превращение по умолчанию в ферзя",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move:  "a7a8",
+			after: "Q3k3/8/8/8/8/8/8/4K3",
+		},
+		{
+			name:  "черная пешка превращается на первой горизонтали",
+			fen:   "4k3/8/8/8/8/8/7p/K7 b - - 0 1",
+			move:  "h1=Q",
+			after: "4k3/8/8/8/8/8/8/K6q",
+		},
+		{
+			name: "превращение не на последней горизонтали",
+			fen:  "4k3/8/P7/8/8/8/8/4K3 w - - 0 1",
+			move: "a6a7q",
+		},
+		{
+			name: "превращение в короля",
+			fen:  "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			move: "a7a8k",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			play(t, game, tt.moves...)
+			move, err := game.ParseMoveText(tt.move)
+			if err == nil {
+				err = game.MakeMove(move)
+			}
+			if tt.after == "" {
+				if err == nil {
+					t.Fatalf("%s принят, позиция %s", tt.move, game.Board.PlacementFEN())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tt.move, err)
+			}
+			if got := game.Board.PlacementFEN(); got != tt.after {
+				t.Errorf("после %s: %s, ожидается %s", tt.move, got, tt.after)
+			}
+		})
+	}
+}
+
+func TestSpecialMovesUndo(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+	}{
+		{"рокировка", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"O-O", "O-O-O"}},
+		{"взятие на проходе", "4k3/3p4/8/4P3/8/8/8/4K3 b - - 0 1", []string{"d5", "exd6"}},
+		{"превращение со взятием", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", []string{"axb8=N"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := startFrom(t, tt.fen)
+			play(t, game, tt.moves...)
+			for range tt.moves {
+				if _, err := game.Undo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := game.FEN(); got != tt.fen {
+				t.Errorf("после отмены %s, ожидается %s", got, tt.fen)
+			}
+		})
+	}
+}
+
+func TestEnPassantRequiresTarget(t *testing.T) {
+	// Пешка противника стоит рядом, но пришла не двойным ходом.
+	game := startFrom(t, "4k3/8/8/3pP3/8/8/8/4K3 w - - 0 1")
+	move, err := game.ParseMoveText("e5d6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var illegalErr *IllegalMoveError
+	if err := game.ValidateMove(move); !errors.As(err, &illegalErr) {
+		t.Errorf("ValidateMove() = %v, ожидается IllegalMoveError", err)
+	}
+}
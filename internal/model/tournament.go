@@ -0,0 +1,82 @@
+package model
+
+import "sync"
+
+// TournamentFormat — система проведения турнира.
+type TournamentFormat string
+
+const (
+	RoundRobin TournamentFormat = "round_robin" // каждый играет с каждым
+	Swiss      TournamentFormat = "swiss"       // швейцарская система
+	Knockout   TournamentFormat = "knockout"    // на выбывание
+)
+
+// Tournament — турнир: участники и пары всех сыгранных и текущего туров.
+type Tournament struct {
+	Entity
+	Name         string
+	Format       TournamentFormat
+	Rounds       int    // число туров; в турнире на выбывание — число кругов сетки
+	Round        int    // текущий тур, 0 — турнир не начат
+	TimeControl  string // контроль времени партий; пусто — без часов
+	Status       GameStatus
+	Participants []*Participant // по порядку посева
+	Pairings     []*Pairing     // по турам и доскам
+	Mu           sync.RWMutex
+}
+
+func NewTournament(name string, format TournamentFormat) *Tournament {
+	return &Tournament{Entity: NewEntity(), Name: name, Format: format, Status: StatusNotStarted}
+}
+
+func (t *Tournament) EntityType() string {
+	return "Tournament"
+}
+
+// Participant — участник турнира. Партии он играет под своим профилем.
+type Participant struct {
+	Entity
+	TournamentID string
+	ProfileID    string
+	Name         string
+	// Engine — уровень встроенного движка или командная строка UCI-движка,
+	// который делает ходы за участника; пусто — ходит человек.
+	Engine string
+	Seed   int // номер посева, начиная с 1
+}
+
+func (p *Participant) EntityType() string {
+	return "Participant"
+}
+
+// IsHuman сообщает, что за участника ходит человек.
+func (p *Participant) IsHuman() bool {
+	return p.Engine == ""
+}
+
+// Pairing — партия тура между двумя участниками. Пара без черных — свободный
+// от игры участник, который получает очко без партии.
+type Pairing struct {
+	Entity
+	TournamentID string
+	Round        int
+	Board        int    // номер доски в туре, начиная с 1
+	WhiteID      string // ID участника
+	BlackID      string // ID участника; пусто — пропуск тура
+	GameID       string // партия пары; пусто, пока она не создана
+	Result       GameResult
+}
+
+func (p *Pairing) EntityType() string {
+	return "Pairing"
+}
+
+// IsBye сообщает, что пара — пропуск тура.
+func (p *Pairing) IsBye() bool {
+	return p.BlackID == ""
+}
+
+// IsPlayed сообщает, что у пары есть результат.
+func (p *Pairing) IsPlayed() bool {
+	return p.Result != ResultNone
+}
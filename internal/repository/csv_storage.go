@@ -1,404 +1,975 @@
 package repository
 
 import (
+	"bytes"
 	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/imyakin/go_hw/internal/model"
 )
 
-const dataDir = "data"
+// DefaultDataDir — каталог CSV-файлов по умолчанию.
+const DefaultDataDir = "data"
 
-func ensureDataDir() {
-	os.MkdirAll(dataDir, 0755)
-}
+const timestampLayout = time.RFC3339Nano
 
-func savePlayersCSV() error {
-	ensureDataDir()
-	f, err := os.Create(filepath.Join(dataDir, "players.csv"))
-	if err != nil {
-		return fmt.Errorf("create players.csv: %w", err)
+// Колонки файлов снимков и записей журнала.
+var (
+	playerHeader  = []string{"ID", "Name", "Color", "Symbol", "ProfileID", "CreatedAt", "UpdatedAt"}
+	profileHeader = []string{"ID", "Name", "Rating", "Wins", "Losses", "Draws", "CreatedAt", "UpdatedAt"}
+	ratingHeader  = []string{"ID", "ProfileID", "GameID", "Before", "After", "Score", "CreatedAt", "UpdatedAt"}
+
+	tournamentHeader = []string{
+		"ID", "Name", "Format", "Rounds", "Round", "TimeControl", "Status",
+		"CreatedAt", "UpdatedAt",
+	}
+	participantHeader = []string{"ID", "TournamentID", "ProfileID", "Name", "Engine", "Seed", "CreatedAt", "UpdatedAt"}
+	pairingHeader     = []string{
+		"ID", "TournamentID", "Round", "Board", "WhiteID", "BlackID", "GameID", "Result",
+		"CreatedAt", "UpdatedAt",
+	}
+	boardHeader = []string{"ID", "Size", "Placement", "CreatedAt", "UpdatedAt"}
+	moveHeader  = []string{
+		"ID", "GameID", "Ply",
+		"FromRow", "FromCol", "ToRow", "ToCol",
+		"PlayerID", "PlayerName", "PlayerColor",
+		"Piece", "Promotion", "SAN", "UCI", "ThinkTime",
+		"CreatedAt", "UpdatedAt",
+	}
+	gameHeader = []string{
+		"ID", "WhitePlayerID", "BlackPlayerID",
+		"WhitePlayerName", "BlackPlayerName", "BoardSize",
+		"Status", "CurrentPlayerColor", "WinnerColor", "StartFEN", "FEN",
+		"Result", "ResultReason", "TimeControl", "WhiteTime", "BlackTime",
+		"CreatedAt", "UpdatedAt",
 	}
-	defer f.Close()
+)
+
+func headerOf(kind string) []string {
+	switch kind {
+	case kindBoards:
+		return boardHeader
+	case kindGames:
+		return gameHeader
+	case kindMoves:
+		return moveHeader
+	case kindPlayers:
+		return playerHeader
+	case kindProfiles:
+		return profileHeader
+	case kindRatings:
+		return ratingHeader
+	case kindTournaments:
+		return tournamentHeader
+	case kindParticipants:
+		return participantHeader
+	case kindPairings:
+		return pairingHeader
+	}
+	return nil
+}
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+// csvRow дает доступ к полям строки по именам колонок из заголовка, поэтому
+// файлы старых форматов, в которых части колонок нет, читаются тем же кодом.
+type csvRow struct {
+	columns map[string]int
+	record  []string
 
-	if err := w.Write([]string{"Name", "Color", "Symbol"}); err != nil {
-		return err
+	// source и line — откуда прочитана строка, для отчета о потерях.
+	source string
+	line   int
+}
+
+func columnIndex(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
 	}
-	for _, p := range players {
-		if err := w.Write([]string{p.Name, string(p.Color), p.Symbol}); err != nil {
-			return err
+	return columns
+}
+
+// get возвращает первое найденное поле из перечисленных колонок; несколько
+// имен нужны для колонок, переименованных в новых версиях формата.
+func (r csvRow) get(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.columns[name]; ok && i < len(r.record) {
+			return r.record[i]
 		}
 	}
-	return w.Error()
+	return ""
+}
+
+func (r csvRow) has(name string) bool {
+	i, ok := r.columns[name]
+	return ok && i < len(r.record)
 }
 
-func loadPlayers() ([]*model.Player, error) {
-	f, err := os.Open(filepath.Join(dataDir, "players.csv"))
+func (r csvRow) time(name string) time.Time {
+	t, _ := time.Parse(timestampLayout, r.get(name))
+	return t
+}
+
+// readCSV читает файл коллекции kind из каталога данных и возвращает его
+// содержимое и версию формата; отсутствующий файл — пустая таблица текущей
+// версии. Строки, которые не удалось прочитать, и расхождение с контрольной
+// суммой попадают в report.
+func readCSV(dir, kind string, report *loadReport) (*table, int, error) {
+	name := kind + ".csv"
+	t := &table{kind: kind}
+	data, err := os.ReadFile(filepath.Join(dir, name))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
+			return t, schemaVersion, nil
+		}
+		return nil, 0, err
+	}
+	version := snapshotVersion(data)
+	switch {
+	case version == 0:
+		return nil, 0, fmt.Errorf("%s: неизвестная версия формата", name)
+	case version > schemaVersion:
+		return nil, 0, fmt.Errorf("%s: %w: версия %d, поддерживается до %d", name, ErrNewerSchema, version, schemaVersion)
+	}
+	body, expected := verifySnapshot(name, data, version, report)
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
 		}
-		return nil, err
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.add(name, parseErr.StartLine, "строка не читается: "+parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if t.header == nil {
+			t.header = rec
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		t.records = append(t.records, rec)
+		t.lines = append(t.lines, line)
 	}
-	defer f.Close()
+	if expected >= 0 && len(t.records) < expected {
+		report.add(name, 0, fmt.Sprintf("прочитано %d записей из %d", len(t.records), expected))
+	}
+	return t, version, nil
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
+// writeSnapshot атомарно перезаписывает файл коллекции.
+func writeSnapshot(dir, name string, header []string, records [][]string) error {
+	data, err := encodeSnapshot(header, records)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, name), data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.Format(timestampLayout)
+}
 
+// loadEntity читает идентификатор и отметки времени; строкам старого
+// формата без ID выдается новый идентификатор.
+func loadEntity(row csvRow) model.Entity {
+	if row.get("ID") == "" {
+		return model.NewEntity()
+	}
+	return model.Entity{ID: row.get("ID"), CreatedAt: row.time("CreatedAt"), UpdatedAt: row.time("UpdatedAt")}
+}
+
+func playerRecord(p *model.Player) []string {
+	return []string{
+		p.ID,
+		p.Name,
+		string(p.Color),
+		p.Symbol,
+		p.ProfileID,
+		formatTime(p.CreatedAt),
+		formatTime(p.UpdatedAt),
+	}
+}
+
+func loadPlayers(rows []csvRow, report *loadReport) []*model.Player {
 	var result []*model.Player
-	for i, rec := range records {
-		// i == 0 — это строка заголовка (Name, Color, Symbol), её пропускаем.
-		// Также пропускаем строки, в которых меньше 3 полей, чтобы не обращаться к несуществующим индексам.
-		if i == 0 {
-			continue
-		}
-		if len(rec) < 3 {
+	for _, row := range rows {
+		// Пропускаем строки, в которых нет обязательных полей.
+		if !row.has("Symbol") {
+			report.skip(row, "игрок: нет обязательных полей")
 			continue
 		}
 		result = append(result, &model.Player{
-			Name:   rec[0],
-			Color:  model.PlayerColor(rec[1]),
-			Symbol: rec[2],
+			Entity:    loadEntity(row),
+			Name:      row.get("Name"),
+			Color:     model.PlayerColor(row.get("Color")),
+			Symbol:    row.get("Symbol"),
+			ProfileID: row.get("ProfileID"),
 		})
 	}
-	return result, nil
+	return result
 }
 
-func saveBoardsCSV() error {
-	ensureDataDir()
-	f, err := os.Create(filepath.Join(dataDir, "boards.csv"))
-	if err != nil {
-		return fmt.Errorf("create boards.csv: %w", err)
+func profileRecord(p *model.Profile) []string {
+	p.Mu.RLock()
+	defer p.Mu.RUnlock()
+	return []string{
+		p.ID,
+		p.Name,
+		strconv.Itoa(p.Rating),
+		strconv.Itoa(p.Wins),
+		strconv.Itoa(p.Losses),
+		strconv.Itoa(p.Draws),
+		formatTime(p.CreatedAt),
+		formatTime(p.UpdatedAt),
 	}
-	defer f.Close()
+}
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+func loadProfiles(rows []csvRow, report *loadReport) []*model.Profile {
+	var result []*model.Profile
+	for _, row := range rows {
+		rating, err := strconv.Atoi(row.get("Rating"))
+		if err != nil || row.get("Name") == "" {
+			report.skip(row, "профиль: нет имени или рейтинга")
+			continue
+		}
+		wins, _ := strconv.Atoi(row.get("Wins"))
+		losses, _ := strconv.Atoi(row.get("Losses"))
+		draws, _ := strconv.Atoi(row.get("Draws"))
+		result = append(result, &model.Profile{
+			Entity: loadEntity(row),
+			Name:   row.get("Name"),
+			Rating: rating,
+			Wins:   wins,
+			Losses: losses,
+			Draws:  draws,
+		})
+	}
+	return result
+}
 
-	if err := w.Write([]string{"Size", "Cells"}); err != nil {
-		return err
+func ratingRecord(c *model.RatingChange) []string {
+	return []string{
+		c.ID,
+		c.ProfileID,
+		c.GameID,
+		strconv.Itoa(c.Before),
+		strconv.Itoa(c.After),
+		strconv.FormatFloat(c.Score, 'f', -1, 64),
+		formatTime(c.CreatedAt),
+		formatTime(c.UpdatedAt),
 	}
-	for _, b := range boards {
-		cellsJSON, err := json.Marshal(b.Cells)
-		if err != nil {
-			return fmt.Errorf("marshal board cells: %w", err)
-		}
-		if err := w.Write([]string{strconv.Itoa(b.Size), string(cellsJSON)}); err != nil {
-			return err
+}
+
+func loadRatings(rows []csvRow, report *loadReport) []*model.RatingChange {
+	var result []*model.RatingChange
+	for _, row := range rows {
+		before, errBefore := strconv.Atoi(row.get("Before"))
+		after, errAfter := strconv.Atoi(row.get("After"))
+		score, errScore := strconv.ParseFloat(row.get("Score"), 64)
+		if err := errors.Join(errBefore, errAfter, errScore); err != nil {
+			report.skip(row, "изменение рейтинга: "+err.Error())
+			continue
 		}
+		result = append(result, &model.RatingChange{
+			Entity:    loadEntity(row),
+			ProfileID: row.get("ProfileID"),
+			GameID:    row.get("GameID"),
+			Before:    before,
+			After:     after,
+			Score:     score,
+		})
 	}
-	return w.Error()
+	return result
 }
 
-func loadBoards() ([]*model.Board, error) {
-	f, err := os.Open(filepath.Join(dataDir, "boards.csv"))
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
-		return nil, err
+func tournamentRecord(t *model.Tournament) []string {
+	t.Mu.RLock()
+	defer t.Mu.RUnlock()
+	return []string{
+		t.ID,
+		t.Name,
+		string(t.Format),
+		strconv.Itoa(t.Rounds),
+		strconv.Itoa(t.Round),
+		t.TimeControl,
+		string(t.Status),
+		formatTime(t.CreatedAt),
+		formatTime(t.UpdatedAt),
 	}
-	defer f.Close()
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
-	if err != nil {
-		return nil, err
+// loadTournaments читает турниры и собирает в них участников по посеву и
+// пары по турам и доскам.
+func loadTournaments(rows []csvRow, participants []*model.Participant, pairings []*model.Pairing, report *loadReport) []*model.Tournament {
+	participantsByTournament := make(map[string][]*model.Participant)
+	for _, p := range participants {
+		participantsByTournament[p.TournamentID] = append(participantsByTournament[p.TournamentID], p)
+	}
+	pairingsByTournament := make(map[string][]*model.Pairing)
+	for _, p := range pairings {
+		pairingsByTournament[p.TournamentID] = append(pairingsByTournament[p.TournamentID], p)
 	}
 
-	var result []*model.Board
-	for i, rec := range records {
-		// i == 0 — строка заголовка (Size, Cells), её пропускаем.
-		// Также пропускаем строки, в которых меньше 2 полей.
-		if i == 0 {
-			continue
-		}
-		if len(rec) < 2 {
+	var result []*model.Tournament
+	for _, row := range rows {
+		rounds, errRounds := strconv.Atoi(row.get("Rounds"))
+		round, errRound := strconv.Atoi(row.get("Round"))
+		if err := errors.Join(errRounds, errRound); err != nil || row.get("Format") == "" {
+			report.skip(row, "турнир: нет системы или числа туров")
 			continue
 		}
-		size, err := strconv.Atoi(rec[0])
-		if err != nil {
-			continue
+		t := &model.Tournament{
+			Entity:       loadEntity(row),
+			Name:         row.get("Name"),
+			Format:       model.TournamentFormat(row.get("Format")),
+			Rounds:       rounds,
+			Round:        round,
+			TimeControl:  row.get("TimeControl"),
+			Status:       model.GameStatus(row.get("Status")),
+			Participants: participantsByTournament[row.get("ID")],
+			Pairings:     pairingsByTournament[row.get("ID")],
 		}
-		var cells [][]string
-		if err := json.Unmarshal([]byte(rec[1]), &cells); err != nil {
-			continue
-		}
-		result = append(result, &model.Board{
-			Size:  size,
-			Cells: cells,
+		sort.SliceStable(t.Participants, func(i, j int) bool {
+			return t.Participants[i].Seed < t.Participants[j].Seed
+		})
+		sort.SliceStable(t.Pairings, func(i, j int) bool {
+			a, b := t.Pairings[i], t.Pairings[j]
+			if a.Round != b.Round {
+				return a.Round < b.Round
+			}
+			if a.Board != b.Board {
+				return a.Board < b.Board
+			}
+			return a.CreatedAt.Before(b.CreatedAt)
 		})
+		result = append(result, t)
 	}
-	return result, nil
+	return result
 }
 
-func saveMovesCSV() error {
-	ensureDataDir()
-	f, err := os.Create(filepath.Join(dataDir, "moves.csv"))
-	if err != nil {
-		return fmt.Errorf("create moves.csv: %w", err)
+func participantRecord(p *model.Participant) []string {
+	return []string{
+		p.ID,
+		p.TournamentID,
+		p.ProfileID,
+		p.Name,
+		p.Engine,
+		strconv.Itoa(p.Seed),
+		formatTime(p.CreatedAt),
+		formatTime(p.UpdatedAt),
 	}
-	defer f.Close()
+}
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+func loadParticipants(rows []csvRow, report *loadReport) []*model.Participant {
+	var result []*model.Participant
+	for _, row := range rows {
+		seed, err := strconv.Atoi(row.get("Seed"))
+		if err != nil || row.get("TournamentID") == "" {
+			report.skip(row, "участник турнира: нет турнира или номера посева")
+			continue
+		}
+		result = append(result, &model.Participant{
+			Entity:       loadEntity(row),
+			TournamentID: row.get("TournamentID"),
+			ProfileID:    row.get("ProfileID"),
+			Name:         row.get("Name"),
+			Engine:       row.get("Engine"),
+			Seed:         seed,
+		})
+	}
+	return result
+}
 
-	if err := w.Write([]string{"FromRow", "FromCol", "ToRow", "ToCol", "PlayerName", "PlayerColor", "Piece"}); err != nil {
-		return err
+func pairingRecord(p *model.Pairing) []string {
+	return []string{
+		p.ID,
+		p.TournamentID,
+		strconv.Itoa(p.Round),
+		strconv.Itoa(p.Board),
+		p.WhiteID,
+		p.BlackID,
+		p.GameID,
+		string(p.Result),
+		formatTime(p.CreatedAt),
+		formatTime(p.UpdatedAt),
 	}
-	for _, m := range moves {
-		playerName, playerColor := "", ""
-		if m.Player != nil {
-			playerName = m.Player.Name
-			playerColor = string(m.Player.Color)
-		}
-		if err := w.Write([]string{
-			strconv.Itoa(m.From.Row),
-			strconv.Itoa(m.From.Col),
-			strconv.Itoa(m.To.Row),
-			strconv.Itoa(m.To.Col),
-			playerName,
-			playerColor,
-			m.Piece,
-		}); err != nil {
-			return err
+}
+
+func loadPairings(rows []csvRow, report *loadReport) []*model.Pairing {
+	var result []*model.Pairing
+	for _, row := range rows {
+		round, errRound := strconv.Atoi(row.get("Round"))
+		board, errBoard := strconv.Atoi(row.get("Board"))
+		if err := errors.Join(errRound, errBoard); err != nil || row.get("WhiteID") == "" {
+			report.skip(row, "пара турнира: нет тура, доски или участника")
+			continue
 		}
+		result = append(result, &model.Pairing{
+			Entity:       loadEntity(row),
+			TournamentID: row.get("TournamentID"),
+			Round:        round,
+			Board:        board,
+			WhiteID:      row.get("WhiteID"),
+			BlackID:      row.get("BlackID"),
+			GameID:       row.get("GameID"),
+			Result:       model.GameResult(row.get("Result")),
+		})
 	}
-	return w.Error()
+	return result
 }
 
-func loadMoves() ([]*model.Move, error) {
-	f, err := os.Open(filepath.Join(dataDir, "moves.csv"))
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
+func boardRecord(b *model.Board) []string {
+	return []string{
+		b.ID,
+		strconv.Itoa(b.Size),
+		b.PlacementFEN(),
+		formatTime(b.CreatedAt),
+		formatTime(b.UpdatedAt),
+	}
+}
+
+func loadBoards(rows []csvRow, report *loadReport) []*model.Board {
+	var result []*model.Board
+	for _, row := range rows {
+		board, err := model.ParsePlacement(row.get("Placement"))
+		if err != nil {
+			report.skip(row, "доска: "+err.Error())
+			continue
 		}
-		return nil, err
+		board.Entity = loadEntity(row)
+		result = append(result, board)
 	}
-	defer f.Close()
+	return result
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
-	if err != nil {
-		return nil, err
+func moveRecord(m *model.Move) []string {
+	playerID, playerName, playerColor := "", "", ""
+	if m.Player != nil {
+		playerID = m.Player.ID
+		playerName = m.Player.Name
+		playerColor = string(m.Player.Color)
+	}
+	thinkTime := ""
+	if m.ThinkTime > 0 {
+		thinkTime = m.ThinkTime.String()
+	}
+	return []string{
+		m.ID,
+		m.GameID,
+		strconv.Itoa(m.Ply),
+		strconv.Itoa(m.From.Row),
+		strconv.Itoa(m.From.Col),
+		strconv.Itoa(m.To.Row),
+		strconv.Itoa(m.To.Col),
+		playerID,
+		playerName,
+		playerColor,
+		m.Piece,
+		string(m.Promotion),
+		m.SAN,
+		m.UCI,
+		thinkTime,
+		formatTime(m.CreatedAt),
+		formatTime(m.UpdatedAt),
 	}
+}
 
+// loadMoves читает ходы и связывает их с уже загруженными игроками по ID.
+func loadMoves(rows []csvRow, playersByID map[string]*model.Player, report *loadReport) []*model.Move {
 	var result []*model.Move
-	for i, rec := range records {
-		if i == 0 {
+	for _, row := range rows {
+		if !row.has("Piece") {
+			report.skip(row, "ход: нет обязательных полей")
 			continue
 		}
-		if len(rec) < 7 {
-			continue
-		}
-		fromRow, _ := strconv.Atoi(rec[0])
-		fromCol, _ := strconv.Atoi(rec[1])
-		toRow, _ := strconv.Atoi(rec[2])
-		toCol, _ := strconv.Atoi(rec[3])
-
-		var player *model.Player
-		if rec[4] != "" {
-			player = model.NewPlayer(rec[4], model.PlayerColor(rec[5]))
+		fromRow, _ := strconv.Atoi(row.get("FromRow"))
+		fromCol, _ := strconv.Atoi(row.get("FromCol"))
+		toRow, _ := strconv.Atoi(row.get("ToRow"))
+		toCol, _ := strconv.Atoi(row.get("ToCol"))
+		ply, _ := strconv.Atoi(row.get("Ply"))
+		thinkTime, _ := time.ParseDuration(row.get("ThinkTime"))
+
+		player := playersByID[row.get("PlayerID")]
+		if player == nil && row.get("PlayerName") != "" {
+			player = model.NewPlayer(row.get("PlayerName"), model.PlayerColor(row.get("PlayerColor")))
 		}
 
 		result = append(result, &model.Move{
-			From:   model.Position{Row: fromRow, Col: fromCol},
-			To:     model.Position{Row: toRow, Col: toCol},
-			Player: player,
-			Piece:  rec[6],
+			Entity:    loadEntity(row),
+			GameID:    row.get("GameID"),
+			Ply:       ply,
+			From:      model.Position{Row: fromRow, Col: fromCol},
+			To:        model.Position{Row: toRow, Col: toCol},
+			Player:    player,
+			Piece:     row.get("Piece"),
+			Promotion: model.PieceKind(row.get("Promotion")),
+			SAN:       row.get("SAN"),
+			UCI:       row.get("UCI"),
+			ThinkTime: thinkTime,
 		})
 	}
-	return result, nil
+	return result
 }
 
-func saveGamesCSV() error {
-	ensureDataDir()
-	f, err := os.Create(filepath.Join(dataDir, "games.csv"))
-	if err != nil {
-		return fmt.Errorf("create games.csv: %w", err)
+func gameRecord(g *model.Game) []string {
+	g.Mu.RLock()
+	defer g.Mu.RUnlock()
+
+	currentColor := ""
+	if g.CurrentPlayer != nil {
+		currentColor = string(g.CurrentPlayer.Color)
+	}
+	winnerColor := ""
+	if g.Winner != nil {
+		winnerColor = string(g.Winner.Color)
+	}
+	// Сохраняем время на часах на момент записи, включая идущий ход.
+	timeControl, whiteTime, blackTime := "", "", ""
+	if g.Clock != nil {
+		now := time.Now()
+		timeControl = g.Clock.Control.String()
+		whiteTime = g.TimeLeft(model.White, now).String()
+		blackTime = g.TimeLeft(model.Black, now).String()
+	}
+	return []string{
+		g.ID,
+		g.WhitePlayer.ID,
+		g.BlackPlayer.ID,
+		g.WhitePlayer.Name,
+		g.BlackPlayer.Name,
+		strconv.Itoa(g.Board.Size),
+		string(g.Status),
+		currentColor,
+		winnerColor,
+		g.StartFEN,
+		g.FEN(),
+		string(g.Result),
+		string(g.ResultReason),
+		timeControl,
+		whiteTime,
+		blackTime,
+		formatTime(g.CreatedAt),
+		formatTime(g.UpdatedAt),
 	}
-	defer f.Close()
+}
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+// loadGames читает партии, связывает их с игроками по ID и восстанавливает
+// историю ходов каждой партии.
+func loadGames(rows []csvRow, playersByID map[string]*model.Player, movesByGame map[string][]*model.Move, report *loadReport) []*model.Game {
+	var result []*model.Game
+	for _, row := range rows {
+		position := row.get("FEN")
+		if position == "" {
+			report.skip(row, "партия: нет позиции")
+			continue
+		}
+		boardSize, _ := strconv.Atoi(row.get("BoardSize"))
 
-	if err := w.Write([]string{
-		"WhitePlayerName", "BlackPlayerName", "BoardSize",
-		"Status", "CurrentPlayerColor", "WinnerColor", "Cells",
-	}); err != nil {
-		return err
-	}
+		game := model.NewGame(row.get("WhitePlayerName"), row.get("BlackPlayerName"), boardSize)
+		if player := playersByID[row.get("WhitePlayerID")]; player != nil {
+			game.WhitePlayer = player
+		}
+		if player := playersByID[row.get("BlackPlayerID")]; player != nil {
+			game.BlackPlayer = player
+		}
+		game.CurrentPlayer = game.WhitePlayer
+		entity := loadEntity(row)
+		game.Entity = entity
+
+		if err := restoreMoves(game, row.get("StartFEN"), position, movesByGame[entity.ID]); err != nil {
+			if !restorePosition(game, position) {
+				report.skip(row, "партия: позиция не читается")
+				continue
+			}
+			// У партий старого формата истории нет, и это не потеря.
+			if row.get("StartFEN") != "" {
+				report.skip(row, fmt.Sprintf("партия %s: история ходов не восстановлена (%v), загружена только позиция", entity.ID, err))
+			}
+		}
 
-	for _, g := range games {
-		g.Mu.RLock()
-		currentColor := ""
-		if g.CurrentPlayer != nil {
-			currentColor = string(g.CurrentPlayer.Color)
-		}
-		winnerColor := ""
-		if g.Winner != nil {
-			winnerColor = string(g.Winner.Color)
-		}
-		cellsJSON, _ := json.Marshal(g.Board.Cells)
-		err := w.Write([]string{
-			g.WhitePlayer.Name,
-			g.BlackPlayer.Name,
-			strconv.Itoa(g.Board.Size),
-			string(g.Status),
-			currentColor,
-			winnerColor,
-			string(cellsJSON),
-		})
-		g.Mu.RUnlock()
-		if err != nil {
-			return err
+		game.Status = model.GameStatus(row.get("Status"))
+		switch row.get("WinnerColor") {
+		case string(model.White):
+			game.Winner = game.WhitePlayer
+		case string(model.Black):
+			game.Winner = game.BlackPlayer
+		default:
+			game.Winner = nil
+		}
+		game.Result = model.GameResult(row.get("Result"))
+		game.ResultReason = model.ResultReason(row.get("ResultReason"))
+		if clock, ok := loadClock(row); ok {
+			game.Clock = clock
 		}
+		// Переигровка ходов обновляет UpdatedAt, поэтому восстанавливаем его последним.
+		game.Entity = entity
+
+		result = append(result, game)
 	}
-	return w.Error()
+	return result
 }
 
-func loadGames() ([]*model.Game, error) {
-	f, err := os.Open(filepath.Join(dataDir, "games.csv"))
+// loadClock восстанавливает часы партии, сыгранной с контролем времени.
+func loadClock(row csvRow) (*model.Clock, bool) {
+	if row.get("TimeControl") == "" {
+		return nil, false
+	}
+	control, err := model.ParseTimeControl(row.get("TimeControl"))
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
-		return nil, err
+		return nil, false
+	}
+	clock := model.NewClock(control)
+	if d, err := time.ParseDuration(row.get("WhiteTime")); err == nil {
+		clock.White = d
 	}
-	defer f.Close()
+	if d, err := time.ParseDuration(row.get("BlackTime")); err == nil {
+		clock.Black = d
+	}
+	return clock, true
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
-	if err != nil {
-		return nil, err
+// restoreMoves переигрывает сохраненные ходы от начальной позиции, чтобы у
+// партии была история с данными для отмены ходов и учета повторений.
+// Итоговая позиция должна совпасть с сохраненной.
+func restoreMoves(game *model.Game, startFEN, fen string, gameMoves []*model.Move) error {
+	if startFEN == "" {
+		return errors.New("начальная позиция не сохранена")
+	}
+	if err := game.LoadFEN(startFEN); err != nil {
+		return err
 	}
 
-	var result []*model.Game
-	for i, rec := range records {
-		if i == 0 {
-			continue
+	sort.SliceStable(gameMoves, func(i, j int) bool {
+		return gameMoves[i].Ply < gameMoves[j].Ply
+	})
+	game.Status = model.StatusInProgress
+	for _, move := range gameMoves {
+		if move.Player == nil || move.Player.Color != game.CurrentPlayer.Color {
+			return fmt.Errorf("ход %d сделан не тем игроком", move.Ply)
 		}
-		if len(rec) < 7 {
-			continue
+		move.Player = game.CurrentPlayer
+		if err := game.MakeMove(move); err != nil {
+			return fmt.Errorf("ход %d %s: %w", move.Ply, move.GetNotation(), err)
 		}
+	}
 
-		boardSize, _ := strconv.Atoi(rec[2])
-		var cells [][]string
-		json.Unmarshal([]byte(rec[6]), &cells)
+	if game.FEN() != fen {
+		return errors.New("позиция после ходов не совпадает с сохраненной")
+	}
+	return nil
+}
 
-		whitePlayer := model.NewPlayer(rec[0], model.White)
-		blackPlayer := model.NewPlayer(rec[1], model.Black)
+// restorePosition загружает только текущую позицию — для партий старого
+// формата, ходы которых не привязаны к игре.
+func restorePosition(game *model.Game, fen string) bool {
+	game.Moves = nil
+	return game.LoadFEN(fen) == nil
+}
 
-		board := &model.Board{Size: boardSize, Cells: cells}
+// CSVRepository хранит сущности в памяти и записывает каждое изменение в
+// журнал; время от времени журнал сворачивается в CSV-снимки коллекций в
+// каталоге dir.
+type CSVRepository struct {
+	*MemoryRepository
+	dir     string
+	journal journal
 
-		game := &model.Game{
-			WhitePlayer: whitePlayer,
-			BlackPlayer: blackPlayer,
-			Board:       board,
-			Moves:       make([]*model.Move, 0),
-			Status:      model.GameStatus(rec[3]),
-		}
+	// readOnly — причина, по которой хранилище нельзя перезаписывать.
+	readOnly error
+}
 
-		if rec[4] == string(model.Black) {
-			game.CurrentPlayer = blackPlayer
+func NewCSVRepository(dir string) *CSVRepository {
+	r := &CSVRepository{MemoryRepository: NewMemoryRepository(), dir: dir}
+	r.journal.path = filepath.Join(dir, journalFile)
+	r.persist = r.save
+	return r
+}
+
+// save дописывает изменения в журнал; вызывается под блокировками
+// затронутых коллекций.
+func (r *CSVRepository) save(ops []txOp) error {
+	if r.readOnly != nil {
+		return r.readOnly
+	}
+	events := make([][]string, 0, len(ops)+1)
+	for _, op := range ops {
+		kind, ok := entityKind(op.entity)
+		if !ok {
+			continue
+		}
+		if op.remove {
+			events = append(events, []string{opRemove, kind, entityID(op.entity)})
 		} else {
-			game.CurrentPlayer = whitePlayer
+			events = append(events, append([]string{opStore, kind}, entityRecord(op.entity)...))
 		}
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	return r.journal.append(events)
+}
 
-		if rec[5] == string(model.White) {
-			game.Winner = whitePlayer
-		} else if rec[5] == string(model.Black) {
-			game.Winner = blackPlayer
-		}
+func entityID(entity model.GameEntity) string {
+	switch e := entity.(type) {
+	case *model.Board:
+		return e.ID
+	case *model.Game:
+		return e.ID
+	case *model.Move:
+		return e.ID
+	case *model.Player:
+		return e.ID
+	case *model.Profile:
+		return e.ID
+	case *model.RatingChange:
+		return e.ID
+	case *model.Tournament:
+		return e.ID
+	case *model.Participant:
+		return e.ID
+	case *model.Pairing:
+		return e.ID
+	}
+	return ""
+}
 
-		result = append(result, game)
+func entityRecord(entity model.GameEntity) []string {
+	switch e := entity.(type) {
+	case *model.Board:
+		return boardRecord(e)
+	case *model.Game:
+		return gameRecord(e)
+	case *model.Move:
+		return moveRecord(e)
+	case *model.Player:
+		return playerRecord(e)
+	case *model.Profile:
+		return profileRecord(e)
+	case *model.RatingChange:
+		return ratingRecord(e)
+	case *model.Tournament:
+		return tournamentRecord(e)
+	case *model.Participant:
+		return participantRecord(e)
+	case *model.Pairing:
+		return pairingRecord(e)
+	}
+	return nil
+}
+
+func (r *CSVRepository) Store(entity model.GameEntity) error {
+	if err := r.MemoryRepository.Store(entity); err != nil {
+		return err
 	}
-	return result, nil
+	return r.compactIfNeeded()
 }
 
-func SaveAll() error {
-	var errs []error
+func (r *CSVRepository) Remove(entity model.GameEntity) error {
+	if err := r.MemoryRepository.Remove(entity); err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
 
-	muPlayers.RLock()
-	if err := savePlayersCSV(); err != nil {
-		errs = append(errs, err)
+func (r *CSVRepository) Transaction(fn func(tx Tx) error) error {
+	if err := r.MemoryRepository.Transaction(fn); err != nil {
+		return err
 	}
-	muPlayers.RUnlock()
+	return r.compactIfNeeded()
+}
 
-	muBoards.RLock()
-	if err := saveBoardsCSV(); err != nil {
-		errs = append(errs, err)
+func (r *CSVRepository) compactIfNeeded() error {
+	if r.journal.pending() < compactEvery {
+		return nil
 	}
-	muBoards.RUnlock()
+	return r.compact()
+}
 
-	muMoves.RLock()
-	if err := saveMovesCSV(); err != nil {
-		errs = append(errs, err)
+// compact записывает снимки всех коллекций и очищает журнал. Журнал
+// очищается только после того, как записаны все снимки: если сбой случится
+// раньше, при загрузке он будет заново применен к снимкам.
+func (r *CSVRepository) compact() error {
+	if r.readOnly != nil {
+		return r.readOnly
+	}
+	for _, kind := range kindOrder {
+		r.mutex(kind).RLock()
+	}
+	defer func() {
+		for i := len(kindOrder) - 1; i >= 0; i-- {
+			r.mutex(kindOrder[i]).RUnlock()
+		}
+	}()
+	return r.journal.compact(r.writeSnapshots)
+}
+
+// writeSnapshots перезаписывает CSV-файлы всех коллекций; вызывающий держит
+// блокировки коллекций.
+func (r *CSVRepository) writeSnapshots() error {
+	snapshots := map[string][][]string{
+		kindBoards:   records(r.boards.items, boardRecord),
+		kindGames:    records(r.games.items, gameRecord),
+		kindMoves:    records(r.moves.items, moveRecord),
+		kindPlayers:  records(r.players.items, playerRecord),
+		kindProfiles: records(r.profiles.items, profileRecord),
+		kindRatings:  records(r.ratings.items, ratingRecord),
+
+		kindTournaments:  records(r.tournaments.items, tournamentRecord),
+		kindParticipants: records(r.participants.items, participantRecord),
+		kindPairings:     records(r.pairings.items, pairingRecord),
+	}
+	for _, kind := range kindOrder {
+		if err := writeSnapshot(r.dir, kind+".csv", headerOf(kind), snapshots[kind]); err != nil {
+			return err
+		}
 	}
-	muMoves.RUnlock()
+	return nil
+}
 
-	muGames.RLock()
-	if err := saveGamesCSV(); err != nil {
-		errs = append(errs, err)
+func records[T any](items []T, record func(T) []string) [][]string {
+	result := make([][]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, record(item))
 	}
-	muGames.RUnlock()
+	return result
+}
 
-	return errors.Join(errs...)
+// Close сворачивает журнал в снимки и закрывает его.
+func (r *CSVRepository) Close() error {
+	if r.readOnly != nil {
+		return r.journal.close()
+	}
+	return errors.Join(r.compact(), r.journal.close())
 }
 
-func LoadAll() error {
+// Load читает CSV-снимки, применяет к ним журнал, связывает ходы и партии с
+// игроками по ID и восстанавливает историю ходов каждой партии. Загружается
+// все, что удалось прочитать; о поврежденных данных сообщает
+// *CorruptionError, а сами поврежденные файлы сохраняются копиями. После
+// загрузки журнал сворачивается в снимки.
+func (r *CSVRepository) Load() error {
 	var errs []error
-
-	loaded, err := loadPlayers()
+	report := &loadReport{}
+
+	tables := make(map[string]*rowSet, len(kindOrder))
+	for _, kind := range kindOrder {
+		tables[kind] = newRowSet(nil)
+		t, version, err := readCSV(r.dir, kind, report)
+		if err == nil {
+			err = r.upgrade(t, version)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load %s: %w", kind, err))
+			r.freezeIfNewer(err)
+			continue
+		}
+		tables[kind] = newRowSet(t.rows(kind + ".csv"))
+	}
+	events, err := replayJournal(r.journal.path, tables, report)
 	if err != nil {
-		errs = append(errs, fmt.Errorf("load players: %w", err))
-	} else if loaded != nil {
-		muPlayers.Lock()
-		players = loaded
-		muPlayers.Unlock()
-		notifySliceChange("players", "load", fmt.Sprintf("loaded %d players from CSV", len(loaded)))
+		errs = append(errs, fmt.Errorf("replay journal: %w", err))
+		r.freezeIfNewer(err)
 	}
 
-	loadedBoards, err := loadBoards()
+	loadedPlayers := loadPlayers(tables[kindPlayers].list(), report)
+	playersByID := make(map[string]*model.Player, len(loadedPlayers))
+	for _, p := range loadedPlayers {
+		playersByID[p.ID] = p
+	}
+	loadedBoards := loadBoards(tables[kindBoards].list(), report)
+	loadedMoves := loadMoves(tables[kindMoves].list(), playersByID, report)
+	movesByGame := make(map[string][]*model.Move)
+	for _, m := range loadedMoves {
+		if m.GameID != "" {
+			movesByGame[m.GameID] = append(movesByGame[m.GameID], m)
+		}
+	}
+	loadedGames := loadGames(tables[kindGames].list(), playersByID, movesByGame, report)
+	loadedParticipants := loadParticipants(tables[kindParticipants].list(), report)
+	loadedPairings := loadPairings(tables[kindPairings].list(), report)
+	loadedTournaments := loadTournaments(tables[kindTournaments].list(), loadedParticipants, loadedPairings, report)
+
+	replace(r.MemoryRepository, &r.players, loadedPlayers, kindPlayers)
+	replace(r.MemoryRepository, &r.profiles, loadProfiles(tables[kindProfiles].list(), report), kindProfiles)
+	replace(r.MemoryRepository, &r.ratings, loadRatings(tables[kindRatings].list(), report), kindRatings)
+	replace(r.MemoryRepository, &r.boards, loadedBoards, kindBoards)
+	replace(r.MemoryRepository, &r.moves, loadedMoves, kindMoves)
+	replace(r.MemoryRepository, &r.games, loadedGames, kindGames)
+	replace(r.MemoryRepository, &r.participants, loadedParticipants, kindParticipants)
+	replace(r.MemoryRepository, &r.pairings, loadedPairings, kindPairings)
+	replace(r.MemoryRepository, &r.tournaments, loadedTournaments, kindTournaments)
+	if events > 0 {
+		r.LogChange("journal", "replay", fmt.Sprintf("replayed %d journal events", events))
+	}
+
+	// Копии снимаются до сворачивания журнала, которое перезапишет снимки.
+	backups, err := report.backup(r.dir)
 	if err != nil {
-		errs = append(errs, fmt.Errorf("load boards: %w", err))
-	} else if loadedBoards != nil {
-		muBoards.Lock()
-		boards = loadedBoards
-		muBoards.Unlock()
-		notifySliceChange("boards", "load", fmt.Sprintf("loaded %d boards from CSV", len(loadedBoards)))
+		errs = append(errs, fmt.Errorf("backup damaged files: %w", err))
 	}
+	if len(errs) == 0 {
+		if err := r.compact(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, report.err(backups))...)
+}
 
-	loadedMoves, err := loadMoves()
+// upgrade приводит таблицу к текущей версии формата. Исходный файл
+// сохраняется копией: после загрузки снимок будет перезаписан в новом формате.
+func (r *CSVRepository) upgrade(t *table, version int) error {
+	applied, err := migrate(t, version)
+	if err != nil || len(applied) == 0 {
+		return err
+	}
+	name := t.kind + ".csv"
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
 	if err != nil {
-		errs = append(errs, fmt.Errorf("load moves: %w", err))
-	} else if loadedMoves != nil {
-		muMoves.Lock()
-		moves = loadedMoves
-		muMoves.Unlock()
-		notifySliceChange("moves", "load", fmt.Sprintf("loaded %d moves from CSV", len(loadedMoves)))
+		return err
 	}
+	backup := fmt.Sprintf("%s.v%d", name, version)
+	if err := os.WriteFile(filepath.Join(r.dir, backup), data, 0644); err != nil {
+		return err
+	}
+	r.LogChange(t.kind, "migrate", fmt.Sprintf("migrated %s to v%d (%s), original saved as %s",
+		name, schemaVersion, strings.Join(applied, "; "), backup))
+	return nil
+}
 
-	loadedGames, err := loadGames()
-	if err != nil {
-		errs = append(errs, fmt.Errorf("load games: %w", err))
-	} else if loadedGames != nil {
-		muGames.Lock()
-		games = loadedGames
-		muGames.Unlock()
-		notifySliceChange("games", "load", fmt.Sprintf("loaded %d games from CSV", len(loadedGames)))
+// freezeIfNewer запрещает запись, если данные созданы более новой версией
+// программы: перезапись снимков потеряла бы незнакомые этой версии данные.
+func (r *CSVRepository) freezeIfNewer(err error) {
+	if errors.Is(err, ErrNewerSchema) {
+		r.readOnly = err
 	}
+}
 
-	return errors.Join(errs...)
+func replace[T comparable](r *MemoryRepository, c *collection[T], items []T, kind string) {
+	c.mu.Lock()
+	c.replace(items)
+	c.mu.Unlock()
+	r.LogChange(kind, "load", fmt.Sprintf("loaded %d %s from CSV", len(items), kind))
 }
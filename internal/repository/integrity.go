@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Снимок коллекции начинается строкой snapshotMarker с версией формата и
+// заканчивается строкой контрольной суммы всего, что перед ней, и числа
+// записей:
+//
+//	#go_hw snapshot v5
+//	ID,Name,...
+//	...
+//	#sha256,<hex>,<записей>
+//
+// Строки с # читатель CSV пропускает. Файлы старого формата без маркера
+// читаются без проверки.
+const (
+	snapshotMarker = "#go_hw snapshot"
+	checksumPrefix = "#sha256,"
+)
+
+// DataLoss описывает данные, которые не удалось загрузить.
+type DataLoss struct {
+	File   string
+	Line   int // 0 — место в файле неизвестно
+	Reason string
+}
+
+func (l DataLoss) String() string {
+	if l.Line > 0 {
+		return fmt.Sprintf("%s, строка %d: %s", l.File, l.Line, l.Reason)
+	}
+	return fmt.Sprintf("%s: %s", l.File, l.Reason)
+}
+
+// CorruptionError — отчет о поврежденных или обрезанных данных, найденных
+// при загрузке. Все, что удалось прочитать, загружено.
+type CorruptionError struct {
+	Losses []DataLoss
+	// Backups — копии поврежденных файлов, сделанные до их перезаписи.
+	Backups []string
+}
+
+func (e *CorruptionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "повреждены сохраненные данные (%d):", len(e.Losses))
+	for _, loss := range e.Losses {
+		b.WriteString("\n  " + loss.String())
+	}
+	if len(e.Backups) > 0 {
+		b.WriteString("\n  копии поврежденных файлов: " + strings.Join(e.Backups, ", "))
+	}
+	return b.String()
+}
+
+// loadReport собирает потери данных во время загрузки.
+type loadReport struct {
+	losses []DataLoss
+	// damaged — файлы, которые нужно сохранить копией до перезаписи.
+	damaged map[string]bool
+}
+
+func (r *loadReport) add(file string, line int, reason string) {
+	r.losses = append(r.losses, DataLoss{File: file, Line: line, Reason: reason})
+	if r.damaged == nil {
+		r.damaged = make(map[string]bool)
+	}
+	r.damaged[file] = true
+}
+
+// skip отмечает строку, которую не удалось превратить в сущность.
+func (r *loadReport) skip(row csvRow, reason string) {
+	r.add(row.source, row.line, reason)
+}
+
+// backup копирует поврежденные файлы рядом с оригиналом, чтобы их можно было
+// разобрать вручную после того, как снимок будет перезаписан.
+func (r *loadReport) backup(dir string) ([]string, error) {
+	var backups []string
+	suffix := ".corrupt-" + time.Now().Format("20060102-150405")
+	for file := range r.damaged {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return backups, err
+		}
+		path := filepath.Join(dir, file+suffix)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return backups, err
+		}
+		backups = append(backups, path)
+	}
+	return backups, nil
+}
+
+func (r *loadReport) err(backups []string) error {
+	if len(r.losses) == 0 {
+		return nil
+	}
+	return &CorruptionError{Losses: r.losses, Backups: backups}
+}
+
+// encodeSnapshot записывает снимок коллекции с маркером и контрольной суммой.
+func encodeSnapshot(header []string, records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s v%d\n", snapshotMarker, schemaVersion)
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+	w.WriteAll(records)
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	fmt.Fprintf(&buf, "%s%s,%d\n", checksumPrefix, hex.EncodeToString(sum[:]), len(records))
+	return buf.Bytes(), nil
+}
+
+// snapshotVersion возвращает версию формата по первой строке файла.
+func snapshotVersion(data []byte) int {
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	marker, ok := strings.CutPrefix(string(first), snapshotMarker)
+	switch {
+	case !ok:
+		return 1
+	case marker == "":
+		// Снимки с контрольной суммой появились раньше номера версии.
+		return 2
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(marker, " v"))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// verifySnapshot проверяет контрольную сумму снимка версии 2 и новее и
+// возвращает его содержимое без строки суммы и число записей, которое в нем
+// должно быть (-1, если оно неизвестно).
+func verifySnapshot(name string, data []byte, version int, report *loadReport) ([]byte, int) {
+	if version < 2 {
+		return data, -1
+	}
+	trimmed := bytes.TrimRight(data, "\n")
+	i := bytes.LastIndexByte(trimmed, '\n')
+	trailer := string(trimmed[i+1:])
+	if i < 0 || !strings.HasPrefix(trailer, checksumPrefix) {
+		report.add(name, 0, "файл обрезан: нет строки контрольной суммы, последние записи могли потеряться")
+		return data, -1
+	}
+	body := data[:i+1]
+	fields := strings.Split(strings.TrimPrefix(trailer, checksumPrefix), ",")
+	rows := -1
+	if len(fields) == 2 {
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			rows = n
+		}
+	}
+	sum := sha256.Sum256(body)
+	if fields[0] != hex.EncodeToString(sum[:]) {
+		report.add(name, 0, "контрольная сумма не совпадает: файл изменен или поврежден")
+	}
+	return body, rows
+}
+
+// writeFileAtomic записывает файл во временный, сбрасывает его на диск и
+// переименовывает поверх старого: после сбоя на диске остается либо старый,
+// либо новый файл целиком.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir сбрасывает на диск каталог, чтобы переименование пережило сбой.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
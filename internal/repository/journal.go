@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journalFile — журнал изменений в каталоге данных. Журнал начинается с
+// версии формата и колонок записей каждой коллекции:
+//
+//	#go_hw journal v5
+//	#columns,players,ID,Name,...
+//
+// Дальше каждая строка — событие:
+//
+//	store,<коллекция>,<поля записи в порядке колонок>
+//	remove,<коллекция>,<ID>
+//	commit,<CRC-32 строк событий>
+//
+// События одного вызова Store, Remove или Transaction дописываются одной
+// записью и завершаются строкой commit. При загрузке события без commit
+// (оборванная при сбое запись) и события с неверной суммой отбрасываются.
+const journalFile = "journal.csv"
+
+const (
+	journalMarker = "#go_hw journal"
+	columnsPrefix = "#columns,"
+	opStore       = "store"
+	opRemove      = "remove"
+	opCommit      = "commit"
+)
+
+// compactEvery — после стольких событий журнал сворачивается в снимки.
+const compactEvery = 1000
+
+type journal struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64 // длина файла после последней успешной записи
+	events int
+}
+
+// append дописывает события и commit в конец журнала одной записью и
+// сбрасывает её на диск. Сохранение хода не зависит от числа уже сохраненных
+// сущностей. Если запись не удалась, журнал обрезается до прежней длины.
+func (j *journal) append(events [][]string) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.WriteAll(events)
+	w.Flush()
+	sum := crc32.ChecksumIEEE(buf.Bytes())
+	w.Write([]string{opCommit, strconv.FormatUint(uint64(sum), 16)})
+	w.Flush()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		j.file = f
+		j.size = info.Size()
+	}
+	if j.size == 0 {
+		buf = *bytes.NewBuffer(append(journalPreamble(), buf.Bytes()...))
+	}
+	_, err := j.file.Write(buf.Bytes())
+	if err == nil {
+		err = j.file.Sync()
+	}
+	if err != nil {
+		j.file.Truncate(j.size)
+		return fmt.Errorf("journal: %w", err)
+	}
+	j.size += int64(buf.Len())
+	j.events += len(events)
+	return nil
+}
+
+// journalPreamble возвращает начало журнала с версией формата и колонками.
+func journalPreamble() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s v%d\n", journalMarker, schemaVersion)
+	for _, kind := range kindOrder {
+		b.WriteString(columnsPrefix + kind + "," + strings.Join(headerOf(kind), ",") + "\n")
+	}
+	return b.Bytes()
+}
+
+// pending возвращает число событий, записанных после последнего сворачивания.
+func (j *journal) pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.events
+}
+
+// compact вызывает writeSnapshots и, если снимки записаны, очищает журнал.
+// Пока идет запись снимков, новые события в журнал не попадают.
+func (j *journal) compact(writeSnapshots func() error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := writeSnapshots(); err != nil {
+		return err
+	}
+	j.events = 0
+	if j.file != nil {
+		j.size = 0
+		if err := j.file.Truncate(0); err != nil {
+			return err
+		}
+		return j.file.Sync()
+	}
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// rowSet — строки коллекции по ID в порядке добавления; к ним применяются
+// события журнала.
+type rowSet struct {
+	keys []string
+	rows map[string]csvRow
+}
+
+func newRowSet(rows []csvRow) *rowSet {
+	s := &rowSet{rows: make(map[string]csvRow, len(rows))}
+	for i, row := range rows {
+		key := row.get("ID")
+		if key == "" {
+			// Строки старого формата без ID журнал не меняет.
+			key = "#" + strconv.Itoa(i)
+		}
+		s.put(key, row)
+	}
+	return s
+}
+
+func (s *rowSet) put(key string, row csvRow) {
+	if _, ok := s.rows[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.rows[key] = row
+}
+
+func (s *rowSet) remove(key string) {
+	if _, ok := s.rows[key]; !ok {
+		return
+	}
+	delete(s.rows, key)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool { return k == key })
+}
+
+func (s *rowSet) list() []csvRow {
+	rows := make([]csvRow, 0, len(s.keys))
+	for _, key := range s.keys {
+		rows = append(rows, s.rows[key])
+	}
+	return rows
+}
+
+// parseJournalPreamble читает версию формата и колонки коллекций из начала
+// журнала и возвращает, с какого байта и строки начинаются события. У
+// журналов без заголовка версия 2 и текущие колонки.
+func parseJournalPreamble(data []byte) (version int, headers map[string][]string, start, lines int) {
+	version = 2
+	headers = make(map[string][]string, len(kindOrder))
+	for _, kind := range kindOrder {
+		headers[kind] = headerOf(kind)
+	}
+	for bytes.HasPrefix(data[start:], []byte("#")) {
+		line, _, ok := bytes.Cut(data[start:], []byte("\n"))
+		if !ok {
+			break
+		}
+		if v, found := strings.CutPrefix(string(line), journalMarker+" v"); found {
+			version, _ = strconv.Atoi(v)
+		}
+		if columns, found := strings.CutPrefix(string(line), columnsPrefix); found {
+			fields := strings.Split(columns, ",")
+			headers[fields[0]] = fields[1:]
+		}
+		start += len(line) + 1
+		lines++
+	}
+	return version, headers, start, lines
+}
+
+// replayJournal применяет завершенные commit события журнала к строкам
+// коллекций и возвращает число примененных событий; записи журнала прошлых
+// версий формата приводятся к текущей. Отсутствующий журнал — не ошибка.
+// Оборванная последняя запись, записи с неверной суммой и нечитаемые строки
+// попадают в report. Оборванная запись в конце отрезается, чтобы новые
+// события не склеились с ней. Запись с нечитаемой строкой в середине
+// журнала пропускается, чтение продолжается со следующей записи, а сам
+// журнал не меняется: при загрузке с него снимается копия.
+func replayJournal(path string, tables map[string]*rowSet, report *loadReport) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	version, headers, start, preambleLines := parseJournalPreamble(data)
+	if version <= 0 {
+		return 0, fmt.Errorf("%s: неизвестная версия формата", journalFile)
+	}
+	if version > schemaVersion {
+		return 0, fmt.Errorf("%s: %w: версия %d, поддерживается до %d", journalFile, ErrNewerSchema, version, schemaVersion)
+	}
+	body := data[start:]
+	lineOf := func(offset int64) int {
+		return preambleLines + bytes.Count(body[:offset], []byte("\n")) + 1
+	}
+	// base — с какого байта body читает r; смещения ниже отсчитываются от
+	// начала body.
+	var base, committed, eventsEnd int64
+	r := newJournalReader(body)
+	applied := 0
+	var pending [][]string
+	var pendingLines []int
+	for {
+		event, err := r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.Is(err, io.EOF) && !errors.As(err, &parseErr) {
+				return applied, err
+			}
+			if parseErr != nil {
+				if next := nextRecord(body, committed); next >= 0 {
+					report.add(journalFile, lineOf(base)+parseErr.Line-1,
+						fmt.Sprintf("строка не читается (%v), запись журнала до следующего commit отброшена", parseErr.Err))
+					base, committed, eventsEnd = next, next, next
+					pending, pendingLines = nil, nil
+					r = newJournalReader(body[next:])
+					continue
+				}
+			}
+			// io.EOF или запись, оборванная при сбое: после нее нет ни
+			// одного commit, события без commit не применяются.
+			if int64(len(body)) > committed {
+				report.add(journalFile, lineOf(committed), "запись оборвана при сбое и отброшена")
+				return applied, os.Truncate(path, int64(start)+committed)
+			}
+			return applied, nil
+		}
+		line, _ := r.FieldPos(0)
+		line += lineOf(base) - 1
+		if event[0] != opCommit {
+			pending = append(pending, event)
+			pendingLines = append(pendingLines, line)
+			eventsEnd = base + r.InputOffset()
+			continue
+		}
+		// Записи журнала до появления сумм заканчиваются commit без суммы.
+		if len(event) > 1 {
+			sum := strconv.FormatUint(uint64(crc32.ChecksumIEEE(body[committed:eventsEnd])), 16)
+			if event[1] != sum {
+				report.add(journalFile, line, fmt.Sprintf("контрольная сумма не совпадает, отброшено событий: %d", len(pending)))
+				pending, pendingLines = nil, nil
+				committed = base + r.InputOffset()
+				eventsEnd = committed
+				continue
+			}
+		}
+		for i, e := range pending {
+			if len(e) < 3 || tables[e[1]] == nil {
+				continue
+			}
+			switch e[0] {
+			case opStore:
+				t := &table{kind: e[1], header: slices.Clone(headers[e[1]]), records: [][]string{e[2:]}, lines: pendingLines[i : i+1]}
+				if version < schemaVersion {
+					if _, err := migrate(t, version); err != nil {
+						return applied, err
+					}
+				}
+				row := t.rows(journalFile)[0]
+				tables[e[1]].put(row.get("ID"), row)
+			case opRemove:
+				tables[e[1]].remove(e[2])
+			}
+		}
+		applied += len(pending)
+		pending, pendingLines = nil, nil
+		committed = base + r.InputOffset()
+		eventsEnd = committed
+	}
+}
+
+func newJournalReader(data []byte) *csv.Reader {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	return r
+}
+
+// nextRecord возвращает начало записи, следующей за ближайшей после from
+// строкой commit, или -1, если строки commit дальше нет.
+func nextRecord(body []byte, from int64) int64 {
+	marker := []byte("\n" + opCommit + ",")
+	i := bytes.Index(body[from:], marker)
+	if i < 0 {
+		return -1
+	}
+	end := from + int64(i) + int64(len(marker))
+	if j := bytes.IndexByte(body[end:], '\n'); j >= 0 {
+		return end + int64(j) + 1
+	}
+	return int64(len(body))
+}
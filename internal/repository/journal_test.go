@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// storePlayers сохраняет игроков по одному, чтобы каждый попал в журнал
+// отдельной записью, и закрывает журнал без сворачивания, как при сбое.
+func storePlayers(t *testing.T, dir string, names ...string) []*model.Player {
+	t.Helper()
+	repo := NewCSVRepository(dir)
+	var players []*model.Player
+	for _, name := range names {
+		p := model.NewPlayer(name, model.White)
+		if err := repo.Store(p); err != nil {
+			t.Fatalf("Store(%s): %v", name, err)
+		}
+		players = append(players, p)
+	}
+	if err := repo.journal.close(); err != nil {
+		t.Fatal(err)
+	}
+	return players
+}
+
+func TestReplayJournalDamage(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func([]byte) []byte
+		loaded  []bool // какие из игроков Anna, Boris, Vera загружены
+		wantMsg string
+		// truncated — журнал обрезан до последнего commit; иначе он не
+		// меняется до копии.
+		truncated bool
+	}{
+		{
+			name: "нечитаемая строка в середине",
+			damage: func(data []byte) []byte {
+				return bytes.Replace(data, []byte("Boris"), []byte(`Bo"ris`), 1)
+			},
+			loaded:  []bool{true, false, true},
+			wantMsg: "строка не читается",
+		},
+		{
+			name: "нечитаемая строка в последней записи",
+			damage: func(data []byte) []byte {
+				return bytes.Replace(data, []byte("Vera"), []byte(`Ve"ra`), 1)
+			},
+			loaded:  []bool{true, true, false},
+			wantMsg: "строка не читается",
+		},
+		{
+			name: "неверная контрольная сумма",
+			damage: func(data []byte) []byte {
+				return bytes.Replace(data, []byte("Boris"), []byte("Borya"), 1)
+			},
+			loaded:  []bool{true, false, true},
+			wantMsg: "контрольная сумма не совпадает",
+		},
+		{
+			name: "оборванная запись в конце",
+			damage: func(data []byte) []byte {
+				return append(data, "store,players,torn,Gle"...)
+			},
+			loaded:    []bool{true, true, true},
+			wantMsg:   "запись оборвана",
+			truncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			players := storePlayers(t, dir, "Anna", "Boris", "Vera")
+			path := filepath.Join(dir, journalFile)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			damaged := tt.damage(data)
+			if err := os.WriteFile(path, damaged, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			repo := NewCSVRepository(dir)
+			err = repo.Load()
+			var corruption *CorruptionError
+			if !errors.As(err, &corruption) {
+				t.Fatalf("Load() = %v, ожидается CorruptionError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Load() = %v, ожидается %q", err, tt.wantMsg)
+			}
+			if len(corruption.Backups) != 1 {
+				t.Fatalf("копии: %v, ожидается копия журнала", corruption.Backups)
+			}
+			backup, err := os.ReadFile(corruption.Backups[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := damaged
+			if tt.truncated {
+				want = data
+			}
+			if !bytes.Equal(backup, want) {
+				t.Errorf("копия журнала:\n%s\nожидается:\n%s", backup, want)
+			}
+			checkPlayers(t, repo, players, tt.loaded)
+			repo.Close()
+
+			// Уцелевшие записи пережили сворачивание журнала в снимки.
+			reopened := NewCSVRepository(dir)
+			if err := reopened.Load(); err != nil {
+				t.Fatalf("повторный Load() = %v", err)
+			}
+			checkPlayers(t, reopened, players, tt.loaded)
+			reopened.Close()
+		})
+	}
+}
+
+func TestJournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewCSVRepository(dir)
+	anna := model.NewPlayer("Anna", model.White)
+	boris := model.NewPlayer("Boris", model.Black)
+	for _, p := range []*model.Player{anna, boris} {
+		if err := repo.Store(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	anna.Name = "Anya"
+	if err := repo.Store(anna); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Remove(boris); err != nil {
+		t.Fatal(err)
+	}
+
+	game, err := model.NewGameFromFEN("Gleb", "Dasha", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	for _, text := range []string{"e4", "e5", "Nf3"} {
+		move, err := game.ParseMoveText(text)
+		if err == nil {
+			err = game.MakeMove(move)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	err = repo.Transaction(func(tx Tx) error {
+		tx.Store(game.WhitePlayer)
+		tx.Store(game.BlackPlayer)
+		tx.Store(game)
+		for _, m := range game.Moves {
+			tx.Store(m)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Отмененная транзакция не попадает в журнал.
+	ghost := model.NewPlayer("Ghost", model.White)
+	rollback := errors.New("откат")
+	if err := repo.Transaction(func(tx Tx) error {
+		tx.Store(ghost)
+		return rollback
+	}); !errors.Is(err, rollback) {
+		t.Fatalf("Transaction() = %v", err)
+	}
+	// Сбой: журнал не свернут в снимки.
+	if err := repo.journal.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "players.csv")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("снимок игроков записан до сворачивания: %v", err)
+	}
+
+	for _, pass := range []string{"применение журнала", "загрузка снимков"} {
+		t.Run(pass, func(t *testing.T) {
+			loaded := NewCSVRepository(dir)
+			if err := loaded.Load(); err != nil {
+				t.Fatalf("Load() = %v", err)
+			}
+			defer loaded.Close()
+
+			if p, ok := loaded.Player(anna.ID); !ok || p.Name != "Anya" {
+				t.Errorf("Anna после загрузки: %v, %v; ожидается переименованная", p, ok)
+			}
+			for _, p := range []*model.Player{boris, ghost} {
+				if _, ok := loaded.Player(p.ID); ok {
+					t.Errorf("игрок %s загружен, хотя удален или не сохранен", p.Name)
+				}
+			}
+			g, ok := loaded.Game(game.ID)
+			if !ok {
+				t.Fatalf("партия %s не загружена", game.ID)
+			}
+			if g.FEN() != game.FEN() || g.GetMoveCount() != 3 {
+				t.Errorf("партия: %q после %d ходов, ожидается %q после 3", g.FEN(), g.GetMoveCount(), game.FEN())
+			}
+			if data, err := os.ReadFile(filepath.Join(dir, journalFile)); err == nil && len(data) > 0 {
+				t.Errorf("журнал не свернут после загрузки:\n%s", data)
+			}
+		})
+	}
+}
+
+func checkPlayers(t *testing.T, repo Repository, players []*model.Player, loaded []bool) {
+	t.Helper()
+	for i, p := range players {
+		if _, ok := repo.Player(p.ID); ok != loaded[i] {
+			t.Errorf("игрок %s загружен: %v, ожидается %v", p.Name, ok, loaded[i])
+		}
+	}
+}
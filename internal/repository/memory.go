@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Виды сущностей; совпадают с SliceChange.SliceType.
+const (
+	kindBoards   = "boards"
+	kindGames    = "games"
+	kindMoves    = "moves"
+	kindPlayers  = "players"
+	kindProfiles = "profiles"
+	kindRatings  = "ratings"
+
+	kindTournaments  = "tournaments"
+	kindParticipants = "participants"
+	kindPairings     = "pairings"
+)
+
+// kindOrder задает порядок захвата блокировок, чтобы транзакции, меняющие
+// несколько видов сущностей, не блокировали друг друга.
+var kindOrder = []string{
+	kindBoards, kindGames, kindMoves, kindPlayers, kindProfiles, kindRatings,
+	kindTournaments, kindParticipants, kindPairings,
+}
+
+// collection — упорядоченный набор сущностей одного вида. Наличие сущности
+// проверяется за O(1), поэтому повторный Store не зависит от размера набора.
+type collection[T comparable] struct {
+	mu    sync.RWMutex
+	items []T
+	index map[T]bool
+}
+
+// store добавляет элемент и возвращает операцию: add или update.
+// Вызывающий держит mu.
+func (c *collection[T]) store(item T) string {
+	if c.index[item] {
+		return "update"
+	}
+	if c.index == nil {
+		c.index = make(map[T]bool)
+	}
+	c.index[item] = true
+	c.items = append(c.items, item)
+	return "add"
+}
+
+// remove удаляет элемент и возвращает remove или пустую строку, если
+// удалять было нечего. Вызывающий держит mu.
+func (c *collection[T]) remove(item T) string {
+	if !c.index[item] {
+		return ""
+	}
+	delete(c.index, item)
+	c.items = slices.DeleteFunc(c.items, func(x T) bool { return x == item })
+	return "remove"
+}
+
+// replace заменяет содержимое набора; вызывающий держит mu.
+func (c *collection[T]) replace(items []T) {
+	c.items = items
+	c.index = make(map[T]bool, len(items))
+	for _, item := range items {
+		c.index[item] = true
+	}
+}
+
+func (c *collection[T]) list() []T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.items)
+}
+
+func (c *collection[T]) find(match func(T) bool) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, item := range c.items {
+		if match(item) {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// MemoryRepository хранит сущности только в памяти. Используется в тестах и
+// как основа CSVRepository.
+type MemoryRepository struct {
+	boards  collection[*model.Board]
+	games   collection[*model.Game]
+	moves   collection[*model.Move]
+	players collection[*model.Player]
+
+	profiles collection[*model.Profile]
+	ratings  collection[*model.RatingChange]
+	// profileMu не дает двум партиям одновременно создать профиль с одним
+	// именем или дважды пересчитать рейтинги по одной партии.
+	profileMu sync.Mutex
+
+	tournaments  collection[*model.Tournament]
+	participants collection[*model.Participant]
+	pairings     collection[*model.Pairing]
+
+	changes chan SliceChange
+
+	// persist сохраняет изменения одного вызова Store, Remove или Transaction;
+	// вызывается под блокировками всех затронутых наборов. nil — хранить
+	// только в памяти.
+	persist func(ops []txOp) error
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{changes: make(chan SliceChange, 128)}
+}
+
+// ProfileLock реализует ProfileLocker.
+func (r *MemoryRepository) ProfileLock() *sync.Mutex {
+	return &r.profileMu
+}
+
+func (r *MemoryRepository) mutex(kind string) *sync.RWMutex {
+	switch kind {
+	case kindBoards:
+		return &r.boards.mu
+	case kindGames:
+		return &r.games.mu
+	case kindMoves:
+		return &r.moves.mu
+	case kindProfiles:
+		return &r.profiles.mu
+	case kindRatings:
+		return &r.ratings.mu
+	case kindTournaments:
+		return &r.tournaments.mu
+	case kindParticipants:
+		return &r.participants.mu
+	case kindPairings:
+		return &r.pairings.mu
+	}
+	return &r.players.mu
+}
+
+func entityKind(entity model.GameEntity) (string, bool) {
+	switch entity.(type) {
+	case *model.Board:
+		return kindBoards, true
+	case *model.Game:
+		return kindGames, true
+	case *model.Move:
+		return kindMoves, true
+	case *model.Player:
+		return kindPlayers, true
+	case *model.Profile:
+		return kindProfiles, true
+	case *model.RatingChange:
+		return kindRatings, true
+	case *model.Tournament:
+		return kindTournaments, true
+	case *model.Participant:
+		return kindParticipants, true
+	case *model.Pairing:
+		return kindPairings, true
+	}
+	return "", false
+}
+
+// Store добавляет сущность и сохраняет её. Повторный вызов для уже
+// сохраненной сущности только сохраняет её текущее состояние.
+func (r *MemoryRepository) Store(entity model.GameEntity) error {
+	return r.apply([]txOp{{entity: entity}})
+}
+
+// Remove удаляет сущность, если она есть в репозитории.
+func (r *MemoryRepository) Remove(entity model.GameEntity) error {
+	return r.apply([]txOp{{entity: entity, remove: true}})
+}
+
+// Transaction выполняет fn и применяет все изменения, сделанные через tx,
+// вместе: другие вызовы не увидят их частично. Если fn вернула ошибку,
+// ничего не меняется.
+func (r *MemoryRepository) Transaction(fn func(tx Tx) error) error {
+	tx := &memoryTx{}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return r.apply(tx.ops)
+}
+
+type txOp struct {
+	entity model.GameEntity
+	remove bool
+}
+
+type memoryTx struct {
+	ops []txOp
+}
+
+func (tx *memoryTx) Store(entity model.GameEntity) {
+	tx.ops = append(tx.ops, txOp{entity: entity})
+}
+
+func (tx *memoryTx) Remove(entity model.GameEntity) {
+	tx.ops = append(tx.ops, txOp{entity: entity, remove: true})
+}
+
+// apply меняет наборы и сохраняет изменения. Если сохранить не удалось,
+// изменения остаются в памяти, а ошибка возвращается вызывающему.
+func (r *MemoryRepository) apply(ops []txOp) error {
+	touched := make(map[string]bool)
+	for _, op := range ops {
+		if kind, ok := entityKind(op.entity); ok {
+			touched[kind] = true
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	for _, kind := range kindOrder {
+		if touched[kind] {
+			r.mutex(kind).Lock()
+		}
+	}
+	var changes []SliceChange
+	for _, op := range ops {
+		kind, operation, details := r.applyOp(op)
+		if operation == "" {
+			continue
+		}
+		verb := "stored"
+		if op.remove {
+			verb = "removed"
+		}
+		changes = append(changes, SliceChange{SliceType: kind, Operation: operation, Details: verb + " " + details})
+	}
+	var err error
+	if r.persist != nil {
+		err = r.persist(ops)
+	}
+	for i := len(kindOrder) - 1; i >= 0; i-- {
+		if touched[kindOrder[i]] {
+			r.mutex(kindOrder[i]).Unlock()
+		}
+	}
+
+	for _, change := range changes {
+		r.LogChange(change.SliceType, change.Operation, change.Details)
+	}
+	if err != nil {
+		return fmt.Errorf("сохранение не удалось: %w", err)
+	}
+	return nil
+}
+
+// applyOp меняет набор и возвращает описание изменения; вызывающий держит
+// блокировку набора. Пустая operation — ничего не изменилось.
+func (r *MemoryRepository) applyOp(op txOp) (kind, operation, details string) {
+	switch e := op.entity.(type) {
+	case *model.Board:
+		return kindBoards, storeOrRemove(&r.boards, e, op.remove), "board " + e.ID
+	case *model.Game:
+		return kindGames, storeOrRemove(&r.games, e, op.remove), "game " + e.ID
+	case *model.Move:
+		return kindMoves, storeOrRemove(&r.moves, e, op.remove), fmt.Sprintf("move %s %s", e.ID, e.GetNotation())
+	case *model.Player:
+		return kindPlayers, storeOrRemove(&r.players, e, op.remove), fmt.Sprintf("player %s %s", e.ID, e.Name)
+	case *model.Profile:
+		return kindProfiles, storeOrRemove(&r.profiles, e, op.remove), fmt.Sprintf("profile %s %s", e.ID, e.Name)
+	case *model.RatingChange:
+		return kindRatings, storeOrRemove(&r.ratings, e, op.remove), fmt.Sprintf("rating %s %d -> %d", e.ProfileID, e.Before, e.After)
+	case *model.Tournament:
+		return kindTournaments, storeOrRemove(&r.tournaments, e, op.remove), fmt.Sprintf("tournament %s %s", e.ID, e.Name)
+	case *model.Participant:
+		return kindParticipants, storeOrRemove(&r.participants, e, op.remove), fmt.Sprintf("participant %s %s", e.ID, e.Name)
+	case *model.Pairing:
+		return kindPairings, storeOrRemove(&r.pairings, e, op.remove), fmt.Sprintf("pairing %s round %d board %d %s", e.ID, e.Round, e.Board, e.Result)
+	}
+	return "", "", ""
+}
+
+func storeOrRemove[T comparable](c *collection[T], item T, remove bool) string {
+	if remove {
+		return c.remove(item)
+	}
+	return c.store(item)
+}
+
+func (r *MemoryRepository) Board(id string) (*model.Board, bool) {
+	return r.boards.find(func(b *model.Board) bool { return b.ID == id })
+}
+
+func (r *MemoryRepository) Game(id string) (*model.Game, bool) {
+	return r.games.find(func(g *model.Game) bool { return g.ID == id })
+}
+
+func (r *MemoryRepository) Player(id string) (*model.Player, bool) {
+	return r.players.find(func(p *model.Player) bool { return p.ID == id })
+}
+
+func (r *MemoryRepository) Move(id string) (*model.Move, bool) {
+	return r.moves.find(func(m *model.Move) bool { return m.ID == id })
+}
+
+func (r *MemoryRepository) Profile(id string) (*model.Profile, bool) {
+	return r.profiles.find(func(p *model.Profile) bool { return p.ID == id })
+}
+
+// ProfileByName ищет профиль по имени без учета регистра.
+func (r *MemoryRepository) ProfileByName(name string) (*model.Profile, bool) {
+	return r.profiles.find(func(p *model.Profile) bool { return strings.EqualFold(p.Name, name) })
+}
+
+func (r *MemoryRepository) Profiles() []*model.Profile {
+	return r.profiles.list()
+}
+
+// RatingHistory возвращает изменения рейтинга профиля в порядке партий.
+func (r *MemoryRepository) RatingHistory(profileID string) []*model.RatingChange {
+	r.ratings.mu.RLock()
+	defer r.ratings.mu.RUnlock()
+	var history []*model.RatingChange
+	for _, c := range r.ratings.items {
+		if c.ProfileID == profileID {
+			history = append(history, c)
+		}
+	}
+	return history
+}
+
+// IsRated сообщает, что рейтинги по партии уже пересчитаны.
+func (r *MemoryRepository) IsRated(gameID string) bool {
+	_, ok := r.ratings.find(func(c *model.RatingChange) bool { return c.GameID == gameID })
+	return ok
+}
+
+func (r *MemoryRepository) Tournament(id string) (*model.Tournament, bool) {
+	return r.tournaments.find(func(t *model.Tournament) bool { return t.ID == id })
+}
+
+func (r *MemoryRepository) Tournaments() []*model.Tournament {
+	return r.tournaments.list()
+}
+
+func (r *MemoryRepository) Boards() []*model.Board {
+	return r.boards.list()
+}
+
+func (r *MemoryRepository) Games() []*model.Game {
+	return r.games.list()
+}
+
+func (r *MemoryRepository) Moves() []*model.Move {
+	return r.moves.list()
+}
+
+func (r *MemoryRepository) Players() []*model.Player {
+	return r.players.list()
+}
+
+// Load ничего не делает: в памяти нечего загружать.
+func (r *MemoryRepository) Load() error {
+	return nil
+}
+
+// Close ничего не делает: в памяти нечего сохранять.
+func (r *MemoryRepository) Close() error {
+	return nil
+}
+
+func (r *MemoryRepository) Changes() <-chan SliceChange {
+	return r.changes
+}
+
+// LogChange сообщает об изменении подписчику Changes; если канал
+// переполнен, событие пропускается.
+func (r *MemoryRepository) LogChange(sliceType, operation, details string) {
+	select {
+	case r.changes <- SliceChange{
+		SliceType: sliceType,
+		Operation: operation,
+		Timestamp: time.Now(),
+		Details:   details,
+	}:
+	default:
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// profileLocks — блокировки профилей репозиториев, которые не реализуют
+// ProfileLocker.
+var profileLocks sync.Map // Repository → *sync.Mutex
+
+// profileLock возвращает блокировку профилей репозитория.
+func profileLock(repo Repository) *sync.Mutex {
+	if l, ok := repo.(ProfileLocker); ok {
+		return l.ProfileLock()
+	}
+	mu, _ := profileLocks.LoadOrStore(repo, new(sync.Mutex))
+	return mu.(*sync.Mutex)
+}
+
+// RegisterPlayer связывает игрока партии с профилем: находит профиль по
+// имени или создает новый и сохраняет оба.
+func RegisterPlayer(repo Repository, player *model.Player) (*model.Profile, error) {
+	mu := profileLock(repo)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if profile, ok := repo.Profile(player.ProfileID); ok {
+		return profile, nil
+	}
+	profile, created := profileByName(repo, player.Name)
+	player.ProfileID = profile.ID
+	return profile, repo.Transaction(func(tx Tx) error {
+		if created {
+			tx.Store(profile)
+		}
+		tx.Store(player)
+		return nil
+	})
+}
+
+// EnsureProfile возвращает профиль с именем name, создавая его при
+// необходимости.
+func EnsureProfile(repo Repository, name string) (*model.Profile, error) {
+	mu := profileLock(repo)
+	mu.Lock()
+	defer mu.Unlock()
+
+	profile, created := profileByName(repo, name)
+	if !created {
+		return profile, nil
+	}
+	return profile, repo.Store(profile)
+}
+
+// profileByName находит профиль по имени или создает новый, еще не
+// сохраненный; вызывающий держит profileLock(repo).
+func profileByName(repo Repository, name string) (profile *model.Profile, created bool) {
+	if profile, ok := repo.ProfileByName(name); ok {
+		return profile, false
+	}
+	return model.NewProfile(name), true
+}
+
+// RateGame пересчитывает рейтинги игроков законченной партии и возвращает
+// изменения. Партии без результата, партии с самим собой и уже оцененные
+// партии не меняют рейтинг; для них возвращается nil.
+func RateGame(repo Repository, game *model.Game) ([]*model.RatingChange, error) {
+	game.Mu.RLock()
+	gameID, result := game.ID, game.Result
+	finished := game.IsFinished()
+	whiteID, blackID := game.WhitePlayer.ProfileID, game.BlackPlayer.ProfileID
+	game.Mu.RUnlock()
+	if !finished || result == model.ResultNone || whiteID == "" || blackID == "" || whiteID == blackID {
+		return nil, nil
+	}
+
+	mu := profileLock(repo)
+	mu.Lock()
+	defer mu.Unlock()
+	if repo.IsRated(gameID) {
+		return nil, nil
+	}
+	white, ok := repo.Profile(whiteID)
+	if !ok {
+		return nil, fmt.Errorf("нет профиля %s", whiteID)
+	}
+	black, ok := repo.Profile(blackID)
+	if !ok {
+		return nil, fmt.Errorf("нет профиля %s", blackID)
+	}
+
+	white.Mu.Lock()
+	black.Mu.Lock()
+	whiteChange, blackChange := model.RateGame(white, black, result, gameID)
+	black.Mu.Unlock()
+	white.Mu.Unlock()
+
+	changes := []*model.RatingChange{whiteChange, blackChange}
+	return changes, repo.Transaction(func(tx Tx) error {
+		tx.Store(white)
+		tx.Store(black)
+		for _, c := range changes {
+			tx.Store(c)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// wrappedRepository — репозиторий другого пакета: видны только методы
+// Repository, своей блокировки профилей у него нет.
+type wrappedRepository struct {
+	Repository
+}
+
+func TestRegisterPlayerConcurrent(t *testing.T) {
+	repos := map[string]Repository{
+		"свой замок":        NewMemoryRepository(),
+		"без ProfileLocker": wrappedRepository{NewMemoryRepository()},
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			players := make([]*model.Player, 20)
+			for i := range players {
+				players[i] = model.NewPlayer("Анна", model.White)
+				wg.Add(1)
+				go func(p *model.Player) {
+					defer wg.Done()
+					if _, err := RegisterPlayer(repo, p); err != nil {
+						t.Error(err)
+					}
+				}(players[i])
+			}
+			wg.Wait()
+
+			if n := len(repo.Profiles()); n != 1 {
+				t.Fatalf("профилей: %d, ожидается один на имя", n)
+			}
+			for _, p := range players {
+				if p.ProfileID != players[0].ProfileID {
+					t.Errorf("игрок привязан к профилю %s, ожидается %s", p.ProfileID, players[0].ProfileID)
+				}
+			}
+		})
+	}
+}
+
+func TestRateGameOnce(t *testing.T) {
+	repo := NewMemoryRepository()
+	game := model.NewGame("Анна", "Борис", model.StandardBoardSize)
+	for _, p := range []*model.Player{game.WhitePlayer, game.BlackPlayer} {
+		if _, err := RegisterPlayer(repo, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	game.Start()
+	game.FinishWith(model.ResultWhiteWins, model.ReasonResignation)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := RateGame(repo, game); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	white, _ := repo.Profile(game.WhitePlayer.ProfileID)
+	black, _ := repo.Profile(game.BlackPlayer.ProfileID)
+	if white.Wins != 1 || black.Losses != 1 {
+		t.Errorf("белые +%d, черные −%d: партия оценена не один раз", white.Wins, black.Losses)
+	}
+	if white.Rating <= model.InitialRating || black.Rating >= model.InitialRating {
+		t.Errorf("рейтинги после победы белых: %d и %d", white.Rating, black.Rating)
+	}
+	if n := len(repo.RatingHistory(white.ID)); n != 1 {
+		t.Errorf("изменений рейтинга белых: %d, ожидается 1", n)
+	}
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Outcome — исход партии для игрока или цвета из запроса.
+type Outcome string
+
+const (
+	OutcomeAny  Outcome = ""
+	OutcomeWin  Outcome = "win"
+	OutcomeLoss Outcome = "loss"
+	OutcomeDraw Outcome = "draw"
+)
+
+// SortField — поле, по которому упорядочиваются найденные партии.
+type SortField string
+
+const (
+	SortByCreated SortField = "created"
+	SortByUpdated SortField = "updated"
+	SortByMoves   SortField = "moves"
+)
+
+// GameQuery описывает условия поиска партий. Пустые поля не ограничивают
+// поиск; все заданные условия должны выполняться одновременно.
+type GameQuery struct {
+	// Player — имя игрока без учета регистра. Вместе с Color ищутся партии,
+	// где игрок играл этим цветом, а Outcome считается для него.
+	Player string
+	// Color — цвет игрока Player; без Player — цвет, для которого считается Outcome.
+	Color   model.PlayerColor
+	Status  model.GameStatus
+	Result  model.GameResult
+	Outcome Outcome
+	// From и To ограничивают время создания партии: From включительно, To — нет.
+	From time.Time
+	To   time.Time
+	// BoardSize — размер доски; 0 — любой.
+	BoardSize int
+	// Opening — первые ходы партии в SAN, например «e4 e5 Nf3» или «1.e4 e5 2.Nf3».
+	Opening  string
+	MinMoves int
+
+	// SortBy — порядок выдачи, по умолчанию по времени создания.
+	SortBy     SortField
+	Descending bool
+	// Offset и Limit выбирают страницу результатов; Limit = 0 — все.
+	Offset int
+	Limit  int
+}
+
+// GamePage — страница найденных партий и общее число подходящих партий.
+type GamePage struct {
+	Games []*model.Game
+	Total int
+}
+
+// FindGames возвращает партии, подходящие под запрос. Партии проверяются на
+// месте, без копирования коллекции, а из подходящих хранится только окно
+// Offset+Limit лучших по порядку выдачи: память запроса зависит от размера
+// страницы, а не от числа партий. Без Limit выдаются все подходящие партии.
+//
+// Индексов нет: поиск перебирает партии, которые репозиторий держит в
+// памяти после Load, и время поиска растет линейно с их числом.
+func (r *MemoryRepository) FindGames(q GameQuery) GamePage {
+	opening := openingMoves(q.Opening)
+	window := -1
+	if q.Limit > 0 {
+		window = max(q.Offset, 0) + q.Limit
+	}
+
+	var page GamePage
+	var matches []gameMatch
+	r.games.mu.RLock()
+	for _, g := range r.games.items {
+		g.Mu.RLock()
+		ok := q.matches(g, opening)
+		m := gameMatch{game: g, created: g.CreatedAt, updated: g.UpdatedAt, moves: len(g.Moves)}
+		g.Mu.RUnlock()
+		if !ok {
+			continue
+		}
+		page.Total++
+		// Равные партии остаются в порядке коллекции: новая встает после
+		// них.
+		i, _ := slices.BinarySearchFunc(matches, m, func(a, b gameMatch) int {
+			if q.before(b, a) {
+				return 1
+			}
+			return -1
+		})
+		if window >= 0 && i >= window {
+			continue
+		}
+		matches = slices.Insert(matches, i, m)
+		if window >= 0 && len(matches) > window {
+			matches = matches[:window]
+		}
+	}
+	r.games.mu.RUnlock()
+
+	start := min(max(q.Offset, 0), len(matches))
+	for _, m := range matches[start:] {
+		page.Games = append(page.Games, m.game)
+	}
+	return page
+}
+
+// gameMatch — найденная партия с полями сортировки, снятыми под ее Mu.
+type gameMatch struct {
+	game    *model.Game
+	created time.Time
+	updated time.Time
+	moves   int
+}
+
+// before сообщает, выдается ли a раньше b.
+func (q GameQuery) before(a, b gameMatch) bool {
+	var c int
+	switch q.SortBy {
+	case SortByUpdated:
+		c = a.updated.Compare(b.updated)
+	case SortByMoves:
+		c = cmp.Compare(a.moves, b.moves)
+	default:
+		c = a.created.Compare(b.created)
+	}
+	if q.Descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+// matches проверяет партию; вызывающий держит g.Mu.
+func (q GameQuery) matches(g *model.Game, opening []string) bool {
+	if q.Status != "" && g.Status != q.Status {
+		return false
+	}
+	if q.Result != "" && g.Result != q.Result {
+		return false
+	}
+	if q.BoardSize > 0 && g.Board.Size != q.BoardSize {
+		return false
+	}
+	if len(g.Moves) < q.MinMoves {
+		return false
+	}
+	if !q.From.IsZero() && g.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !g.CreatedAt.Before(q.To) {
+		return false
+	}
+	if len(opening) > len(g.Moves) {
+		return false
+	}
+	for i, san := range opening {
+		if normalizeSAN(g.Moves[i].GetNotation()) != san {
+			return false
+		}
+	}
+
+	// Цвета, за которые мог играть искомый игрок.
+	colors := []model.PlayerColor{model.White, model.Black}
+	if q.Color != "" {
+		colors = []model.PlayerColor{q.Color}
+	}
+	if q.Player != "" {
+		colors = slices.DeleteFunc(colors, func(c model.PlayerColor) bool {
+			return !strings.EqualFold(playerOf(g, c).Name, q.Player)
+		})
+	}
+	if len(colors) == 0 {
+		return false
+	}
+	if q.Outcome == OutcomeAny {
+		return true
+	}
+	return slices.ContainsFunc(colors, func(c model.PlayerColor) bool {
+		return outcomeFor(g.Result, c) == q.Outcome
+	})
+}
+
+func playerOf(g *model.Game, color model.PlayerColor) *model.Player {
+	if color == model.White {
+		return g.WhitePlayer
+	}
+	return g.BlackPlayer
+}
+
+// outcomeFor возвращает исход партии для стороны; у незаконченной партии
+// исхода нет.
+func outcomeFor(result model.GameResult, color model.PlayerColor) Outcome {
+	switch result {
+	case model.ResultDraw:
+		return OutcomeDraw
+	case model.WinFor(color):
+		return OutcomeWin
+	case model.WinFor(color.Opponent()):
+		return OutcomeLoss
+	}
+	return OutcomeAny
+}
+
+// openingMoves разбирает запись первых ходов, пропуская номера ходов.
+func openingMoves(s string) []string {
+	var moves []string
+	for _, token := range strings.Fields(s) {
+		// «1.e4» и «1...e5» — номер хода вместе с ходом.
+		if i := strings.LastIndex(token, "."); i >= 0 {
+			token = token[i+1:]
+		}
+		if token = normalizeSAN(token); token != "" {
+			moves = append(moves, token)
+		}
+	}
+	return moves
+}
+
+// normalizeSAN убирает из записи хода знаки шаха, мата и оценки.
+func normalizeSAN(san string) string {
+	return strings.TrimRight(san, "+#!?")
+}
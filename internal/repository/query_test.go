@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// playedGame начинает партию с начальной позиции и делает в ней ходы.
+func playedGame(t *testing.T, white, black string, moves ...string) *model.Game {
+	t.Helper()
+	game, err := model.NewGameFromFEN(white, black, model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	for _, text := range moves {
+		move, err := game.ParseMoveText(text)
+		if err == nil {
+			err = game.MakeMove(move)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	return game
+}
+
+func TestFindGames(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	games := []*model.Game{
+		playedGame(t, "Иван", "Петр", "e4", "e5", "Nf3", "Nc6"),
+		playedGame(t, "Петр", "Иван", "d4", "d5"),
+		playedGame(t, "Иван", "Мария", "e4", "c5"),
+		playedGame(t, "Мария", "Петр", "e4", "e5"),
+		model.NewGame("Анна", "Иван", 10),
+	}
+	games[0].FinishWith(model.ResultWhiteWins, model.ReasonResignation)
+	games[1].FinishWith(model.ResultWhiteWins, model.ReasonResignation)
+	games[2].FinishWith(model.ResultDraw, model.ReasonAgreement)
+	games[4].Start()
+
+	repo := NewMemoryRepository()
+	for i, g := range games {
+		// Создавались по дню подряд, а менялись в обратном порядке.
+		g.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		g.UpdatedAt = base.Add(time.Duration(len(games)-i) * time.Hour)
+		if err := repo.Store(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query GameQuery
+		want  []int // номера партий в порядке выдачи
+		total int   // 0 — len(want)
+	}{
+		{"без условий", GameQuery{}, []int{0, 1, 2, 3, 4}, 0},
+		{"игрок без учета регистра", GameQuery{Player: "иван"}, []int{0, 1, 2, 4}, 0},
+		{"игрок белыми", GameQuery{Player: "Иван", Color: model.White}, []int{0, 2}, 0},
+		{"поражения игрока черными", GameQuery{Player: "Иван", Color: model.Black, Outcome: OutcomeLoss}, []int{1}, 0},
+		{"победы игрока", GameQuery{Player: "Иван", Outcome: OutcomeWin}, []int{0}, 0},
+		{"ничьи игрока", GameQuery{Player: "Мария", Outcome: OutcomeDraw}, []int{2}, 0},
+		{"победы белых", GameQuery{Color: model.White, Outcome: OutcomeWin}, []int{0, 1}, 0},
+		{"победы черных", GameQuery{Color: model.Black, Outcome: OutcomeWin}, nil, 0},
+		{"незнакомый игрок", GameQuery{Player: "Олег"}, nil, 0},
+		{"идущие", GameQuery{Status: model.StatusInProgress}, []int{3, 4}, 0},
+		{"ничьи", GameQuery{Result: model.ResultDraw}, []int{2}, 0},
+		{"даты, To не включается", GameQuery{From: games[1].CreatedAt, To: games[3].CreatedAt}, []int{1, 2}, 0},
+		{"только From", GameQuery{From: games[3].CreatedAt}, []int{3, 4}, 0},
+		{"размер доски", GameQuery{BoardSize: 10}, []int{4}, 0},
+		{"дебют без номеров", GameQuery{Opening: "e4 e5"}, []int{0, 3}, 0},
+		{"дебют с номерами", GameQuery{Opening: "1.e4 e5 2.Nf3"}, []int{0}, 0},
+		{"дебют длиннее партии", GameQuery{Opening: "e4 e5 Nf3 Nc6 Bb5"}, nil, 0},
+		{"минимум ходов", GameQuery{MinMoves: 3}, []int{0}, 0},
+		{"по созданию в обратном порядке", GameQuery{Descending: true}, []int{4, 3, 2, 1, 0}, 0},
+		{"по изменению", GameQuery{SortBy: SortByUpdated}, []int{4, 3, 2, 1, 0}, 0},
+		{"по числу ходов, равные по порядку", GameQuery{SortBy: SortByMoves}, []int{4, 1, 2, 3, 0}, 0},
+		{"по числу ходов в обратном порядке", GameQuery{SortBy: SortByMoves, Descending: true}, []int{0, 1, 2, 3, 4}, 0},
+		{"страница", GameQuery{Offset: 1, Limit: 2}, []int{1, 2}, 5},
+		{"последняя неполная страница", GameQuery{SortBy: SortByUpdated, Offset: 3, Limit: 5}, []int{1, 0}, 5},
+		{"смещение без лимита", GameQuery{Offset: 3}, []int{3, 4}, 5},
+		{"смещение за концом", GameQuery{Offset: 10, Limit: 2}, nil, 5},
+		{"первая партия игрока с конца", GameQuery{Player: "Иван", Descending: true, Limit: 1}, []int{4}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := repo.FindGames(tt.query)
+			var got []int
+			for _, g := range page.Games {
+				got = append(got, slices.Index(games, g))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("партии %v, ожидается %v", got, tt.want)
+			}
+			total := tt.total
+			if total == 0 {
+				total = len(tt.want)
+			}
+			if page.Total != total {
+				t.Errorf("всего %d, ожидается %d", page.Total, total)
+			}
+		})
+	}
+}
+
+func TestFindGamesPagesMatchFullSort(t *testing.T) {
+	repo := NewMemoryRepository()
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 30 {
+		g := model.NewGame("Белые", "Черные", 8)
+		// Повторяющиеся моменты создания проверяют порядок равных партий.
+		g.CreatedAt = base.Add(time.Duration(i*7%5) * time.Minute)
+		if err := repo.Store(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, desc := range []bool{false, true} {
+		all := repo.FindGames(GameQuery{Descending: desc}).Games
+		for _, limit := range []int{1, 4, 7} {
+			t.Run(fmt.Sprintf("desc=%v limit=%d", desc, limit), func(t *testing.T) {
+				var paged []*model.Game
+				for offset := 0; offset < len(all); offset += limit {
+					page := repo.FindGames(GameQuery{Descending: desc, Offset: offset, Limit: limit})
+					if page.Total != len(all) {
+						t.Fatalf("всего %d, ожидается %d", page.Total, len(all))
+					}
+					paged = append(paged, page.Games...)
+				}
+				if !slices.Equal(paged, all) {
+					t.Errorf("страницы по %d не совпадают с полной выдачей", limit)
+				}
+			})
+		}
+	}
+}
@@ -8,15 +8,6 @@ import (
 	"github.com/imyakin/go_hw/internal/model"
 )
 
-var boards []*model.Board
-var games []*model.Game
-var moves []*model.Move
-var players []*model.Player
-var muBoards sync.RWMutex
-var muGames sync.RWMutex
-var muMoves sync.RWMutex
-var muPlayers sync.RWMutex
-
 type SliceChange struct {
 	SliceType string
 	Operation string
@@ -24,102 +15,84 @@ type SliceChange struct {
 	Details   string
 }
 
-var SliceChangeChan = make(chan SliceChange, 128)
+// Repository хранит доски, партии, ходы, игроков, их профили и турниры.
+type Repository interface {
+	// Store добавляет сущность или сохраняет новое состояние уже добавленной.
+	// Ошибка означает, что изменение есть в памяти, но не сохранено.
+	Store(entity model.GameEntity) error
+	// Remove удаляет сущность, если она есть в репозитории.
+	Remove(entity model.GameEntity) error
+	// Transaction применяет все изменения, сделанные через tx, вместе;
+	// если fn вернула ошибку, не применяется ничего.
+	Transaction(fn func(tx Tx) error) error
 
-func Store(entity model.GameEntity) {
-	switch e := entity.(type) {
-	case *model.Board:
-		muBoards.Lock()
-		boards = append(boards, e)
-		saveBoardsCSV()
-		muBoards.Unlock()
-		notifySliceChange("boards", "add", fmt.Sprintf("added board %p, saved to CSV", e))
-	case *model.Game:
-		muGames.Lock()
-		games = append(games, e)
-		saveGamesCSV()
-		muGames.Unlock()
-		notifySliceChange("games", "add", fmt.Sprintf("added game %p, saved to CSV", e))
-	case *model.Move:
-		muMoves.Lock()
-		moves = append(moves, e)
-		saveMovesCSV()
-		muMoves.Unlock()
-		notifySliceChange("moves", "add", fmt.Sprintf("added move %s, saved to CSV", e.GetNotation()))
-	case *model.Player:
-		muPlayers.Lock()
-		players = append(players, e)
-		savePlayersCSV()
-		muPlayers.Unlock()
-		notifySliceChange("players", "add", fmt.Sprintf("added player %s, saved to CSV", e.Name))
-	}
-}
+	Board(id string) (*model.Board, bool)
+	Game(id string) (*model.Game, bool)
+	Player(id string) (*model.Player, bool)
+	Move(id string) (*model.Move, bool)
 
-func RemoveGame(game *model.Game) {
-	muGames.Lock()
-	defer muGames.Unlock()
-	for i, g := range games {
-		if g == game {
-			games = append(games[:i], games[i+1:]...)
-			saveGamesCSV()
-			notifySliceChange("games", "remove", fmt.Sprintf("removed game %p, saved to CSV", game))
-			return
-		}
-	}
-}
+	Boards() []*model.Board
+	Games() []*model.Game
+	Moves() []*model.Move
+	Players() []*model.Player
 
-func GetBoards() []*model.Board {
-	muBoards.RLock()
-	defer muBoards.RUnlock()
-	copied := make([]*model.Board, len(boards))
-	copy(copied, boards)
-	return copied
-}
+	// Profile и ProfileByName ищут профиль игрока; имя сравнивается без
+	// учета регистра.
+	Profile(id string) (*model.Profile, bool)
+	ProfileByName(name string) (*model.Profile, bool)
+	Profiles() []*model.Profile
+	// RatingHistory возвращает изменения рейтинга профиля по порядку.
+	RatingHistory(profileID string) []*model.RatingChange
+	// IsRated сообщает, что рейтинги по партии уже пересчитаны.
+	IsRated(gameID string) bool
 
-func GetGames() []*model.Game {
-	muGames.RLock()
-	defer muGames.RUnlock()
-	copied := make([]*model.Game, len(games))
-	copy(copied, games)
-	return copied
-}
+	// Турнир сохраняется вместе со своими участниками и парами, как партия
+	// вместе с ходами.
+	Tournament(id string) (*model.Tournament, bool)
+	Tournaments() []*model.Tournament
 
-func GetMoves() []*model.Move {
-	muMoves.RLock()
-	defer muMoves.RUnlock()
-	copied := make([]*model.Move, len(moves))
-	copy(copied, moves)
-	return copied
-}
+	// FindGames возвращает страницу партий, подходящих под запрос, не
+	// собирая все подходящие партии.
+	FindGames(q GameQuery) GamePage
+
+	// Load загружает данные, сохраненные в прошлых сессиях. Если часть
+	// данных повреждена, загружает остальное и возвращает *CorruptionError.
+	Load() error
+	// Close сохраняет несохраненные данные и освобождает файлы хранилища.
+	Close() error
 
-func GetPlayers() []*model.Player {
-	muPlayers.RLock()
-	defer muPlayers.RUnlock()
-	copied := make([]*model.Player, len(players))
-	copy(copied, players)
-	return copied
+	// Changes отдает события об изменениях коллекций для журнала.
+	Changes() <-chan SliceChange
+	LogChange(sliceType, operation, details string)
 }
 
-func notifySliceChange(sliceType, operation, details string) {
-	select {
-	case SliceChangeChan <- SliceChange{
-		SliceType: sliceType,
-		Operation: operation,
-		Timestamp: time.Now(),
-		Details:   details,
-	}:
-	default:
-	}
+// ProfileLocker — репозиторий со своей блокировкой профилей. Ею
+// RegisterPlayer, EnsureProfile и RateGame сериализуют создание профилей и
+// пересчет рейтингов; для репозиториев без нее блокировка заводится отдельно
+// на каждый репозиторий.
+type ProfileLocker interface {
+	ProfileLock() *sync.Mutex
 }
 
-func LogSliceChange(sliceType, operation, details string) {
-	notifySliceChange(sliceType, operation, details)
+// Tx собирает изменения транзакции.
+type Tx interface {
+	Store(entity model.GameEntity)
+	Remove(entity model.GameEntity)
 }
 
-func PrintStats() {
-	fmt.Println("=== Repository Stats ===")
-	fmt.Printf("Boards:  %d\n", len(GetBoards()))
-	fmt.Printf("Games:   %d\n", len(GetGames()))
-	fmt.Printf("Moves:   %d\n", len(GetMoves()))
-	fmt.Printf("Players: %d\n", len(GetPlayers()))
+// Хранилища, которые можно выбрать при запуске.
+const (
+	BackendCSV    = "csv"
+	BackendMemory = "memory"
+)
+
+// Open создает репозиторий с указанным хранилищем; dir — каталог для файлов.
+func Open(backend, dir string) (Repository, error) {
+	switch backend {
+	case BackendCSV:
+		return NewCSVRepository(dir), nil
+	case BackendMemory:
+		return NewMemoryRepository(), nil
+	}
+	return nil, fmt.Errorf("неизвестное хранилище %q: доступны %s и %s", backend, BackendCSV, BackendMemory)
 }
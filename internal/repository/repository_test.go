@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+func TestBoardByID(t *testing.T) {
+	game, err := model.NewGameFromFEN("Анна", "Борис", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board := game.Board
+
+	dir := t.TempDir()
+	backends := map[string]func() Repository{
+		BackendMemory: func() Repository { return NewMemoryRepository() },
+		BackendCSV:    func() Repository { return NewCSVRepository(dir) },
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			repo := open()
+			if err := repo.Store(board); err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := repo.Board(board.ID); !ok || got != board {
+				t.Fatalf("Board(%s) = %v, %v; ожидается сохраненная доска", board.ID, got, ok)
+			}
+			if _, ok := repo.Board("нет такой"); ok {
+				t.Errorf("Board нашел несуществующую доску")
+			}
+			if err := repo.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// После перезапуска доска загружается из снимка.
+	repo := NewCSVRepository(dir)
+	if err := repo.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	got, ok := repo.Board(board.ID)
+	if !ok {
+		t.Fatalf("Board(%s) не найдена после загрузки", board.ID)
+	}
+	if got.PlacementFEN() != board.PlacementFEN() {
+		t.Errorf("расстановка после загрузки %q, ожидается %q", got.PlacementFEN(), board.PlacementFEN())
+	}
+}
+
+// TestTakeBackRemovesMoves повторяет отмену хода в консоли: отмененные ходы
+// удаляются вместе с сохранением партии, и после перезапуска их нет.
+func TestTakeBackRemovesMoves(t *testing.T) {
+	dir := t.TempDir()
+	backends := map[string]func() Repository{
+		BackendMemory: func() Repository { return NewMemoryRepository() },
+		BackendCSV:    func() Repository { return NewCSVRepository(dir) },
+	}
+	games := make(map[string]*model.Game)
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			repo := open()
+			game := playedGame(t, "Анна", "Борис")
+			games[name] = game
+			err := repo.Transaction(func(tx Tx) error {
+				tx.Store(game.Board)
+				tx.Store(game.WhitePlayer)
+				tx.Store(game.BlackPlayer)
+				tx.Store(game)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, text := range []string{"e4", "e5", "Nf3", "Nc6"} {
+				move, err := game.ParseMoveText(text)
+				if err == nil {
+					err = game.MakeMove(move)
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", text, err)
+				}
+				err = repo.Transaction(func(tx Tx) error {
+					tx.Store(move)
+					tx.Store(game)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var undone []*model.Move
+			for range 2 {
+				move, err := game.Undo()
+				if err != nil {
+					t.Fatal(err)
+				}
+				undone = append(undone, move)
+			}
+			err = repo.Transaction(func(tx Tx) error {
+				for _, move := range undone {
+					tx.Remove(move)
+				}
+				tx.Store(game)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(repo.Moves()); n != 2 {
+				t.Errorf("ходов в репозитории %d, ожидается 2", n)
+			}
+			if err := repo.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	game := games[BackendCSV]
+	repo := NewCSVRepository(dir)
+	if err := repo.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if n := len(repo.Moves()); n != 2 {
+		t.Errorf("ходов после загрузки %d, ожидается 2", n)
+	}
+	for _, m := range repo.Moves() {
+		if m.GameID != game.ID {
+			t.Errorf("ход %s без партии: %s", m.GetNotation(), m.GameID)
+		}
+	}
+	loaded, ok := repo.Game(game.ID)
+	if !ok {
+		t.Fatal("партия не загружена")
+	}
+	if loaded.GetMoveCount() != 2 || loaded.FEN() != game.FEN() {
+		t.Errorf("после загрузки %d ходов, %s; ожидается 2 хода, %s", loaded.GetMoveCount(), loaded.FEN(), game.FEN())
+	}
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// schemaVersion — версия формата файлов данных, которую пишет программа.
+// Версия 1 — CSV без маркера версии, до появления снимков с контрольными
+// суммами; колонки в них опознаются по заголовку.
+const schemaVersion = 5
+
+var ErrNewerSchema = errors.New("формат данных новее, чем поддерживает программа")
+
+// table — содержимое файла коллекции, над которым работают миграции.
+type table struct {
+	kind    string
+	header  []string
+	records [][]string
+	lines   []int // номера строк записей в файле, для отчета о потерях
+}
+
+func (t *table) column(name string) int {
+	return slices.Index(t.header, name)
+}
+
+// addColumn добавляет колонку, если её нет, и заполняет её значением value.
+func (t *table) addColumn(name string, value func(rec []string) string) {
+	if t.column(name) >= 0 {
+		return
+	}
+	t.header = append(t.header, name)
+	for i, rec := range t.records {
+		t.records[i] = append(padRecord(rec, len(t.header)-1), value(rec))
+	}
+}
+
+// renameColumn переименовывает колонку, если она есть, а колонки с новым
+// именем еще нет.
+func (t *table) renameColumn(from, to string) {
+	if i := t.column(from); i >= 0 && t.column(to) < 0 {
+		t.header[i] = to
+	}
+}
+
+// update меняет значение колонки в каждой записи.
+func (t *table) update(name string, value func(rec []string, old string) string) {
+	i := t.column(name)
+	if i < 0 {
+		return
+	}
+	for j, rec := range t.records {
+		rec = padRecord(rec, len(t.header))
+		rec[i] = value(rec, rec[i])
+		t.records[j] = rec
+	}
+}
+
+func (t *table) get(rec []string, name string) string {
+	if i := t.column(name); i >= 0 && i < len(rec) {
+		return rec[i]
+	}
+	return ""
+}
+
+func (t *table) rows(source string) []csvRow {
+	columns := columnIndex(t.header)
+	rows := make([]csvRow, 0, len(t.records))
+	for i, rec := range t.records {
+		row := csvRow{columns: columns, record: rec, source: source}
+		if i < len(t.lines) {
+			row.line = t.lines[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// padRecord дополняет короткую запись пустыми полями до n полей.
+func padRecord(rec []string, n int) []string {
+	for len(rec) < n {
+		rec = append(rec, "")
+	}
+	return rec
+}
+
+// migration переводит таблицу из версии version-1 в version.
+type migration struct {
+	version     int
+	description string
+	apply       func(t *table) error
+}
+
+// migrations — все изменения формата по порядку. Чтобы изменить формат,
+// увеличьте schemaVersion и добавьте сюда миграцию, которая приводит
+// таблицы прошлой версии к новой.
+var migrations = []migration{
+	{
+		version:     2,
+		description: "постоянные ID и отметки времени, FEN вместо JSON-матриц клеток",
+		apply:       migrateToV2,
+	},
+	{
+		version:     3,
+		description: "ссылка игрока партии на профиль",
+		apply:       migrateToV3,
+	},
+	{
+		version:     4,
+		description: "время на обдумывание хода",
+		apply:       migrateToV4,
+	},
+	{
+		version: 5,
+		// Турниры хранятся в новых файлах, старые таблицы не меняются;
+		// версия не дает прошлым версиям программы потерять турниры из журнала.
+		description: "турниры",
+		apply:       func(*table) error { return nil },
+	},
+}
+
+// migrate приводит таблицу версии from к текущей версии и возвращает
+// описания примененных миграций.
+func migrate(t *table, from int) ([]string, error) {
+	if from > schemaVersion {
+		return nil, fmt.Errorf("%w: версия %d, поддерживается до %d", ErrNewerSchema, from, schemaVersion)
+	}
+	var applied []string
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		if err := m.apply(t); err != nil {
+			return applied, fmt.Errorf("миграция на версию %d: %w", m.version, err)
+		}
+		applied = append(applied, fmt.Sprintf("v%d: %s", m.version, m.description))
+	}
+	return applied, nil
+}
+
+// migrateToV2 выдает записям старого формата ID и отметки времени и
+// переводит клетки досок из JSON-матриц в FEN.
+func migrateToV2(t *table) error {
+	now := formatTime(time.Now())
+	t.addColumn("ID", func([]string) string { return "" })
+	t.update("ID", func(_ []string, id string) string {
+		if id == "" {
+			return model.NewID()
+		}
+		return id
+	})
+	t.addColumn("CreatedAt", func([]string) string { return now })
+	t.addColumn("UpdatedAt", func([]string) string { return now })
+
+	switch t.kind {
+	case kindBoards:
+		t.renameColumn("Cells", "Placement")
+		t.update("Placement", func(_ []string, cells string) string {
+			return cellsToPlacement(cells)
+		})
+	case kindGames:
+		t.renameColumn("Cells", "FEN")
+		t.update("FEN", func(rec []string, cells string) string {
+			if !isLegacyCells(cells) {
+				return cells
+			}
+			side := "w"
+			if t.get(rec, "CurrentPlayerColor") == string(model.Black) {
+				side = "b"
+			}
+			// Права на рокировку и взятие на проходе старый формат не хранил.
+			return cellsToPlacement(cells) + " " + side + " - - 0 1"
+		})
+	}
+	return nil
+}
+
+// migrateToV3 добавляет игрокам партий ссылку на профиль. Профили для
+// старых записей создаются, когда их партия продолжается.
+func migrateToV3(t *table) error {
+	if t.kind == kindPlayers {
+		t.addColumn("ProfileID", func([]string) string { return "" })
+	}
+	return nil
+}
+
+// migrateToV4 добавляет ходам время на обдумывание; у старых ходов оно не
+// измерено.
+func migrateToV4(t *table) error {
+	if t.kind == kindMoves {
+		t.addColumn("ThinkTime", func([]string) string { return "" })
+	}
+	return nil
+}
+
+// isLegacyCells сообщает, что клетки доски сохранены старым форматом —
+// JSON-матрицей вместо FEN.
+func isLegacyCells(value string) bool {
+	return strings.HasPrefix(value, "[")
+}
+
+// cellsToPlacement переводит квадратную JSON-матрицу клеток в расстановку
+// FEN; остальные значения возвращаются как есть, и загрузчик сообщит о них.
+func cellsToPlacement(value string) string {
+	if !isLegacyCells(value) {
+		return value
+	}
+	var cells [][]string
+	if err := json.Unmarshal([]byte(value), &cells); err != nil {
+		return value
+	}
+	for _, row := range cells {
+		if len(row) != len(cells) {
+			return value
+		}
+	}
+	return (&model.Board{Size: len(cells), Cells: cells}).PlacementFEN()
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Каталоги testdata/v1…v5 записаны версиями программы, которые писали
+// соответствующий формат: в каждом партия Anna — Boris после 1.e4 e5 2.Nf3,
+// а начиная с v2 еще и законченная сдачей черных после 1.d4. Снимки v2 —
+// первые, с контрольной суммой, но еще без номера версии в маркере.
+const afterNf3 = "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R"
+
+// copyFixture копирует файлы данных версии version во временный каталог.
+func copyFixture(t *testing.T, version int) string {
+	t.Helper()
+	src := filepath.Join("testdata", fmt.Sprintf("v%d", version))
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, e.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMigrateFixtures(t *testing.T) {
+	tests := []struct {
+		version  int
+		players  int
+		games    int
+		profiles int
+		// history — у партии есть ходы с привязкой к ней; в v1 ходы не
+		// ссылались на партию, и позиция восстанавливается из клеток.
+		history bool
+	}{
+		{version: 1, players: 2, games: 1},
+		{version: 2, players: 4, games: 2, history: true},
+		{version: 3, players: 4, games: 2, profiles: 2, history: true},
+		{version: 4, players: 4, games: 2, profiles: 2, history: true},
+		{version: 5, players: 4, games: 2, profiles: 2, history: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("v%d", tt.version), func(t *testing.T) {
+			dir := copyFixture(t, tt.version)
+			originals := map[string][]byte{}
+			for _, kind := range kindOrder {
+				if data, err := os.ReadFile(filepath.Join(dir, kind+".csv")); err == nil {
+					originals[kind] = data
+				}
+			}
+
+			repo := NewCSVRepository(dir)
+			if err := repo.Load(); err != nil {
+				t.Fatalf("Load() = %v", err)
+			}
+			checkFixture(t, repo, tt.players, tt.games, tt.profiles, tt.history)
+			if err := repo.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			for kind, original := range originals {
+				name := kind + ".csv"
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if snapshotVersion(data) != schemaVersion {
+					t.Errorf("%s после загрузки версии %d", name, snapshotVersion(data))
+				}
+				backup, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%s.v%d", name, tt.version)))
+				switch {
+				case tt.version == schemaVersion && err == nil:
+					t.Errorf("копия %s снята без миграции", name)
+				case tt.version < schemaVersion && !bytes.Equal(backup, original):
+					t.Errorf("копия %s до миграции: %v", name, err)
+				}
+			}
+
+			// Перезаписанные файлы читаются без миграций и с теми же данными.
+			reopened := NewCSVRepository(dir)
+			if err := reopened.Load(); err != nil {
+				t.Fatalf("повторный Load() = %v", err)
+			}
+			checkFixture(t, reopened, tt.players, tt.games, tt.profiles, tt.history)
+			reopened.Close()
+		})
+	}
+}
+
+func checkFixture(t *testing.T, repo Repository, players, games, profiles int, history bool) {
+	t.Helper()
+	if n := len(repo.Players()); n != players {
+		t.Errorf("игроков %d, ожидается %d", n, players)
+	}
+	for _, p := range repo.Players() {
+		if p.ID == "" {
+			t.Errorf("игрок %s без ID", p.Name)
+		}
+		if profiles > 0 && p.ProfileID == "" {
+			t.Errorf("игрок %s не привязан к профилю", p.Name)
+		}
+	}
+	if n := len(repo.Boards()); n != 1 {
+		t.Errorf("досок %d, ожидается 1", n)
+	}
+	if n := len(repo.Profiles()); n != profiles {
+		t.Errorf("профилей %d, ожидается %d", n, profiles)
+	}
+	if n := len(repo.Games()); n != games {
+		t.Fatalf("партий %d, ожидается %d", n, games)
+	}
+
+	var inProgress, finished *model.Game
+	for _, g := range repo.Games() {
+		if g.IsFinished() {
+			finished = g
+		} else {
+			inProgress = g
+		}
+	}
+	if inProgress == nil {
+		t.Fatal("нет незаконченной партии")
+	}
+	if inProgress.WhitePlayer.Name != "Anna" || inProgress.BlackPlayer.Name != "Boris" {
+		t.Errorf("игроки %s и %s", inProgress.WhitePlayer.Name, inProgress.BlackPlayer.Name)
+	}
+	if got := inProgress.Board.PlacementFEN(); got != afterNf3 {
+		t.Errorf("позиция %q, ожидается %q", got, afterNf3)
+	}
+	if !inProgress.CurrentPlayer.IsBlack() {
+		t.Errorf("ход %s, ожидается ход черных", inProgress.CurrentPlayer.Color)
+	}
+	if !history {
+		return
+	}
+	if n := inProgress.GetMoveCount(); n != 3 {
+		t.Errorf("ходов %d, ожидается 3", n)
+	}
+	if inProgress.Clock == nil || inProgress.Clock.Control.String() != "5+3" {
+		t.Errorf("часы %v, ожидается контроль 5+3", inProgress.Clock)
+	}
+	if finished == nil {
+		t.Fatal("нет законченной партии")
+	}
+	if finished.Result != model.ResultWhiteWins || finished.ResultReason != model.ReasonResignation {
+		t.Errorf("итог %q (%s), ожидается победа белых сдачей", finished.Result, finished.ResultReason)
+	}
+	if profiles > 0 {
+		anna, ok := repo.ProfileByName("Anna")
+		if !ok || anna.Wins != 1 || anna.Rating <= model.InitialRating {
+			t.Errorf("профиль Anna: %+v", anna)
+		}
+		if !repo.IsRated(finished.ID) {
+			t.Errorf("законченная партия не отмечена как оцененная")
+		}
+	}
+}
+
+func TestNewerSchemaIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	current := NewCSVRepository(dir)
+	if err := current.Store(model.NewPlayer("Anna", model.White)); err != nil {
+		t.Fatal(err)
+	}
+	if err := current.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "players.csv")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer := bytes.Replace(data, []byte(fmt.Sprintf("v%d\n", schemaVersion)), []byte(fmt.Sprintf("v%d\n", schemaVersion+1)), 1)
+	if bytes.Equal(newer, data) {
+		t.Fatalf("в снимке нет маркера версии %d:\n%s", schemaVersion, data)
+	}
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewCSVRepository(dir)
+	if err := repo.Load(); !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("Load() = %v, ожидается ErrNewerSchema", err)
+	}
+	if err := repo.Store(model.NewPlayer("Vera", model.White)); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Store() = %v, ожидается отказ в записи", err)
+	}
+	repo.Close()
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, newer) {
+		t.Errorf("файл новой версии перезаписан")
+	}
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// openingPlies — число полуходов, по которым партии относятся к одному дебюту.
+const openingPlies = 4
+
+// Record — счет побед, поражений и ничьих.
+type Record struct {
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+func (r Record) Games() int {
+	return r.Wins + r.Losses + r.Draws
+}
+
+// WinRate возвращает долю побед; без партий — 0.
+func (r Record) WinRate() float64 {
+	if r.Games() == 0 {
+		return 0
+	}
+	return float64(r.Wins) / float64(r.Games())
+}
+
+func (r *Record) add(outcome Outcome) {
+	switch outcome {
+	case OutcomeWin:
+		r.Wins++
+	case OutcomeLoss:
+		r.Losses++
+	case OutcomeDraw:
+		r.Draws++
+	}
+}
+
+// Standing — место игрока в рейтинг-листе.
+type Standing struct {
+	Rank      int
+	ProfileID string
+	Name      string
+	Rating    int
+	Record
+}
+
+// OpeningCount — дебют и число партий, в которых он встретился.
+type OpeningCount struct {
+	Moves string // первые ходы, например «1.e4 e5 2.Nf3 Nc6»
+	Games int
+}
+
+// HeadToHead — счет личных встреч с соперником.
+type HeadToHead struct {
+	Opponent string
+	Record
+}
+
+// GameStats — сводка по партиям: всем или одного игрока.
+type GameStats struct {
+	Games    int
+	Finished int
+	// White и Black — результаты за каждый цвет. В сводке по всем партиям
+	// Black зеркален White.
+	White Record
+	Black Record
+	// AverageLength — среднее число полуходов законченной партии.
+	AverageLength float64
+	// AverageThinkTime — среднее время на ход по ходам, где оно измерено.
+	AverageThinkTime time.Duration
+	Openings         []OpeningCount
+}
+
+// PlayerStats — статистика игрока.
+type PlayerStats struct {
+	Standing
+	GameStats
+	HeadToHead []HeadToHead
+	History    []*model.RatingChange
+}
+
+// Leaderboard возвращает игроков по убыванию рейтинга; при равном рейтинге
+// выше тот, у кого больше побед.
+func Leaderboard(repo Repository) []Standing {
+	var standings []Standing
+	for _, p := range repo.Profiles() {
+		standings = append(standings, standingOf(p))
+	}
+	slices.SortStableFunc(standings, func(a, b Standing) int {
+		if c := cmp.Compare(b.Rating, a.Rating); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Wins, a.Wins); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+func standingOf(p *model.Profile) Standing {
+	p.Mu.RLock()
+	defer p.Mu.RUnlock()
+	return Standing{
+		ProfileID: p.ID,
+		Name:      p.Name,
+		Rating:    p.Rating,
+		Record:    Record{Wins: p.Wins, Losses: p.Losses, Draws: p.Draws},
+	}
+}
+
+// Summary собирает сводку по всем партиям; openings — сколько самых частых
+// дебютов вернуть.
+func Summary(repo Repository, openings int) GameStats {
+	var acc statsAccumulator
+	for _, g := range repo.Games() {
+		g.Mu.RLock()
+		acc.addGame(g, model.White, nil)
+		acc.mirrorBlack(g)
+		g.Mu.RUnlock()
+	}
+	return acc.result(openings)
+}
+
+// PlayerSummary собирает статистику игрока по его профилю. Партии, сыгранные
+// до появления профилей, узнаются по имени.
+func PlayerSummary(repo Repository, profile *model.Profile, openings int) PlayerStats {
+	stats := PlayerStats{Standing: standingOf(profile)}
+	for _, s := range Leaderboard(repo) {
+		if s.ProfileID == profile.ID {
+			stats.Rank = s.Rank
+		}
+	}
+
+	var acc statsAccumulator
+	opponents := make(map[string]*HeadToHead)
+	var order []string
+	for _, g := range repo.Games() {
+		g.Mu.RLock()
+		for _, color := range []model.PlayerColor{model.White, model.Black} {
+			if !isProfile(playerOf(g, color), profile) {
+				continue
+			}
+			opponent := playerOf(g, color.Opponent())
+			key := opponentKey(opponent)
+			h, ok := opponents[key]
+			if !ok {
+				h = &HeadToHead{Opponent: opponent.Name}
+				opponents[key] = h
+				order = append(order, key)
+			}
+			acc.addGame(g, color, &h.Record)
+		}
+		g.Mu.RUnlock()
+	}
+	stats.GameStats = acc.result(openings)
+
+	for _, key := range order {
+		if h := opponents[key]; h.Games() > 0 {
+			stats.HeadToHead = append(stats.HeadToHead, *h)
+		}
+	}
+	slices.SortStableFunc(stats.HeadToHead, func(a, b HeadToHead) int {
+		return cmp.Compare(b.Games(), a.Games())
+	})
+	stats.History = repo.RatingHistory(profile.ID)
+	return stats
+}
+
+// isProfile сообщает, что за игрока партии играл владелец профиля.
+func isProfile(player *model.Player, profile *model.Profile) bool {
+	if player.ProfileID != "" {
+		return player.ProfileID == profile.ID
+	}
+	return strings.EqualFold(player.Name, profile.Name)
+}
+
+func opponentKey(player *model.Player) string {
+	if player.ProfileID != "" {
+		return player.ProfileID
+	}
+	return "name:" + strings.ToLower(player.Name)
+}
+
+// statsAccumulator копит сводку по партиям.
+type statsAccumulator struct {
+	stats GameStats
+	plies int
+	// thinkSeconds — сумма времени на ходы в секундах: сумма в Duration
+	// переполнилась бы на ходах с большим измеренным временем.
+	thinkSeconds float64
+	thinkMoves   int
+	openings     map[string]int
+}
+
+// addGame учитывает партию за сторону color; h2h, если задан, получает исход
+// партии для этой стороны. Вызывающий держит g.Mu.
+func (a *statsAccumulator) addGame(g *model.Game, color model.PlayerColor, h2h *Record) {
+	a.stats.Games++
+	a.addThinkTime(g, color)
+	if len(g.Moves) >= openingPlies {
+		if a.openings == nil {
+			a.openings = make(map[string]int)
+		}
+		a.openings[formatOpening(g.StartFEN, g.Moves[:openingPlies])]++
+	}
+	if !g.IsFinished() {
+		return
+	}
+	a.stats.Finished++
+	a.plies += g.GetMoveCount()
+
+	outcome := outcomeFor(g.Result, color)
+	if color == model.White {
+		a.stats.White.add(outcome)
+	} else {
+		a.stats.Black.add(outcome)
+	}
+	if h2h != nil {
+		h2h.add(outcome)
+	}
+}
+
+// mirrorBlack учитывает результат партии за черных, не считая партию и её
+// ходы второй раз. Вызывающий держит g.Mu.
+func (a *statsAccumulator) mirrorBlack(g *model.Game) {
+	if g.IsFinished() {
+		a.stats.Black.add(outcomeFor(g.Result, model.Black))
+	}
+	a.addThinkTime(g, model.Black)
+}
+
+// addThinkTime учитывает время на ходы стороны color; ходы без измеренного
+// времени пропускаются. Вызывающий держит g.Mu.
+func (a *statsAccumulator) addThinkTime(g *model.Game, color model.PlayerColor) {
+	for _, m := range g.Moves {
+		if m.ThinkTime > 0 && m.Player != nil && m.Player.Color == color {
+			a.thinkSeconds += m.ThinkTime.Seconds()
+			a.thinkMoves++
+		}
+	}
+}
+
+func (a *statsAccumulator) result(openings int) GameStats {
+	stats := a.stats
+	if stats.Finished > 0 {
+		stats.AverageLength = float64(a.plies) / float64(stats.Finished)
+	}
+	if a.thinkMoves > 0 {
+		stats.AverageThinkTime = time.Duration(a.thinkSeconds / float64(a.thinkMoves) * float64(time.Second))
+	}
+	for moves, n := range a.openings {
+		stats.Openings = append(stats.Openings, OpeningCount{Moves: moves, Games: n})
+	}
+	slices.SortFunc(stats.Openings, func(x, y OpeningCount) int {
+		if c := cmp.Compare(y.Games, x.Games); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Moves, y.Moves)
+	})
+	if len(stats.Openings) > openings {
+		stats.Openings = stats.Openings[:max(openings, 0)]
+	}
+	return stats
+}
+
+// formatOpening записывает ходы с номерами: «1.e4 e5 2.Nf3 Nc6». Нумерация
+// начинается с номера хода и очереди из начальной позиции startFEN, поэтому
+// партия с позиции, где ходят черные, записывается как «12...Nf6 13.c4».
+func formatOpening(startFEN string, moves []*model.Move) string {
+	number, blackToMove := 1, false
+	if fields := strings.Fields(startFEN); len(fields) == 6 {
+		if n, err := strconv.Atoi(fields[5]); err == nil {
+			number = n
+		}
+		blackToMove = fields[1] == "b"
+	}
+
+	var b strings.Builder
+	for i, m := range moves {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch {
+		case !blackToMove:
+			fmt.Fprintf(&b, "%d.", number)
+		case i == 0:
+			fmt.Fprintf(&b, "%d...", number)
+		}
+		b.WriteString(normalizeSAN(m.GetNotation()))
+		if blackToMove {
+			number++
+		}
+		blackToMove = !blackToMove
+	}
+	return b.String()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+func TestFormatOpening(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  string
+	}{
+		{
+			name:  "начальная позиция",
+			fen:   model.StartFEN,
+			moves: []string{"e4", "e5", "Nf3", "Nc6"},
+			want:  "1.e4 e5 2.Nf3 Nc6",
+		},
+		{
+			name:  "ходят белые на 12-м ходу",
+			fen:   "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 12",
+			moves: []string{"Bb5", "a6", "Ba4", "Nf6"},
+			want:  "12.Bb5 a6 13.Ba4 Nf6",
+		},
+		{
+			name:  "ходят черные",
+			fen:   "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			moves: []string{"c5", "Nf3", "d6", "d4"},
+			want:  "1...c5 2.Nf3 d6 3.d4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := model.NewGameFromFEN("Белые", "Черные", tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			game.Start()
+			for _, text := range tt.moves {
+				move, err := game.ParseMoveText(text)
+				if err != nil {
+					t.Fatalf("%s: %v", text, err)
+				}
+				if err := game.MakeMove(move); err != nil {
+					t.Fatalf("%s: %v", text, err)
+				}
+			}
+			if got := formatOpening(game.StartFEN, game.Moves); got != tt.want {
+				t.Errorf("formatOpening() = %q, ожидается %q", got, tt.want)
+			}
+			if got := openingMoves(tt.want); len(got) != len(tt.moves) {
+				t.Errorf("openingMoves(%q) = %v: запись не разбирается обратно", tt.want, got)
+			}
+		})
+	}
+}
+
+// statsGames сохраняет партии для сводок. У Анны и Бориса есть профили;
+// партия «анна» — Борис сыграна до профилей, и игроки узнаются по имени.
+func statsGames(t *testing.T) (*MemoryRepository, *model.Profile) {
+	t.Helper()
+	repo := NewMemoryRepository()
+	games := []*model.Game{
+		playedGame(t, "Анна", "Борис", "e4", "e5", "Nf3", "Nc6"),
+		playedGame(t, "Борис", "Анна", "e4", "e5", "Nf3", "Nc6", "Bb5"),
+		playedGame(t, "анна", "Борис", "d4", "d5"),
+		playedGame(t, "Анна", "Вера", "e4", "e5", "Nf3", "Nc6"),
+		playedGame(t, "Вера", "Глеб", "d4", "d5", "c4", "e6"),
+	}
+	games[0].FinishWith(model.ResultWhiteWins, model.ReasonResignation)
+	games[1].FinishWith(model.ResultWhiteWins, model.ReasonResignation)
+	games[2].FinishWith(model.ResultDraw, model.ReasonAgreement)
+	games[4].FinishWith(model.ResultBlackWins, model.ReasonResignation)
+
+	// Время на ход в секундах по полуходам; 0 — не измерено.
+	thinkTimes := [][]int{{2, 10, 4, 0}, {20, 3, 0, 0, 0}}
+	for i, times := range thinkTimes {
+		for ply, sec := range times {
+			games[i].Moves[ply].ThinkTime = time.Duration(sec) * time.Second
+		}
+	}
+
+	for i, g := range games {
+		if i < 2 {
+			for _, p := range []*model.Player{g.WhitePlayer, g.BlackPlayer} {
+				if _, err := RegisterPlayer(repo, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := repo.Store(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := RegisterPlayer(repo, games[3].WhitePlayer); err != nil {
+		t.Fatal(err)
+	}
+	anna, _ := repo.ProfileByName("Анна")
+	return repo, anna
+}
+
+func TestSummary(t *testing.T) {
+	repo, _ := statsGames(t)
+	stats := Summary(repo, 5)
+	if stats.Games != 5 || stats.Finished != 4 {
+		t.Errorf("партий %d, законченных %d; ожидается 5 и 4", stats.Games, stats.Finished)
+	}
+	if want := (Record{Wins: 2, Losses: 1, Draws: 1}); stats.White != want {
+		t.Errorf("белые %+v, ожидается %+v", stats.White, want)
+	}
+	if want := (Record{Wins: 1, Losses: 2, Draws: 1}); stats.Black != want {
+		t.Errorf("черные %+v, ожидается %+v", stats.Black, want)
+	}
+	// Незаконченная партия в среднюю длину не входит: (4+5+2+4)/4.
+	if stats.AverageLength != 3.75 {
+		t.Errorf("средняя длина %v, ожидается 3.75", stats.AverageLength)
+	}
+	// Неизмеренные ходы не учитываются: (2+10+4+20+3)/5.
+	if want := 7800 * time.Millisecond; stats.AverageThinkTime != want {
+		t.Errorf("среднее время на ход %v, ожидается %v", stats.AverageThinkTime, want)
+	}
+	want := []OpeningCount{{"1.e4 e5 2.Nf3 Nc6", 3}, {"1.d4 d5 2.c4 e6", 1}}
+	if !slices.Equal(stats.Openings, want) {
+		t.Errorf("дебюты %v, ожидается %v", stats.Openings, want)
+	}
+	if top := Summary(repo, 1).Openings; !slices.Equal(top, want[:1]) {
+		t.Errorf("самый частый дебют %v, ожидается %v", top, want[:1])
+	}
+}
+
+func TestPlayerSummary(t *testing.T) {
+	repo, anna := statsGames(t)
+	stats := PlayerSummary(repo, anna, 3)
+	if stats.Games != 4 || stats.Finished != 3 {
+		t.Errorf("партий %d, законченных %d; ожидается 4 и 3", stats.Games, stats.Finished)
+	}
+	if want := (Record{Wins: 1, Draws: 1}); stats.White != want || stats.White.WinRate() != 0.5 {
+		t.Errorf("белыми %+v (%.2f), ожидается %+v и половина побед", stats.White, stats.White.WinRate(), want)
+	}
+	if want := (Record{Losses: 1}); stats.Black != want || stats.Black.WinRate() != 0 {
+		t.Errorf("черными %+v (%.2f), ожидается %+v без побед", stats.Black, stats.Black.WinRate(), want)
+	}
+	if want := 11.0 / 3; stats.AverageLength != want {
+		t.Errorf("средняя длина %v, ожидается %v", stats.AverageLength, want)
+	}
+	// Только ходы Анны: 2 и 4 белыми, 3 черными.
+	if stats.AverageThinkTime != 3*time.Second {
+		t.Errorf("среднее время на ход %v, ожидается 3s", stats.AverageThinkTime)
+	}
+	if want := []OpeningCount{{"1.e4 e5 2.Nf3 Nc6", 3}}; !slices.Equal(stats.Openings, want) {
+		t.Errorf("дебюты %v, ожидается %v", stats.Openings, want)
+	}
+
+	// Борис с профилем и Борис из партии до профилей — разные соперники;
+	// с Верой законченных партий нет.
+	want := []HeadToHead{
+		{Opponent: "Борис", Record: Record{Wins: 1, Losses: 1}},
+		{Opponent: "Борис", Record: Record{Draws: 1}},
+	}
+	if !slices.Equal(stats.HeadToHead, want) {
+		t.Errorf("личные встречи %+v, ожидается %+v", stats.HeadToHead, want)
+	}
+}
+
+func TestAverageThinkTimeDoesNotOverflow(t *testing.T) {
+	repo := NewMemoryRepository()
+	game := playedGame(t, "Анна", "Борис", "e4", "e5")
+	long := 200 * 365 * 24 * time.Hour
+	for _, m := range game.Moves {
+		m.ThinkTime = long
+	}
+	if err := repo.Store(game); err != nil {
+		t.Fatal(err)
+	}
+	got := Summary(repo, 0).AverageThinkTime
+	if diff := math.Abs((got - long).Seconds()); diff > 1 {
+		t.Errorf("среднее время на ход %v, ожидается %v", got, long)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	repo := NewMemoryRepository()
+	profiles := []struct {
+		name   string
+		rating int
+		wins   int
+	}{
+		{"Анна", 1600, 3},
+		{"Борис", 1600, 5},
+		{"Вера", 1500, 9},
+		{"глеб", 1600, 5},
+	}
+	for _, p := range profiles {
+		profile, err := EnsureProfile(repo, p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		profile.Rating, profile.Wins = p.rating, p.wins
+	}
+
+	var got []string
+	for i, s := range Leaderboard(repo) {
+		if s.Rank != i+1 {
+			t.Errorf("%s на месте %d, ожидается %d", s.Name, s.Rank, i+1)
+		}
+		got = append(got, s.Name)
+	}
+	// При равном рейтинге выше больше побед, затем имя без учета регистра.
+	if want := []string{"Борис", "глеб", "Анна", "Вера"}; !slices.Equal(got, want) {
+		t.Errorf("рейтинг-лист %v, ожидается %v", got, want)
+	}
+
+	anna, _ := repo.ProfileByName("Анна")
+	if stats := PlayerSummary(repo, anna, 0); stats.Rank != 3 || stats.Games != 0 {
+		t.Errorf("место Анны %d, партий %d; ожидается 3 и 0", stats.Rank, stats.Games)
+	}
+}
@@ -0,0 +1,416 @@
+// Package server отдает партии, ходы и игроков по HTTP в JSON, чтобы с
+// программой могли работать другие сервисы, а не только человек в консоли.
+//
+//	POST /api/games                 создать партию
+//	GET  /api/games                 список партий
+//	GET  /api/games/{id}            партия: доска, FEN, часы, результат
+//	GET  /api/games/{id}/moves      история ходов
+//	POST /api/games/{id}/moves      сделать ход
+//	POST /api/games/{id}/resign     сдаться
+//	GET  /api/players               игроки по рейтингу
+//	GET  /api/games/{id}/stream     трансляция партии (Server-Sent Events)
+//	GET  /api/stream?game={id}      трансляция нескольких партий; без game — всех
+//
+// Если партии одновременно играются в консоли (NewShared), через API можно
+// менять только партии, созданные через API; остальные доступны для чтения.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+// maxBodySize ограничивает размер тела запроса.
+const maxBodySize = 1 << 20
+
+// Server обрабатывает запросы API поверх репозитория.
+type Server struct {
+	repo repository.Repository
+	mux  *http.ServeMux
+	hub  *Hub
+
+	// shared — партии репозитория ведет и консоль; тогда менять можно
+	// только партии из created.
+	shared  bool
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+func New(repo repository.Repository) *Server {
+	s := &Server{
+		repo:    repo,
+		mux:     http.NewServeMux(),
+		hub:     NewHub(),
+		created: make(map[string]bool),
+	}
+	s.hub.expired = s.flag
+	s.mux.HandleFunc("POST /api/games", s.createGame)
+	s.mux.HandleFunc("GET /api/games", s.listGames)
+	s.mux.HandleFunc("GET /api/games/{id}", s.getGame)
+	s.mux.HandleFunc("GET /api/games/{id}/moves", s.getMoves)
+	s.mux.HandleFunc("POST /api/games/{id}/moves", s.makeMove)
+	s.mux.HandleFunc("POST /api/games/{id}/resign", s.resign)
+	s.mux.HandleFunc("GET /api/players", s.listPlayers)
+	s.mux.HandleFunc("GET /api/games/{id}/stream", s.streamGame)
+	s.mux.HandleFunc("GET /api/stream", s.streamAll)
+	return s
+}
+
+// NewShared создает сервер для процесса, в котором партии играются и в
+// консоли: ходы и сдача через API принимаются только в партиях, созданных
+// через API, иначе консоль и клиент API спорили бы за одну партию.
+func NewShared(repo repository.Repository) *Server {
+	s := New(repo)
+	s.shared = true
+	return s
+}
+
+// Hub возвращает трансляцию партий сервера. Партии, которые играются вне
+// API, попадают в нее через Hub.Update; часы рассылает Hub.Run.
+func (s *Server) Hub() *Hub {
+	return s.hub
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type createGameRequest struct {
+	White       string `json:"white"`
+	Black       string `json:"black"`
+	FEN         string `json:"fen"`          // начальная позиция; пусто — обычная
+	TimeControl string `json:"time_control"` // например 5+3; пусто — без часов
+}
+
+func (s *Server) createGame(w http.ResponseWriter, r *http.Request) {
+	var req createGameRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.White == "" || req.Black == "" {
+		writeError(w, http.StatusBadRequest, errors.New("нужны имена белых и черных"))
+		return
+	}
+	if req.FEN == "" {
+		req.FEN = model.StartFEN
+	}
+	game, err := model.NewGameFromFEN(req.White, req.Black, req.FEN)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.TimeControl != "" {
+		control, err := model.ParseTimeControl(req.TimeControl)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		game.SetTimeControl(control)
+	}
+	for _, player := range []*model.Player{game.WhitePlayer, game.BlackPlayer} {
+		if _, err := repository.RegisterPlayer(s.repo, player); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	game.Start()
+
+	err = s.repo.Transaction(func(tx repository.Tx) error {
+		tx.Store(game)
+		tx.Store(game.Board)
+		tx.Store(game.WhitePlayer)
+		tx.Store(game.BlackPlayer)
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.mu.Lock()
+	s.created[game.ID] = true
+	s.mu.Unlock()
+	s.hub.Update(game)
+	writeJSON(w, http.StatusCreated, viewGame(game, true))
+}
+
+func (s *Server) listGames(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q := repository.GameQuery{
+		Player: values.Get("player"),
+		Color:  model.PlayerColor(values.Get("color")),
+		Status: model.GameStatus(values.Get("status")),
+		Result: model.GameResult(values.Get("result")),
+		SortBy: repository.SortField(values.Get("sort")),
+		Limit:  20,
+	}
+	q.Descending, _ = strconv.ParseBool(values.Get("desc"))
+	for name, field := range map[string]*int{"limit": &q.Limit, "offset": &q.Offset} {
+		if value := values.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s: ожидается неотрицательное число", name))
+				return
+			}
+			*field = n
+		}
+	}
+
+	page := s.repo.FindGames(q)
+	result := gamePageView{Games: make([]gameView, 0, len(page.Games)), Total: page.Total}
+	for _, game := range page.Games {
+		result.Games = append(result.Games, viewGame(game, false))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) getGame(w http.ResponseWriter, r *http.Request) {
+	game, ok := s.game(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, viewGame(game, true))
+}
+
+func (s *Server) getMoves(w http.ResponseWriter, r *http.Request) {
+	game, ok := s.game(w, r)
+	if !ok {
+		return
+	}
+	game.Mu.RLock()
+	moves := make([]moveView, 0, len(game.Moves))
+	for _, m := range game.Moves {
+		moves = append(moves, viewMove(m))
+	}
+	game.Mu.RUnlock()
+	writeJSON(w, http.StatusOK, moves)
+}
+
+type moveRequest struct {
+	Move string `json:"move"` // SAN, «e2-e4» или UCI
+}
+
+// makeMove делает ход тем же путем, что и консоль: разбор записи, проверка
+// правил в Game.MakeMove, сохранение хода вместе с партией.
+func (s *Server) makeMove(w http.ResponseWriter, r *http.Request) {
+	game, ok := s.writableGame(w, r)
+	if !ok {
+		return
+	}
+	var req moveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	game.Mu.Lock()
+	move, err := game.ParseMoveText(req.Move)
+	if !game.IsInProgress() {
+		err = model.ErrGameNotInProgress
+	}
+	if err == nil {
+		thinkTime := time.Since(game.MoveStartTime)
+		if err = game.MakeMove(move); err == nil {
+			move.ThinkTime = thinkTime
+		}
+	}
+	game.Mu.Unlock()
+
+	var illegal *model.IllegalMoveError
+	switch {
+	case errors.As(err, &illegal):
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	case errors.Is(err, model.ErrTimeExpired):
+		// Флаг упал: партия закончилась по времени.
+		s.finish(game)
+		writeError(w, http.StatusConflict, err)
+		return
+	case errors.Is(err, model.ErrGameNotInProgress):
+		writeError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.repo.Transaction(func(tx repository.Tx) error {
+		tx.Store(move)
+		tx.Store(game)
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	game.Mu.RLock()
+	finished := game.IsFinished()
+	result := moveResultView{Move: viewMove(move)}
+	game.Mu.RUnlock()
+	if finished {
+		s.rate(game)
+	}
+	s.hub.Update(game)
+	result.Game = viewGame(game, true)
+	writeJSON(w, http.StatusCreated, result)
+}
+
+type resignRequest struct {
+	// Color — сдающаяся сторона; пусто — та, чей ход.
+	Color model.PlayerColor `json:"color"`
+}
+
+func (s *Server) resign(w http.ResponseWriter, r *http.Request) {
+	game, ok := s.writableGame(w, r)
+	if !ok {
+		return
+	}
+	var req resignRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Color != "" && req.Color != model.White && req.Color != model.Black {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("неизвестный цвет %q", req.Color))
+		return
+	}
+
+	game.Mu.Lock()
+	if !game.IsInProgress() {
+		game.Mu.Unlock()
+		writeError(w, http.StatusConflict, model.ErrGameNotInProgress)
+		return
+	}
+	color := req.Color
+	if color == "" {
+		color = game.CurrentPlayer.Color
+	}
+	game.FinishWith(model.WinFor(color.Opponent()), model.ReasonResignation)
+	game.Mu.Unlock()
+
+	if !s.finish(game) {
+		writeError(w, http.StatusInternalServerError, errors.New("партия закончена, но не сохранена"))
+		return
+	}
+	writeJSON(w, http.StatusOK, viewGame(game, true))
+}
+
+func (s *Server) listPlayers(w http.ResponseWriter, r *http.Request) {
+	standings := repository.Leaderboard(s.repo)
+	players := make([]profileView, 0, len(standings))
+	for _, standing := range standings {
+		players = append(players, viewStanding(standing))
+	}
+	writeJSON(w, http.StatusOK, players)
+}
+
+// game находит партию из пути запроса или отвечает 404.
+func (s *Server) game(w http.ResponseWriter, r *http.Request) (*model.Game, bool) {
+	id := r.PathValue("id")
+	game, ok := s.repo.Game(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("партия %q не найдена", id))
+		return nil, false
+	}
+	return game, true
+}
+
+// resume запускает часы незаконченной партии, загруженной из репозитория:
+// начало хода не сохраняется, и без этого время на ход считалось бы от
+// нулевого момента. Консоль делает то же при продолжении своих партий.
+func resume(game *model.Game) {
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+	if game.IsInProgress() && game.MoveStartTime.IsZero() {
+		game.Resume()
+	}
+}
+
+// writableGame находит партию, которую можно менять через API, или отвечает
+// 404 либо 403.
+func (s *Server) writableGame(w http.ResponseWriter, r *http.Request) (*model.Game, bool) {
+	game, ok := s.game(w, r)
+	if !ok {
+		return nil, false
+	}
+	if !s.writable(game.ID) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("партия %q ведется из консоли, через API ее можно только смотреть", game.ID))
+		return nil, false
+	}
+	resume(game)
+	return game, true
+}
+
+// writable сообщает, можно ли менять партию через API.
+func (s *Server) writable(id string) bool {
+	if !s.shared {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.created[id]
+}
+
+// flag заканчивает партию, у которой упал флажок, если ее ведет API: иначе
+// флажок проверит консоль.
+func (s *Server) flag(game *model.Game, now time.Time) {
+	if !s.writable(game.ID) {
+		return
+	}
+	game.Mu.Lock()
+	flagged := game.CheckFlag(now)
+	game.Mu.Unlock()
+	if flagged {
+		s.finish(game)
+	}
+}
+
+// finish сохраняет законченную партию, пересчитывает рейтинги игроков и
+// сообщает зрителям о результате.
+func (s *Server) finish(game *model.Game) bool {
+	s.hub.Update(game)
+	if err := s.repo.Store(game); err != nil {
+		return false
+	}
+	s.rate(game)
+	return true
+}
+
+func (s *Server) rate(game *model.Game) {
+	// Ошибка рейтинга не отменяет ход: результат партии уже сохранен.
+	if _, err := repository.RateGame(s.repo, game); err != nil {
+		s.repo.LogChange("ratings", "error", err.Error())
+	}
+}
+
+// decode читает JSON-тело запроса или отвечает 400. Пустое тело — пустой
+// запрос.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("неверный JSON: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+type errorView struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorView{Error: err.Error()})
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+// request выполняет запрос к обработчику и разбирает JSON-ответ в v, если
+// v не nil. Возвращает код ответа.
+func request(t *testing.T, h http.Handler, method, path, body string, v any) int {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if v != nil && w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: %v\n%s", method, path, err, w.Body)
+		}
+	}
+	return w.Code
+}
+
+// createGame создает партию через API и возвращает ее описание.
+func createGame(t *testing.T, h http.Handler, body string) gameView {
+	t.Helper()
+	var game gameView
+	if code := request(t, h, "POST", "/api/games", body, &game); code != http.StatusCreated {
+		t.Fatalf("POST /api/games %s = %d, ожидается %d", body, code, http.StatusCreated)
+	}
+	return game
+}
+
+func TestCreateGame(t *testing.T) {
+	srv := New(repository.NewMemoryRepository())
+	const fen = "4k3/8/8/8/8/8/8/R3K3 w - - 0 1"
+	created := createGame(t, srv, `{"white":"Анна","black":"Борис","fen":"`+fen+`","time_control":"5+3"}`)
+	if created.FEN != fen || created.Status != model.StatusInProgress || created.Turn != model.White {
+		t.Errorf("партия %+v, ожидается %s с ходом белых", created, fen)
+	}
+	if created.Clock == nil || created.Clock.Control != "5+3" || created.Clock.BlackMS != (5*time.Minute).Milliseconds() {
+		t.Errorf("часы %+v, ожидается 5+3", created.Clock)
+	}
+
+	var got gameView
+	if code := request(t, srv, "GET", "/api/games/"+created.ID, "", &got); code != http.StatusOK {
+		t.Fatalf("GET партии = %d", code)
+	}
+	if len(got.Board) != 8 || got.Board[0] != "....♚..." || got.Board[7] != "♖...♔..." {
+		t.Errorf("доска %q", got.Board)
+	}
+	if got.White.Name != "Анна" || got.Black.Name != "Борис" {
+		t.Errorf("игроки %s и %s", got.White.Name, got.Black.Name)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"без черных", `{"white":"Анна"}`},
+		{"неверная позиция", `{"white":"Анна","black":"Борис","fen":"8/8 w"}`},
+		{"неверный контроль", `{"white":"Анна","black":"Борис","time_control":"пять"}`},
+		{"лишнее поле", `{"white":"Анна","black":"Борис","color":"white"}`},
+		{"не JSON", `{"white":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := request(t, srv, "POST", "/api/games", tt.body, nil); code != http.StatusBadRequest {
+				t.Errorf("код %d, ожидается %d", code, http.StatusBadRequest)
+			}
+		})
+	}
+	if code := request(t, srv, "GET", "/api/games/нет", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET несуществующей партии = %d, ожидается %d", code, http.StatusNotFound)
+	}
+}
+
+func TestMakeMove(t *testing.T) {
+	srv := New(repository.NewMemoryRepository())
+	game := createGame(t, srv, `{"white":"Анна","black":"Борис"}`)
+	path := "/api/games/" + game.ID + "/moves"
+
+	tests := []struct {
+		name string
+		move string
+		code int
+	}{
+		{"SAN", "e4", http.StatusCreated},
+		{"длинная запись", "e7-e5", http.StatusCreated},
+		{"UCI", "g1f3", http.StatusCreated},
+		{"недопустимый ход", "Ke6", http.StatusUnprocessableEntity},
+		{"пешка бьет вперед", "e5e4", http.StatusUnprocessableEntity},
+		{"непонятная запись", "ход конем", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result moveResultView
+			code := request(t, srv, "POST", path, `{"move":"`+tt.move+`"}`, &result)
+			if code != tt.code {
+				t.Fatalf("POST %s = %d, ожидается %d", tt.move, code, tt.code)
+			}
+			if code == http.StatusCreated && result.Game.Moves != result.Move.Ply {
+				t.Errorf("ход %d, в партии %d ходов", result.Move.Ply, result.Game.Moves)
+			}
+		})
+	}
+
+	var moves []moveView
+	if code := request(t, srv, "GET", path, "", &moves); code != http.StatusOK {
+		t.Fatalf("GET ходов = %d", code)
+	}
+	var sans []string
+	for _, m := range moves {
+		sans = append(sans, m.SAN)
+	}
+	if got := strings.Join(sans, " "); got != "e4 e5 Nf3" {
+		t.Errorf("ходы %q, ожидается %q", got, "e4 e5 Nf3")
+	}
+	if moves[2].UCI != "g1f3" || moves[2].Color != model.White {
+		t.Errorf("третий ход %+v", moves[2])
+	}
+}
+
+func TestMoveAfterGameOver(t *testing.T) {
+	srv := New(repository.NewMemoryRepository())
+	game := createGame(t, srv, `{"white":"Анна","black":"Борис","fen":"7k/8/5K2/8/8/8/8/6Q1 w - - 0 1"}`)
+	path := "/api/games/" + game.ID + "/moves"
+
+	var result moveResultView
+	if code := request(t, srv, "POST", path, `{"move":"Qg7#"}`, &result); code != http.StatusCreated {
+		t.Fatalf("мат = %d", code)
+	}
+	if result.Game.Result != model.ResultWhiteWins || result.Game.Reason != model.ReasonCheckmate {
+		t.Errorf("итог %q (%s), ожидается мат черным", result.Game.Result, result.Game.Reason)
+	}
+	if code := request(t, srv, "POST", path, `{"move":"Kxg7"}`, nil); code != http.StatusConflict {
+		t.Errorf("ход после мата = %d, ожидается %d", code, http.StatusConflict)
+	}
+	if code := request(t, srv, "POST", "/api/games/"+game.ID+"/resign", "", nil); code != http.StatusConflict {
+		t.Errorf("сдача после мата = %d, ожидается %d", code, http.StatusConflict)
+	}
+}
+
+func TestResign(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		result model.GameResult
+	}{
+		{"сторона, чей ход", "", http.StatusOK, model.ResultBlackWins},
+		{"черные", `{"color":"black"}`, http.StatusOK, model.ResultWhiteWins},
+		{"неизвестный цвет", `{"color":"green"}`, http.StatusBadRequest, model.ResultNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(repository.NewMemoryRepository())
+			game := createGame(t, srv, `{"white":"Анна","black":"Борис"}`)
+			var got gameView
+			if code := request(t, srv, "POST", "/api/games/"+game.ID+"/resign", tt.body, &got); code != tt.code {
+				t.Fatalf("POST resign %s = %d, ожидается %d", tt.body, code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if got.Result != tt.result || got.Reason != model.ReasonResignation || got.Status != model.StatusFinished {
+				t.Errorf("итог %q (%s), ожидается %q сдачей", got.Result, got.Reason, tt.result)
+			}
+		})
+	}
+}
+
+func TestSharedServerProtectsConsoleGames(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	console, err := model.NewGameFromFEN("Анна", "Борис", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	console.Start()
+	if err := repo.Store(console); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewShared(repo)
+	path := "/api/games/" + console.ID
+	if code := request(t, srv, "GET", path, "", nil); code != http.StatusOK {
+		t.Errorf("GET консольной партии = %d, ожидается %d", code, http.StatusOK)
+	}
+	if code := request(t, srv, "POST", path+"/moves", `{"move":"e4"}`, nil); code != http.StatusForbidden {
+		t.Errorf("ход в консольной партии = %d, ожидается %d", code, http.StatusForbidden)
+	}
+	if code := request(t, srv, "POST", path+"/resign", "", nil); code != http.StatusForbidden {
+		t.Errorf("сдача в консольной партии = %d, ожидается %d", code, http.StatusForbidden)
+	}
+	if console.GetMoveCount() != 0 || !console.IsInProgress() {
+		t.Errorf("консольная партия изменена через API")
+	}
+
+	own := createGame(t, srv, `{"white":"Вера","black":"Глеб"}`)
+	if code := request(t, srv, "POST", "/api/games/"+own.ID+"/moves", `{"move":"e4"}`, nil); code != http.StatusCreated {
+		t.Errorf("ход в партии API = %d, ожидается %d", code, http.StatusCreated)
+	}
+}
+
+func TestThinkTimeOfLoadedGame(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	game, err := model.NewGameFromFEN("Анна", "Борис", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	// Начало хода не сохраняется: у загруженной партии оно нулевое.
+	game.MoveStartTime = time.Time{}
+	if err := repo.Store(game); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(repo)
+	var result moveResultView
+	if code := request(t, srv, "POST", "/api/games/"+game.ID+"/moves", `{"move":"e4"}`, &result); code != http.StatusCreated {
+		t.Fatalf("ход = %d", code)
+	}
+	if think := time.Duration(result.Move.ThinkTimeMS) * time.Millisecond; think < 0 || think > time.Minute {
+		t.Errorf("время на ход %v после загрузки партии", think)
+	}
+}
+
+func TestListGamesPaging(t *testing.T) {
+	srv := New(repository.NewMemoryRepository())
+	for range 3 {
+		createGame(t, srv, `{"white":"Анна","black":"Борис"}`)
+	}
+
+	var page gamePageView
+	if code := request(t, srv, "GET", "/api/games?limit=2&offset=0", "", &page); code != http.StatusOK {
+		t.Fatalf("GET /api/games = %d", code)
+	}
+	if page.Total != 3 || len(page.Games) != 2 {
+		t.Errorf("всего %d, на странице %d; ожидается 3 и 2", page.Total, len(page.Games))
+	}
+
+	for _, query := range []string{"limit=-1", "limit=два", "offset=-5", "offset=1.5"} {
+		if code := request(t, srv, "GET", "/api/games?"+query, "", nil); code != http.StatusBadRequest {
+			t.Errorf("GET /api/games?%s = %d, ожидается %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Трансляция партий зрителям через Server-Sent Events. Зритель сначала
+// получает снимок каждой партии (snapshot), затем события: move — сделан
+// ход, clock — показания часов раз в секунду, game_over — партия закончена.
+// Событие хода с ply не больше числа ходов в последнем снимке партии уже
+// учтено в снимке.
+
+const (
+	// streamBuffer — сколько событий может ждать отправки одному зрителю.
+	// Если зритель не успевает читать, события партии пропускаются, а когда
+	// очередь разобрана, он получает свежий снимок этой партии.
+	streamBuffer = 64
+	// clockInterval — как часто рассылаются показания часов.
+	clockInterval = time.Second
+	// heartbeatInterval — как часто в тишине отправляется комментарий, чтобы
+	// прокси не закрыли соединение.
+	heartbeatInterval = 15 * time.Second
+	// streamWriteTimeout — сколько ждать записи одного события; зритель,
+	// который не принимает данные дольше, отключается.
+	streamWriteTimeout = 10 * time.Second
+)
+
+const (
+	eventSnapshot = "snapshot"
+	eventMove     = "move"
+	eventClock    = "clock"
+	eventGameOver = "game_over"
+)
+
+type moveEventView struct {
+	GameID string            `json:"game_id"`
+	Move   moveView          `json:"move"`
+	FEN    string            `json:"fen"`
+	Turn   model.PlayerColor `json:"turn"`
+	Clock  *clockView        `json:"clock,omitempty"`
+}
+
+type clockEventView struct {
+	GameID string            `json:"game_id"`
+	Turn   model.PlayerColor `json:"turn"`
+	Clock  clockView         `json:"clock"`
+}
+
+type gameOverView struct {
+	GameID string             `json:"game_id"`
+	Result model.GameResult   `json:"result"`
+	Reason model.ResultReason `json:"reason,omitempty"`
+	Moves  int                `json:"moves"`
+}
+
+type event struct {
+	name string
+	game *model.Game
+	data any
+}
+
+// Hub рассылает изменения партий подписанным зрителям. Источники изменений
+// — API и консольные партии — вызывают Update после каждого сохранения
+// партии.
+type Hub struct {
+	mu      sync.Mutex
+	watched map[string]*watchedGame
+	subs    map[*subscriber]struct{}
+
+	// expired вызывается без mu для идущей партии, в которой у стороны,
+	// чья очередь хода, кончилось время; nil — партии ждут хода.
+	expired func(game *model.Game, now time.Time)
+}
+
+// watchedGame — партия, которая сейчас транслируется.
+type watchedGame struct {
+	game  *model.Game
+	moves int // сколько ходов уже разослано
+}
+
+type subscriber struct {
+	games  map[string]bool // nil — все партии
+	events chan event
+	// stale — партии, события которых не поместились в очередь; вместо них
+	// зритель получит снимок. Защищено Hub.mu.
+	stale map[string]*model.Game
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		watched: make(map[string]*watchedGame),
+		subs:    make(map[*subscriber]struct{}),
+	}
+}
+
+// Update рассылает новые ходы партии и ее результат. Партия, которую хаб
+// видит впервые, транслируется снимком, а не всей историей ходов; отмена
+// ходов тоже транслируется снимком. Вызывающий не держит Mu партии.
+func (h *Hub) Update(game *model.Game) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	var events []event
+	game.Mu.RLock()
+	w, ok := h.watched[game.ID]
+	if !ok {
+		if game.IsFinished() {
+			// О результате уже сообщено, либо партия закончилась до начала
+			// трансляции.
+			game.Mu.RUnlock()
+			return
+		}
+		w = &watchedGame{game: game, moves: -1}
+		h.watched[game.ID] = w
+	}
+	count := game.GetMoveCount()
+	if count < w.moves || w.moves < 0 {
+		h.resync(game)
+	} else {
+		for _, move := range game.Moves[w.moves:count] {
+			events = append(events, event{name: eventMove, game: game, data: moveEventView{
+				GameID: game.ID,
+				Move:   viewMove(move),
+				FEN:    game.FEN(),
+				Turn:   game.CurrentPlayer.Color,
+				Clock:  viewClock(game, now),
+			}})
+		}
+	}
+	finished := game.IsFinished()
+	if finished {
+		events = append(events, event{name: eventGameOver, game: game, data: gameOverView{
+			GameID: game.ID,
+			Result: game.Result,
+			Reason: game.ResultReason,
+			Moves:  count,
+		}})
+	}
+	game.Mu.RUnlock()
+
+	w.moves = count
+	for _, e := range events {
+		h.broadcast(e)
+	}
+	if finished {
+		delete(h.watched, game.ID)
+	}
+}
+
+// Run рассылает показания часов идущих партий и сообщает о партиях с
+// упавшим флажком, пока ctx не отменен.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(clockInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.tick(now)
+		}
+	}
+}
+
+func (h *Hub) tick(now time.Time) {
+	for _, game := range h.clocks(now) {
+		if h.expired != nil {
+			h.expired(game, now)
+		}
+	}
+}
+
+// clocks рассылает показания часов идущих партий и возвращает партии, в
+// которых у стороны, чья очередь хода, кончилось время.
+func (h *Hub) clocks(now time.Time) []*model.Game {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var expired []*model.Game
+	for _, w := range h.watched {
+		game := w.game
+		game.Mu.RLock()
+		clock := viewClock(game, now)
+		running := clock != nil && game.IsInProgress()
+		turn := game.CurrentPlayer.Color
+		flag := running && game.TimeLeft(turn, now) == 0
+		game.Mu.RUnlock()
+		if !running {
+			continue
+		}
+		if flag {
+			expired = append(expired, game)
+		}
+		if len(h.subs) > 0 {
+			h.broadcast(event{name: eventClock, game: game, data: clockEventView{
+				GameID: game.ID,
+				Turn:   turn,
+				Clock:  *clock,
+			}})
+		}
+	}
+	return expired
+}
+
+// broadcast ставит событие в очереди зрителей партии; вызывающий держит mu.
+func (h *Hub) broadcast(e event) {
+	for sub := range h.subs {
+		if !sub.watches(e.game.ID) {
+			continue
+		}
+		if _, ok := sub.stale[e.game.ID]; ok {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			sub.stale[e.game.ID] = e.game
+		}
+	}
+}
+
+// resync заменяет зрителям партии ожидающие события ее снимком; вызывающий
+// держит mu.
+func (h *Hub) resync(game *model.Game) {
+	for sub := range h.subs {
+		if sub.watches(game.ID) {
+			sub.stale[game.ID] = game
+		}
+	}
+}
+
+// subscribe подписывает зрителя на партии ids, пустой список — на все
+// транслируемые партии. Первыми зритель получит снимки games и
+// транслируемых партий из подписки.
+func (h *Hub) subscribe(ids []string, games []*model.Game) *subscriber {
+	sub := &subscriber{
+		events: make(chan event, streamBuffer),
+		stale:  make(map[string]*model.Game),
+	}
+	if len(ids) > 0 {
+		sub.games = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			sub.games[id] = true
+		}
+	}
+	for _, game := range games {
+		sub.stale[game.ID] = game
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, w := range h.watched {
+		if sub.watches(id) {
+			sub.stale[id] = w.game
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, sub)
+}
+
+// snapshots забирает партии, по которым зритель пропустил события, и
+// возвращает их снимки. Снимок строится под mu, поэтому следующие события
+// партии в очереди зрителя будут новее снимка.
+func (h *Hub) snapshots(sub *subscriber) []event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var events []event
+	for id, game := range sub.stale {
+		events = append(events, event{name: eventSnapshot, game: game, data: viewGame(game, true)})
+		delete(sub.stale, id)
+	}
+	return events
+}
+
+func (s *subscriber) watches(id string) bool {
+	return s.games == nil || s.games[id]
+}
+
+// streamAll транслирует партии из параметров game запроса, без них — все
+// транслируемые партии.
+func (s *Server) streamAll(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["game"]
+	var games []*model.Game
+	for _, id := range ids {
+		game, ok := s.repo.Game(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("партия %q не найдена", id))
+			return
+		}
+		games = append(games, game)
+	}
+	s.stream(w, r, ids, games)
+}
+
+// streamGame транслирует одну партию.
+func (s *Server) streamGame(w http.ResponseWriter, r *http.Request) {
+	game, ok := s.game(w, r)
+	if !ok {
+		return
+	}
+	s.stream(w, r, []string{game.ID}, []*model.Game{game})
+}
+
+// stream отправляет события зрителю, пока тот не отключится.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, ids []string, games []*model.Game) {
+	// Партии, которые с запуска сервера не менялись, начинают
+	// транслироваться с первым зрителем.
+	for _, game := range games {
+		s.hub.Update(game)
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	sub := s.hub.subscribe(ids, games)
+	defer s.hub.unsubscribe(sub)
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	id := 0
+	send := func(write func(io.Writer) error) bool {
+		// Медленный зритель отключается, а не задерживает трансляцию.
+		err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return false
+		}
+		if err := write(w); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	for {
+		if len(sub.events) == 0 {
+			for _, e := range s.hub.snapshots(sub) {
+				id++
+				if !send(func(w io.Writer) error { return writeEvent(w, id, e) }) {
+					return
+				}
+			}
+		}
+		select {
+		case <-r.Context().Done():
+			// При остановке сервера зритель получает уже накопленные
+			// события, например конец последней партии.
+			for len(sub.events) > 0 {
+				e := <-sub.events
+				id++
+				if !send(func(w io.Writer) error { return writeEvent(w, id, e) }) {
+					return
+				}
+			}
+			return
+		case e := <-sub.events:
+			id++
+			if !send(func(w io.Writer) error { return writeEvent(w, id, e) }) {
+				return
+			}
+		case <-heartbeat.C:
+			if !send(func(w io.Writer) error { _, err := io.WriteString(w, ": ping\n\n"); return err }) {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, id int, e event) error {
+	data, err := json.Marshal(e.data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, e.name, data)
+	return err
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+// startGame создает идущую партию; control — контроль времени, пустой —
+// без часов.
+func startGame(t *testing.T, control string) *model.Game {
+	t.Helper()
+	game, err := model.NewGameFromFEN("Анна", "Борис", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if control != "" {
+		tc, err := model.ParseTimeControl(control)
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.SetTimeControl(tc)
+	}
+	game.Start()
+	return game
+}
+
+// playMoves делает ходы в партии так, как это делает консоль.
+func playMoves(t *testing.T, game *model.Game, moves ...string) {
+	t.Helper()
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+	for _, text := range moves {
+		move, err := game.ParseMoveText(text)
+		if err == nil {
+			err = game.MakeMove(move)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+}
+
+// pending забирает события из очереди зрителя, не дожидаясь новых.
+func pending(sub *subscriber) []event {
+	var events []event
+	for len(sub.events) > 0 {
+		events = append(events, <-sub.events)
+	}
+	return events
+}
+
+func eventNames(events []event) string {
+	var names []string
+	for _, e := range events {
+		names = append(names, e.name+":"+e.game.ID)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestStreamOrder(t *testing.T) {
+	srv := New(repository.NewMemoryRepository())
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	game := createGame(t, srv, `{"white":"Анна","black":"Борис"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/games/"+game.ID+"/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type %q", ct)
+	}
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var name string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: ") && name != eventClock:
+				events <- name + " " + strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	next := func() (string, map[string]any) {
+		t.Helper()
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("трансляция закрыта")
+			}
+			name, data, _ := strings.Cut(e, " ")
+			var v map[string]any
+			if err := json.Unmarshal([]byte(data), &v); err != nil {
+				t.Fatalf("%s: %v", e, err)
+			}
+			return name, v
+		case <-time.After(5 * time.Second):
+			t.Fatal("нет события")
+		}
+		return "", nil
+	}
+
+	if name, data := next(); name != eventSnapshot || data["id"] != game.ID {
+		t.Fatalf("первое событие %s %v, ожидается снимок партии", name, data)
+	}
+	if code := request(t, srv, "POST", "/api/games/"+game.ID+"/moves", `{"move":"e4"}`, nil); code != http.StatusCreated {
+		t.Fatalf("ход = %d", code)
+	}
+	name, data := next()
+	if name != eventMove || data["fen"] != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" {
+		t.Fatalf("событие %s %v, ожидается ход e4", name, data)
+	}
+	if move, _ := data["move"].(map[string]any); move["san"] != "e4" {
+		t.Errorf("ход %v, ожидается e4", data["move"])
+	}
+	if code := request(t, srv, "POST", "/api/games/"+game.ID+"/resign", "", nil); code != http.StatusOK {
+		t.Fatalf("сдача = %d", code)
+	}
+	if name, data := next(); name != eventGameOver || data["result"] != string(model.ResultWhiteWins) || data["moves"] != 1.0 {
+		t.Errorf("событие %s %v, ожидается конец партии сдачей черных", name, data)
+	}
+}
+
+func TestHubSubscriptionFilter(t *testing.T) {
+	hub := NewHub()
+	first, second := startGame(t, ""), startGame(t, "")
+	hub.Update(first)
+	hub.Update(second)
+
+	one := hub.subscribe([]string{first.ID}, nil)
+	all := hub.subscribe(nil, nil)
+	if got, want := eventNames(hub.snapshots(one)), "snapshot:"+first.ID; got != want {
+		t.Errorf("снимки подписки на одну партию %q, ожидается %q", got, want)
+	}
+	if n := len(hub.snapshots(all)); n != 2 {
+		t.Errorf("снимков подписки на все партии %d, ожидается 2", n)
+	}
+
+	playMoves(t, second, "d4")
+	hub.Update(second)
+	playMoves(t, first, "e4")
+	hub.Update(first)
+
+	if got, want := eventNames(pending(one)), "move:"+first.ID; got != want {
+		t.Errorf("события подписки на одну партию %q, ожидается %q", got, want)
+	}
+	if got, want := eventNames(pending(all)), "move:"+second.ID+" move:"+first.ID; got != want {
+		t.Errorf("события подписки на все партии %q, ожидается %q", got, want)
+	}
+
+	// Отписанный зритель больше ничего не получает.
+	hub.unsubscribe(one)
+	playMoves(t, first, "e5")
+	hub.Update(first)
+	if n := len(one.events); n != 0 {
+		t.Errorf("после отписки получено %d событий", n)
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	game := startGame(t, "5+0")
+	hub.Update(game)
+	sub := hub.subscribe(nil, nil)
+	hub.snapshots(sub)
+
+	// Зритель не читает: очередь заполняется показаниями часов.
+	now := time.Now()
+	for i := range streamBuffer + 1 {
+		hub.tick(now.Add(time.Duration(i) * time.Millisecond))
+	}
+	if n := len(sub.events); n != streamBuffer {
+		t.Fatalf("в очереди %d событий, ожидается %d", n, streamBuffer)
+	}
+	if _, ok := sub.stale[game.ID]; !ok {
+		t.Fatal("партия не отмечена для снимка после переполнения очереди")
+	}
+	// Пока снимок не отправлен, события партии не ставятся в очередь.
+	playMoves(t, game, "e4")
+	hub.Update(game)
+	if n := len(sub.events); n != streamBuffer {
+		t.Errorf("в очереди %d событий после хода, ожидается %d", n, streamBuffer)
+	}
+
+	pending(sub)
+	snapshots := hub.snapshots(sub)
+	if got, want := eventNames(snapshots), "snapshot:"+game.ID; got != want {
+		t.Fatalf("снимки %q, ожидается %q", got, want)
+	}
+	if view := snapshots[0].data.(gameView); view.Moves != 1 {
+		t.Errorf("в снимке %d ходов, ожидается 1", view.Moves)
+	}
+	if len(hub.snapshots(sub)) != 0 {
+		t.Errorf("снимок отправлен повторно")
+	}
+
+	playMoves(t, game, "e5")
+	hub.Update(game)
+	if got, want := eventNames(pending(sub)), "move:"+game.ID; got != want {
+		t.Errorf("события после снимка %q, ожидается %q", got, want)
+	}
+}
+
+func TestHubFlagsExpiredGame(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	console := startGame(t, "1+0")
+	if err := repo.Store(console); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewShared(repo)
+	srv.hub.Update(console)
+	created := createGame(t, srv, `{"white":"Вера","black":"Глеб","time_control":"1+0"}`)
+	game, ok := repo.Game(created.ID)
+	if !ok {
+		t.Fatal("партия API не сохранена")
+	}
+	sub := srv.hub.subscribe([]string{game.ID}, nil)
+	srv.hub.snapshots(sub)
+
+	srv.hub.tick(time.Now().Add(2 * time.Minute))
+	if game.Result != model.ResultBlackWins || game.ResultReason != model.ReasonTimeout {
+		t.Errorf("итог %q (%s), ожидается победа черных по времени", game.Result, game.ResultReason)
+	}
+	if got := eventNames(pending(sub)); got != "clock:"+game.ID+" game_over:"+game.ID {
+		t.Errorf("события %q, ожидается показание часов и конец партии", got)
+	}
+	if !repo.IsRated(game.ID) {
+		t.Errorf("рейтинги по партии не пересчитаны")
+	}
+	// Флажок консольной партии проверяет консоль.
+	if !console.IsInProgress() {
+		t.Errorf("консольная партия закончена через API: %q", console.Result)
+	}
+}
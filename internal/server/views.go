@@ -0,0 +1,142 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+// Представления сущностей в ответах API.
+
+type playerView struct {
+	Name      string `json:"name"`
+	ProfileID string `json:"profile_id,omitempty"`
+}
+
+type clockView struct {
+	Control string `json:"control"`
+	WhiteMS int64  `json:"white_ms"`
+	BlackMS int64  `json:"black_ms"`
+}
+
+type gameView struct {
+	ID        string             `json:"id"`
+	White     playerView         `json:"white"`
+	Black     playerView         `json:"black"`
+	Status    model.GameStatus   `json:"status"`
+	Result    model.GameResult   `json:"result,omitempty"`
+	Reason    model.ResultReason `json:"reason,omitempty"`
+	Turn      model.PlayerColor  `json:"turn"`
+	FEN       string             `json:"fen"`
+	Moves     int                `json:"moves"`
+	Clock     *clockView         `json:"clock,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	// Board — ряды доски сверху вниз, пустая клетка — точка; только в
+	// ответе по одной партии.
+	Board []string `json:"board,omitempty"`
+}
+
+type gamePageView struct {
+	Games []gameView `json:"games"`
+	Total int        `json:"total"`
+}
+
+type moveView struct {
+	Ply         int               `json:"ply"`
+	Color       model.PlayerColor `json:"color,omitempty"`
+	SAN         string            `json:"san"`
+	UCI         string            `json:"uci"`
+	ThinkTimeMS int64             `json:"think_time_ms,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type moveResultView struct {
+	Move moveView `json:"move"`
+	Game gameView `json:"game"`
+}
+
+type profileView struct {
+	Rank   int    `json:"rank"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Rating int    `json:"rating"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Draws  int    `json:"draws"`
+}
+
+// viewGame описывает партию; withBoard добавляет ряды доски.
+func viewGame(g *model.Game, withBoard bool) gameView {
+	g.Mu.RLock()
+	defer g.Mu.RUnlock()
+	view := gameView{
+		ID:        g.ID,
+		White:     playerView{Name: g.WhitePlayer.Name, ProfileID: g.WhitePlayer.ProfileID},
+		Black:     playerView{Name: g.BlackPlayer.Name, ProfileID: g.BlackPlayer.ProfileID},
+		Status:    g.Status,
+		Result:    g.Result,
+		Reason:    g.ResultReason,
+		Turn:      g.CurrentPlayer.Color,
+		FEN:       g.FEN(),
+		Moves:     g.GetMoveCount(),
+		Clock:     viewClock(g, time.Now()),
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+	if withBoard {
+		for _, row := range g.Board.Cells {
+			var b strings.Builder
+			for _, cell := range row {
+				if cell == "" {
+					cell = "."
+				}
+				b.WriteString(cell)
+			}
+			view.Board = append(view.Board, b.String())
+		}
+	}
+	return view
+}
+
+// viewClock описывает часы партии на момент now; nil — партия без часов.
+// Вызывающий держит Mu партии.
+func viewClock(g *model.Game, now time.Time) *clockView {
+	if g.Clock == nil {
+		return nil
+	}
+	return &clockView{
+		Control: g.Clock.Control.String(),
+		WhiteMS: g.TimeLeft(model.White, now).Milliseconds(),
+		BlackMS: g.TimeLeft(model.Black, now).Milliseconds(),
+	}
+}
+
+// viewMove описывает ход; вызывающий держит Mu партии.
+func viewMove(m *model.Move) moveView {
+	view := moveView{
+		Ply:         m.Ply,
+		SAN:         m.GetNotation(),
+		UCI:         m.UCI,
+		ThinkTimeMS: m.ThinkTime.Milliseconds(),
+		CreatedAt:   m.CreatedAt,
+	}
+	if m.Player != nil {
+		view.Color = m.Player.Color
+	}
+	return view
+}
+
+func viewStanding(s repository.Standing) profileView {
+	return profileView{
+		Rank:   s.Rank,
+		ID:     s.ProfileID,
+		Name:   s.Name,
+		Rating: s.Rating,
+		Wins:   s.Wins,
+		Losses: s.Losses,
+		Draws:  s.Draws,
+	}
+}
@@ -0,0 +1,259 @@
+package tournament
+
+import (
+	"cmp"
+	"fmt"
+	"math/bits"
+	"slices"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// roundRobinPairs составляет пары тура round (с 0) по круговой схеме Бергера:
+// первый участник стоит на месте, остальные сдвигаются по кругу. При
+// нечетном числе участников соперник nil означает пропуск тура.
+func roundRobinPairs(participants []*model.Participant, round int) [][2]*model.Participant {
+	circle := slices.Clone(participants)
+	if len(circle)%2 == 1 {
+		circle = append(circle, nil)
+	}
+	n := len(circle)
+	rest := circle[1:]
+	shift := round % len(rest)
+	rotated := append(slices.Clone(rest[len(rest)-shift:]), rest[:len(rest)-shift]...)
+	circle = append(circle[:1], rotated...)
+
+	var pairs [][2]*model.Participant
+	for i := 0; i < n/2; i++ {
+		white, black := circle[i], circle[n-1-i]
+		// Белыми играет верхний ряд круга, а первый участник чередует цвета
+		// по турам. За круг каждый проходит все места, так что белых и
+		// черных у него поровну или на одну партию больше.
+		if i == 0 && round%2 == 1 {
+			white, black = black, white
+		}
+		if white == nil {
+			white, black = black, nil
+		}
+		pairs = append(pairs, [2]*model.Participant{white, black})
+	}
+	return pairs
+}
+
+// swissPlayer — участник швейцарского турнира перед составлением пар.
+type swissPlayer struct {
+	participant *model.Participant
+	points      float64
+	// colorDiff — сколько партий белыми больше, чем черными.
+	colorDiff int
+	lastColor model.PlayerColor
+	opponents map[string]bool
+	hadBye    bool
+}
+
+// swissPairs составляет пары тура швейцарской системы: участники с равными
+// очками играют между собой, повторных встреч нет, а цвета выравниваются —
+// белыми играет тот, у кого их было меньше. Пропуск тура получает
+// последний в таблице участник, который еще не пропускал.
+func swissPairs(t *model.Tournament) ([][2]*model.Participant, error) {
+	players := swissPlayers(t)
+
+	if len(players)%2 == 0 {
+		if pairs, ok := pairSwiss(players); ok {
+			return pairs, nil
+		}
+		return nil, fmt.Errorf("не удалось составить пары тура %d без повторных встреч", t.Round+1)
+	}
+	for i := len(players) - 1; i >= 0; i-- {
+		if players[i].hadBye {
+			continue
+		}
+		rest := slices.Delete(slices.Clone(players), i, i+1)
+		if pairs, ok := pairSwiss(rest); ok {
+			return append(pairs, [2]*model.Participant{players[i].participant, nil}), nil
+		}
+	}
+	return nil, fmt.Errorf("не удалось составить пары тура %d без повторных встреч", t.Round+1)
+}
+
+// swissPlayers собирает очки, цвета и соперников участников и упорядочивает
+// их по очкам, а при равенстве — по посеву.
+func swissPlayers(t *model.Tournament) []*swissPlayer {
+	byID := make(map[string]*swissPlayer, len(t.Participants))
+	var players []*swissPlayer
+	for _, p := range t.Participants {
+		sp := &swissPlayer{participant: p, opponents: make(map[string]bool)}
+		byID[p.ID] = sp
+		players = append(players, sp)
+	}
+	for _, p := range t.Pairings {
+		white := byID[p.WhiteID]
+		if p.IsBye() {
+			white.hadBye = true
+			white.points++
+			continue
+		}
+		black := byID[p.BlackID]
+		white.opponents[black.participant.ID] = true
+		black.opponents[white.participant.ID] = true
+		white.colorDiff++
+		black.colorDiff--
+		white.lastColor, black.lastColor = model.White, model.Black
+		whiteScore := score(p.Result, model.White)
+		white.points += whiteScore
+		black.points += 1 - whiteScore
+	}
+	slices.SortStableFunc(players, func(a, b *swissPlayer) int {
+		if c := cmp.Compare(b.points, a.points); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.participant.Seed, b.participant.Seed)
+	})
+	return players
+}
+
+// pairSwiss подбирает пару первому участнику среди тех, с кем он еще не
+// играл, начиная с соперников, которым нужен другой цвет, и рекурсивно
+// составляет пары остальных; при неудаче пробует следующего соперника.
+func pairSwiss(players []*swissPlayer) ([][2]*model.Participant, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+	first := players[0]
+	var candidates []int
+	for i := 1; i < len(players); i++ {
+		if !first.opponents[players[i].participant.ID] {
+			candidates = append(candidates, i)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		return cmp.Compare(colorClash(first, players[a]), colorClash(first, players[b]))
+	})
+	for _, i := range candidates {
+		rest := slices.Delete(slices.Clone(players[1:]), i-1, i)
+		pairs, ok := pairSwiss(rest)
+		if !ok {
+			continue
+		}
+		white, black := first, players[i]
+		if !prefersWhite(white, black) {
+			white, black = black, white
+		}
+		return append([][2]*model.Participant{{white.participant, black.participant}}, pairs...), true
+	}
+	return nil, false
+}
+
+// colorClash — 1, если обоим участникам нужен один и тот же цвет.
+func colorClash(a, b *swissPlayer) int {
+	if a.colorDiff > 0 && b.colorDiff > 0 || a.colorDiff < 0 && b.colorDiff < 0 {
+		return 1
+	}
+	return 0
+}
+
+// prefersWhite решает, что a играет белыми: у него было меньше белых, или
+// поровну, но прошлую партию он играл черными, или он выше в таблице.
+func prefersWhite(a, b *swissPlayer) bool {
+	if a.colorDiff != b.colorDiff {
+		return a.colorDiff < b.colorDiff
+	}
+	if a.lastColor != b.lastColor {
+		return a.lastColor == model.Black || b.lastColor == model.White
+	}
+	return true
+}
+
+// knockoutPairs составляет пары тура на выбывание. В первом туре сетка
+// строится по посеву так, что сильнейшие встречаются как можно позже, а
+// недостающих до степени двойки соперников заменяют пропуски; дальше
+// победители соседних досок играют между собой.
+func knockoutPairs(t *model.Tournament) [][2]*model.Participant {
+	var pairs [][2]*model.Participant
+	if t.Round == 0 {
+		size := 1 << bits.Len(uint(len(t.Participants)-1))
+		order := bracketOrder(size)
+		for i := 0; i < len(order); i += 2 {
+			pairs = append(pairs, seededPair(t, order[i], order[i+1]))
+		}
+		return pairs
+	}
+
+	var winners []*model.Participant
+	for board := 1; ; board++ {
+		games := boardPairings(t, t.Round, board)
+		if len(games) == 0 {
+			break
+		}
+		winners = append(winners, boardWinner(t, games))
+	}
+	for i := 0; i+1 < len(winners); i += 2 {
+		white, black := winners[i], winners[i+1]
+		if black.Seed < white.Seed {
+			white, black = black, white
+		}
+		pairs = append(pairs, [2]*model.Participant{white, black})
+	}
+	return pairs
+}
+
+// seededPair — пара участников с номерами посева a и b; номер больше числа
+// участников — пропуск.
+func seededPair(t *model.Tournament, a, b int) [2]*model.Participant {
+	seed := func(n int) *model.Participant {
+		if n > len(t.Participants) {
+			return nil
+		}
+		return t.Participants[n-1]
+	}
+	white, black := seed(min(a, b)), seed(max(a, b))
+	return [2]*model.Participant{white, black}
+}
+
+// bracketOrder возвращает номера посева по порядку досок сетки на size
+// участников: 1, 8, 4, 5, 2, 7, 3, 6 для восьми.
+func bracketOrder(size int) []int {
+	order := []int{1}
+	for n := 2; n <= size; n *= 2 {
+		next := make([]int, 0, n)
+		for _, seed := range order {
+			next = append(next, seed, n+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// boardWinner определяет, кто прошел дальше с доски тура на выбывание:
+// победитель последней результативной партии, а если все партии закончились
+// вничью — участник с лучшим посевом.
+func boardWinner(t *model.Tournament, games []*model.Pairing) *model.Participant {
+	first := games[0]
+	if first.IsBye() {
+		return Participant(t, first.WhiteID)
+	}
+	for i := len(games) - 1; i >= 0; i-- {
+		switch games[i].Result {
+		case model.ResultWhiteWins:
+			return Participant(t, games[i].WhiteID)
+		case model.ResultBlackWins:
+			return Participant(t, games[i].BlackID)
+		}
+	}
+	white, black := Participant(t, first.WhiteID), Participant(t, first.BlackID)
+	if black.Seed < white.Seed {
+		return black
+	}
+	return white
+}
+
+// score возвращает очки стороны color за партию с результатом result.
+func score(result model.GameResult, color model.PlayerColor) float64 {
+	switch result {
+	case model.ResultDraw:
+		return 0.5
+	case model.WinFor(color):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,386 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// decider выбирает результат партии пары.
+type decider func(white, black *model.Participant) model.GameResult
+
+func whiteWins(white, black *model.Participant) model.GameResult { return model.ResultWhiteWins }
+
+func allDraws(white, black *model.Participant) model.GameResult { return model.ResultDraw }
+
+// favoriteWins — побеждает участник с лучшим посевом.
+func favoriteWins(white, black *model.Participant) model.GameResult {
+	if white.Seed < black.Seed {
+		return model.ResultWhiteWins
+	}
+	return model.ResultBlackWins
+}
+
+// underdogWins — побеждает участник с худшим посевом.
+func underdogWins(white, black *model.Participant) model.GameResult {
+	if white.Seed > black.Seed {
+		return model.ResultWhiteWins
+	}
+	return model.ResultBlackWins
+}
+
+func newTournament(t *testing.T, format model.TournamentFormat, rounds, n int) *model.Tournament {
+	t.Helper()
+	var entrants []Entrant
+	for i := 1; i <= n; i++ {
+		entrants = append(entrants, Entrant{Name: fmt.Sprintf("P%d", i), Rating: 2000 - 10*i})
+	}
+	tour, err := New("Тест", format, rounds, entrants)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	return tour
+}
+
+// playRound составляет пары следующего тура и играет их, включая
+// переигровки, и возвращает все пары тура.
+func playRound(t *testing.T, tour *model.Tournament, decide decider) []*model.Pairing {
+	t.Helper()
+	pending, err := NextRound(tour)
+	if err != nil {
+		t.Fatalf("NextRound() в туре %d = %v", tour.Round+1, err)
+	}
+	for len(pending) > 0 {
+		p := pending[0]
+		pending = pending[1:]
+		if p.IsPlayed() {
+			continue
+		}
+		result := decide(Participant(tour, p.WhiteID), Participant(tour, p.BlackID))
+		pending = append(pending, RecordResult(tour, p, result)...)
+	}
+	if !RoundComplete(tour) {
+		t.Fatalf("тур %d не доигран: %d пар", tour.Round, len(Pending(tour)))
+	}
+	return roundPairings(tour, tour.Round)
+}
+
+func roundPairings(tour *model.Tournament, round int) []*model.Pairing {
+	var result []*model.Pairing
+	for _, p := range tour.Pairings {
+		if p.Round == round {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// meetingKey — ключ встречи двух участников, не зависящий от цвета.
+func meetingKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// checkRound проверяет, что каждый участник играет в туре ровно один раз и
+// что пропуск тура один при нечетном числе участников, а при четном его нет.
+func checkRound(t *testing.T, tour *model.Tournament, pairings []*model.Pairing) {
+	t.Helper()
+	seen := map[string]bool{}
+	byes := 0
+	for _, p := range pairings {
+		if p.IsBye() {
+			byes++
+			if p.Result != model.ResultWhiteWins {
+				t.Errorf("тур %d: пропуск тура с итогом %q", p.Round, p.Result)
+			}
+		}
+		for _, id := range []string{p.WhiteID, p.BlackID} {
+			if id == "" {
+				continue
+			}
+			// Переигровки на выбывание — те же участники на той же доске.
+			if seen[id] && tour.Format != model.Knockout {
+				t.Errorf("тур %d: %s играет дважды", p.Round, Participant(tour, id).Name)
+			}
+			seen[id] = true
+		}
+	}
+	if tour.Format == model.Knockout {
+		return
+	}
+	if len(seen) != len(tour.Participants) {
+		t.Errorf("тур %d: играют %d участников из %d", tour.Round, len(seen), len(tour.Participants))
+	}
+	if want := len(tour.Participants) % 2; byes != want {
+		t.Errorf("тур %d: пропусков %d, ожидается %d", tour.Round, byes, want)
+	}
+}
+
+func TestRoundRobinPairs(t *testing.T) {
+	for n := 2; n <= 9; n++ {
+		t.Run(fmt.Sprintf("%d участников", n), func(t *testing.T) {
+			tour := newTournament(t, model.RoundRobin, 0, n)
+			if tour.Rounds != roundRobinRounds(n) {
+				t.Fatalf("туров %d, ожидается %d", tour.Rounds, roundRobinRounds(n))
+			}
+			meetings := map[string]int{}
+			byes := map[string]int{}
+			whites := map[string]int{}
+			for round := 1; round <= tour.Rounds; round++ {
+				pairings := playRound(t, tour, whiteWins)
+				checkRound(t, tour, pairings)
+				for i, p := range pairings {
+					if p.IsBye() {
+						byes[p.WhiteID]++
+						if i != len(pairings)-1 {
+							t.Errorf("тур %d: пропуск на доске %d, а не на последней", round, p.Board)
+						}
+						continue
+					}
+					meetings[meetingKey(p.WhiteID, p.BlackID)]++
+					whites[p.WhiteID]++
+				}
+			}
+
+			if want := n * (n - 1) / 2; len(meetings) != want {
+				t.Errorf("встреч %d, ожидается %d", len(meetings), want)
+			}
+			for key, count := range meetings {
+				if count != 1 {
+					t.Errorf("пара %s встретилась %d раз", key, count)
+				}
+			}
+			for _, p := range tour.Participants {
+				if want := n % 2; byes[p.ID] != want {
+					t.Errorf("%s пропускал тур %d раз, ожидается %d", p.Name, byes[p.ID], want)
+				}
+				played := tour.Rounds - byes[p.ID]
+				if diff := 2*whites[p.ID] - played; diff < -1 || diff > 1 {
+					t.Errorf("%s: белыми %d партий из %d", p.Name, whites[p.ID], played)
+				}
+			}
+			if tour.Status != model.StatusFinished {
+				t.Errorf("статус %s после последнего тура", tour.Status)
+			}
+			if _, err := NextRound(tour); !errors.Is(err, ErrFinished) {
+				t.Errorf("NextRound() после последнего тура = %v", err)
+			}
+		})
+	}
+}
+
+func TestSwissPairs(t *testing.T) {
+	tests := []struct {
+		players int
+		rounds  int
+		decide  decider
+		name    string
+	}{
+		{4, 3, favoriteWins, "фавориты побеждают"},
+		{5, 3, favoriteWins, "фавориты побеждают"},
+		{6, 3, underdogWins, "аутсайдеры побеждают"},
+		{7, 4, whiteWins, "белые побеждают"},
+		{8, 0, allDraws, "ничьи"},
+		{9, 5, underdogWins, "аутсайдеры побеждают"},
+		{10, 5, favoriteWins, "фавориты побеждают"},
+		{11, 5, whiteWins, "белые побеждают"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d участников, %s", tt.players, tt.name), func(t *testing.T) {
+			tour := newTournament(t, model.Swiss, tt.rounds, tt.players)
+			meetings := map[string]bool{}
+			hadBye := map[string]bool{}
+			colorDiff := map[string]int{}
+			for round := 1; round <= tour.Rounds; round++ {
+				points := map[string]float64{}
+				for _, s := range Standings(tour) {
+					points[s.Participant.ID] = s.Points
+				}
+
+				pairings := playRound(t, tour, tt.decide)
+				checkRound(t, tour, pairings)
+				for _, p := range pairings {
+					if p.IsBye() {
+						if hadBye[p.WhiteID] {
+							t.Errorf("тур %d: %s пропускает второй раз", round, Participant(tour, p.WhiteID).Name)
+						}
+						hadBye[p.WhiteID] = true
+						// Пропуск достается участнику с наименьшими очками
+						// среди тех, кто еще не пропускал.
+						for _, other := range tour.Participants {
+							if other.ID != p.WhiteID && !hadBye[other.ID] && points[other.ID] < points[p.WhiteID] {
+								t.Errorf("тур %d: пропуск у %s с %.1f очка, а у %s без пропуска %.1f",
+									round, Participant(tour, p.WhiteID).Name, points[p.WhiteID], other.Name, points[other.ID])
+							}
+						}
+						continue
+					}
+					key := meetingKey(p.WhiteID, p.BlackID)
+					if meetings[key] {
+						t.Errorf("тур %d: повторная встреча %s и %s", round,
+							Participant(tour, p.WhiteID).Name, Participant(tour, p.BlackID).Name)
+					}
+					meetings[key] = true
+					colorDiff[p.WhiteID]++
+					colorDiff[p.BlackID]--
+				}
+			}
+			for _, p := range tour.Participants {
+				if d := colorDiff[p.ID]; d < -2 || d > 2 {
+					t.Errorf("%s: разница белых и черных %d", p.Name, d)
+				}
+			}
+			if tour.Status != model.StatusFinished {
+				t.Errorf("статус %s после последнего тура", tour.Status)
+			}
+		})
+	}
+}
+
+func TestSwissRoundsLimit(t *testing.T) {
+	var entrants []Entrant
+	for i := 1; i <= 4; i++ {
+		entrants = append(entrants, Entrant{Name: fmt.Sprintf("P%d", i)})
+	}
+	if _, err := New("Тест", model.Swiss, 4, entrants); err == nil {
+		t.Errorf("New() принял 4 тура для 4 участников")
+	}
+	if _, err := New("Тест", model.Swiss, 3, entrants); err != nil {
+		t.Errorf("New() с 3 турами = %v", err)
+	}
+}
+
+func TestNextRoundWaitsForResults(t *testing.T) {
+	tour := newTournament(t, model.Swiss, 0, 4)
+	pairings, err := NextRound(tour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NextRound(tour); !errors.Is(err, ErrRoundInProgress) {
+		t.Fatalf("NextRound() до результатов = %v, ожидается ErrRoundInProgress", err)
+	}
+	RecordResult(tour, pairings[0], model.ResultDraw)
+	if _, err := NextRound(tour); !errors.Is(err, ErrRoundInProgress) {
+		t.Errorf("NextRound() с недоигранной парой = %v", err)
+	}
+	if got := Pending(tour); len(got) != 1 || got[0] != pairings[1] {
+		t.Errorf("Pending() = %v, ожидается вторая пара", got)
+	}
+}
+
+func TestBracketOrder(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{1, []int{1}},
+		{2, []int{1, 2}},
+		{4, []int{1, 4, 2, 3}},
+		{8, []int{1, 8, 4, 5, 2, 7, 3, 6}},
+		{16, []int{1, 16, 8, 9, 4, 13, 5, 12, 2, 15, 7, 10, 3, 14, 6, 11}},
+	}
+	for _, tt := range tests {
+		if got := bracketOrder(tt.size); !slices.Equal(got, tt.want) {
+			t.Errorf("bracketOrder(%d) = %v, ожидается %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestKnockout(t *testing.T) {
+	tests := []struct {
+		players int
+		decide  decider
+		name    string
+		rounds  int
+		winner  int // посев победителя
+	}{
+		{2, favoriteWins, "фавориты побеждают", 1, 1},
+		{3, favoriteWins, "фавориты побеждают", 2, 1},
+		{5, favoriteWins, "фавориты побеждают", 3, 1},
+		{8, favoriteWins, "фавориты побеждают", 3, 1},
+		{8, underdogWins, "аутсайдеры побеждают", 3, 8},
+		// Сетка на 8: 1 и 2 свободны в первом туре и проходят без партий,
+		// дальше побеждают аутсайдеры: 5 обыгрывает 1, 6 обыгрывает 2.
+		{6, underdogWins, "аутсайдеры побеждают", 3, 6},
+		// Все партии вничью: после переигровок проходит лучший посев.
+		{4, allDraws, "ничьи", 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d участников, %s", tt.players, tt.name), func(t *testing.T) {
+			tour := newTournament(t, model.Knockout, 0, tt.players)
+			if tour.Rounds != tt.rounds {
+				t.Fatalf("туров %d, ожидается %d", tour.Rounds, tt.rounds)
+			}
+
+			size := 1 << tour.Rounds
+			alive := map[string]bool{}
+			for _, p := range tour.Participants {
+				alive[p.ID] = true
+			}
+			for round := 1; round <= tour.Rounds; round++ {
+				pairings := playRound(t, tour, tt.decide)
+				checkRound(t, tour, pairings)
+
+				boards := map[int][]*model.Pairing{}
+				for _, p := range pairings {
+					boards[p.Board] = append(boards[p.Board], p)
+				}
+				if want := size >> round; len(boards) != want {
+					t.Errorf("тур %d: досок %d, ожидается %d", round, len(boards), want)
+				}
+				for board, games := range boards {
+					first := games[0]
+					if !alive[first.WhiteID] || first.BlackID != "" && !alive[first.BlackID] {
+						t.Errorf("тур %d, доска %d: играет выбывший участник", round, board)
+					}
+					if first.IsBye() {
+						// Пропускают первый тур сильнейшие по посеву.
+						if seed := Participant(tour, first.WhiteID).Seed; round != 1 || seed > size-tt.players {
+							t.Errorf("тур %d: пропуск у посева %d", round, seed)
+						}
+						continue
+					}
+					if tt.decide(Participant(tour, first.WhiteID), Participant(tour, first.BlackID)) == model.ResultDraw &&
+						len(games) != knockoutMaxGames {
+						t.Errorf("тур %d, доска %d: партий %d после ничьих, ожидается %d", round, board, len(games), knockoutMaxGames)
+					}
+					for i := 1; i < len(games); i++ {
+						if games[i].WhiteID != games[i-1].BlackID || games[i].BlackID != games[i-1].WhiteID {
+							t.Errorf("тур %d, доска %d: в переигровке не сменились цвета", round, board)
+						}
+					}
+					loser := first.BlackID
+					if boardWinner(tour, games).ID == first.BlackID {
+						loser = first.WhiteID
+					}
+					alive[loser] = false
+				}
+			}
+
+			if tour.Status != model.StatusFinished {
+				t.Fatalf("статус %s после финала", tour.Status)
+			}
+			winner := Winner(tour)
+			if winner == nil || winner.Seed != tt.winner {
+				t.Fatalf("победитель %v, ожидается посев %d", winner, tt.winner)
+			}
+			if !alive[winner.ID] {
+				t.Errorf("победил выбывший участник %s", winner.Name)
+			}
+			standings := Standings(tour)
+			for _, s := range standings[1:] {
+				if !s.Eliminated {
+					t.Errorf("%s не отмечен выбывшим", s.Participant.Name)
+				}
+			}
+			if standings[0].Eliminated || standings[0].Reached != tour.Rounds {
+				t.Errorf("победитель: %+v", standings[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package tournament
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Standing — строка турнирной таблицы.
+type Standing struct {
+	Rank        int
+	Participant *model.Participant
+	Points      float64
+	Wins        int
+	Losses      int
+	Draws       int
+	Byes        int
+	// Buchholz — сумма очков соперников.
+	Buchholz float64
+	// SonnebornBerger — сумма очков побежденных соперников и половины очков
+	// тех, с кем сыграна ничья.
+	SonnebornBerger float64
+	// Eliminated — участник выбыл из турнира на выбывание.
+	Eliminated bool
+	// Reached — последний тур, в котором участник играл.
+	Reached int
+}
+
+// Standings возвращает турнирную таблицу по сыгранным партиям. Места
+// распределяются по очкам, затем по Бухгольцу, затем по Зоннеборну-Бергеру,
+// затем по посеву. В турнире на выбывание выше те, кто прошел дальше.
+func Standings(t *model.Tournament) []Standing {
+	byID := make(map[string]*Standing, len(t.Participants))
+	rows := make([]*Standing, 0, len(t.Participants))
+	for _, p := range t.Participants {
+		s := &Standing{Participant: p}
+		byID[p.ID] = s
+		rows = append(rows, s)
+	}
+
+	type game struct {
+		opponent string
+		score    float64
+	}
+	games := make(map[string][]game)
+	for _, p := range t.Pairings {
+		white := byID[p.WhiteID]
+		white.Reached = max(white.Reached, p.Round)
+		if p.IsBye() {
+			white.Points++
+			white.Byes++
+			continue
+		}
+		black := byID[p.BlackID]
+		black.Reached = max(black.Reached, p.Round)
+		if !p.IsPlayed() {
+			continue
+		}
+		whiteScore := score(p.Result, model.White)
+		addScore(white, whiteScore)
+		addScore(black, 1-whiteScore)
+		games[p.WhiteID] = append(games[p.WhiteID], game{p.BlackID, whiteScore})
+		games[p.BlackID] = append(games[p.BlackID], game{p.WhiteID, 1 - whiteScore})
+	}
+	for id, played := range games {
+		s := byID[id]
+		for _, g := range played {
+			opponentPoints := byID[g.opponent].Points
+			s.Buchholz += opponentPoints
+			s.SonnebornBerger += g.score * opponentPoints
+		}
+	}
+	if t.Format == model.Knockout {
+		markEliminated(t, byID)
+	}
+
+	slices.SortStableFunc(rows, func(a, b *Standing) int {
+		if t.Format == model.Knockout {
+			if c := cmp.Compare(b.Reached, a.Reached); c != 0 {
+				return c
+			}
+			if a.Eliminated != b.Eliminated {
+				if a.Eliminated {
+					return 1
+				}
+				return -1
+			}
+		}
+		if c := cmp.Compare(b.Points, a.Points); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Buchholz, a.Buchholz); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.SonnebornBerger, a.SonnebornBerger); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Participant.Seed, b.Participant.Seed)
+	})
+	standings := make([]Standing, len(rows))
+	for i, s := range rows {
+		s.Rank = i + 1
+		standings[i] = *s
+	}
+	return standings
+}
+
+func addScore(s *Standing, score float64) {
+	s.Points += score
+	switch score {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+// markEliminated отмечает проигравших на досках, где игра закончена.
+func markEliminated(t *model.Tournament, byID map[string]*Standing) {
+	for round := 1; round <= t.Round; round++ {
+		for board := 1; ; board++ {
+			games := boardPairings(t, round, board)
+			if len(games) == 0 {
+				break
+			}
+			if !boardDecided(games) {
+				continue
+			}
+			winner := boardWinner(t, games)
+			for _, id := range []string{games[0].WhiteID, games[0].BlackID} {
+				if id != "" && id != winner.ID {
+					byID[id].Eliminated = true
+				}
+			}
+		}
+	}
+}
+
+// boardDecided сообщает, что на доске больше не будет партий: все сыграны
+// и последняя не требует переигровки.
+func boardDecided(games []*model.Pairing) bool {
+	for _, g := range games {
+		if !g.IsPlayed() {
+			return false
+		}
+	}
+	last := games[len(games)-1]
+	return last.Result != model.ResultDraw || len(games) >= knockoutMaxGames
+}
+
+// Winner возвращает победителя законченного турнира.
+func Winner(t *model.Tournament) *model.Participant {
+	if t.Status != model.StatusFinished {
+		return nil
+	}
+	return Standings(t)[0].Participant
+}
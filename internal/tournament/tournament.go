@@ -0,0 +1,238 @@
+// Package tournament проводит турниры: составляет пары туров по круговой,
+// швейцарской и олимпийской системам и считает турнирную таблицу.
+// Партии пар играются снаружи, а их результаты передаются в RecordResult.
+//
+// Функции пакета не берут блокировок: вызывающий держит Tournament.Mu —
+// на запись, если функция меняет турнир.
+package tournament
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+var (
+	ErrRoundInProgress = errors.New("текущий тур еще не доигран")
+	ErrFinished        = errors.New("турнир закончен")
+)
+
+// knockoutMaxGames — сколько партий играет пара на выбывание: после ничьей
+// партия переигрывается со сменой цвета, а если ничьей закончились все,
+// дальше проходит участник с лучшим посевом.
+const knockoutMaxGames = 3
+
+// Entrant — заявка на участие в турнире.
+type Entrant struct {
+	ProfileID string
+	Name      string
+	Engine    string // см. model.Participant.Engine
+	Rating    int    // для посева
+}
+
+// New создает турнир. Участники сеются по убыванию рейтинга. rounds — число
+// туров швейцарской системы, 0 — по числу участников; в круговом турнире и
+// турнире на выбывание число туров следует из числа участников.
+func New(name string, format model.TournamentFormat, rounds int, entrants []Entrant) (*model.Tournament, error) {
+	if len(entrants) < 2 {
+		return nil, fmt.Errorf("в турнире должно быть не меньше двух участников")
+	}
+	seen := make(map[string]bool, len(entrants))
+	for _, e := range entrants {
+		key := strings.ToLower(e.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("участник %s заявлен дважды", e.Name)
+		}
+		seen[key] = true
+	}
+
+	n := len(entrants)
+	switch format {
+	case model.RoundRobin:
+		rounds = roundRobinRounds(n)
+	case model.Knockout:
+		rounds = bits.Len(uint(n - 1))
+	case model.Swiss:
+		if rounds <= 0 {
+			rounds = max(bits.Len(uint(n-1)), 1)
+		}
+		// Больше туров не составить без повторных встреч.
+		if rounds > roundRobinRounds(n) {
+			return nil, fmt.Errorf("для %d участников возможно не больше %d туров", n, roundRobinRounds(n))
+		}
+	default:
+		return nil, fmt.Errorf("неизвестная система турнира %q", format)
+	}
+
+	t := model.NewTournament(name, format)
+	t.Rounds = rounds
+	seeded := slices.Clone(entrants)
+	slices.SortStableFunc(seeded, func(a, b Entrant) int { return cmp.Compare(b.Rating, a.Rating) })
+	for i, e := range seeded {
+		t.Participants = append(t.Participants, &model.Participant{
+			Entity:       model.NewEntity(),
+			TournamentID: t.ID,
+			ProfileID:    e.ProfileID,
+			Name:         e.Name,
+			Engine:       e.Engine,
+			Seed:         i + 1,
+		})
+	}
+	return t, nil
+}
+
+// roundRobinRounds — число туров кругового турнира: при нечетном числе
+// участников каждый по разу пропускает тур.
+func roundRobinRounds(n int) int {
+	if n%2 == 1 {
+		return n
+	}
+	return n - 1
+}
+
+// NextRound составляет пары следующего тура и добавляет их в турнир.
+// Пропуск тура сразу получает результат — очко свободному участнику.
+func NextRound(t *model.Tournament) ([]*model.Pairing, error) {
+	if t.Status == model.StatusFinished {
+		return nil, ErrFinished
+	}
+	if t.Round > 0 && !RoundComplete(t) {
+		return nil, ErrRoundInProgress
+	}
+	if t.Round >= t.Rounds {
+		finish(t)
+		return nil, ErrFinished
+	}
+
+	var pairs [][2]*model.Participant
+	var err error
+	switch t.Format {
+	case model.RoundRobin:
+		pairs = roundRobinPairs(t.Participants, t.Round)
+	case model.Swiss:
+		pairs, err = swissPairs(t)
+	case model.Knockout:
+		pairs = knockoutPairs(t)
+	default:
+		err = fmt.Errorf("неизвестная система турнира %q", t.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Round++
+	t.Status = model.StatusInProgress
+	t.Touch()
+	if t.Format != model.Knockout {
+		// Пропуск — на последней доске; в сетке на выбывание порядок досок
+		// задает, кто с кем встретится дальше, и не меняется.
+		slices.SortStableFunc(pairs, func(a, b [2]*model.Participant) int {
+			return cmp.Compare(isBye(a), isBye(b))
+		})
+	}
+	var created []*model.Pairing
+	for i, pair := range pairs {
+		p := newPairing(t, i+1, pair[0], pair[1])
+		if pair[1] == nil {
+			p.Result = model.ResultWhiteWins
+		}
+		created = append(created, p)
+	}
+	t.Pairings = append(t.Pairings, created...)
+	return created, nil
+}
+
+func isBye(pair [2]*model.Participant) int {
+	if pair[1] == nil {
+		return 1
+	}
+	return 0
+}
+
+func newPairing(t *model.Tournament, board int, white, black *model.Participant) *model.Pairing {
+	p := &model.Pairing{
+		Entity:       model.NewEntity(),
+		TournamentID: t.ID,
+		Round:        t.Round,
+		Board:        board,
+		WhiteID:      white.ID,
+	}
+	if black != nil {
+		p.BlackID = black.ID
+	}
+	return p
+}
+
+// RecordResult записывает результат партии пары. В турнире на выбывание
+// ничья переигрывается: функция добавляет и возвращает пару переигровки.
+func RecordResult(t *model.Tournament, p *model.Pairing, result model.GameResult) []*model.Pairing {
+	p.Result = result
+	p.Touch()
+	t.Touch()
+
+	var created []*model.Pairing
+	if t.Format == model.Knockout && result == model.ResultDraw && len(boardPairings(t, p.Round, p.Board)) < knockoutMaxGames {
+		replay := newPairing(t, p.Board, Participant(t, p.BlackID), Participant(t, p.WhiteID))
+		replay.Round = p.Round
+		t.Pairings = append(t.Pairings, replay)
+		created = append(created, replay)
+	}
+	if RoundComplete(t) && t.Round >= t.Rounds {
+		finish(t)
+	}
+	return created
+}
+
+func finish(t *model.Tournament) {
+	if t.Status != model.StatusFinished {
+		t.Status = model.StatusFinished
+		t.Touch()
+	}
+}
+
+// RoundComplete сообщает, что у всех пар текущего тура есть результат.
+func RoundComplete(t *model.Tournament) bool {
+	for _, p := range t.Pairings {
+		if p.Round == t.Round && !p.IsPlayed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Pending возвращает пары текущего тура, которые еще не сыграны.
+func Pending(t *model.Tournament) []*model.Pairing {
+	var pending []*model.Pairing
+	for _, p := range t.Pairings {
+		if p.Round == t.Round && !p.IsPlayed() {
+			pending = append(pending, p)
+		}
+	}
+	return pending
+}
+
+// Participant ищет участника турнира по ID.
+func Participant(t *model.Tournament, id string) *model.Participant {
+	for _, p := range t.Participants {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// boardPairings возвращает все партии доски тура, включая переигровки.
+func boardPairings(t *model.Tournament, round, board int) []*model.Pairing {
+	var result []*model.Pairing
+	for _, p := range t.Pairings {
+		if p.Round == round && p.Board == board {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+const (
+	// handshakeTimeout ограничивает ответы на uci и isready.
+	handshakeTimeout = 10 * time.Second
+	// bestMoveGrace — сколько ждать bestmove сверх времени на ход.
+	bestMoveGrace = 5 * time.Second
+	// quitTimeout — сколько ждать завершения процесса после quit.
+	quitTimeout = 2 * time.Second
+)
+
+var (
+	ErrEngineTimeout = errors.New("движок не ответил вовремя")
+	ErrEngineExited  = errors.New("процесс движка завершился")
+)
+
+// Client управляет внешним движком, который понимает протокол UCI.
+// Процесс завершается при Close или отмене контекста, переданного в Start.
+type Client struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines <-chan string
+	// stop отпускает читающую горутину, когда строки движка больше никто не
+	// ждет; readerDone закрывается, когда она вышла.
+	stop       chan struct{}
+	stopOnce   sync.Once
+	readerDone chan struct{}
+	mu         sync.Mutex
+	name       string
+}
+
+// Start запускает движок командой path с аргументами args и выполняет
+// начальное рукопожатие uci/isready.
+func Start(ctx context.Context, path string, args ...string) (*Client, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	lines := make(chan string, 64)
+	c := &Client{
+		cmd:        cmd,
+		stdin:      stdin,
+		lines:      lines,
+		stop:       make(chan struct{}),
+		readerDone: make(chan struct{}),
+		name:       path,
+	}
+	go func() {
+		defer close(c.readerDone)
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+
+	if err := c.handshake(ctx); err != nil {
+		c.kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) handshake(ctx context.Context) error {
+	if err := c.send("uci"); err != nil {
+		return err
+	}
+	err := c.waitFor(ctx, handshakeTimeout, func(line string) bool {
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			c.name = name
+		}
+		return line == "uciok"
+	})
+	if err != nil {
+		return fmt.Errorf("uci: %w", err)
+	}
+	return c.ready(ctx)
+}
+
+func (c *Client) ready(ctx context.Context) error {
+	if err := c.send("isready"); err != nil {
+		return err
+	}
+	if err := c.waitFor(ctx, handshakeTimeout, func(line string) bool { return line == "readyok" }); err != nil {
+		return fmt.Errorf("isready: %w", err)
+	}
+	return nil
+}
+
+// Name возвращает имя, которое сообщил движок, или путь к нему.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// BestMove передает движку позицию игры и возвращает его ход в записи UCI
+// (e2e4, e7e8q). Для позиции без ходов движки отвечают 0000 или (none).
+func (c *Client) BestMove(ctx context.Context, game *model.Game, moveTime time.Duration) (string, error) {
+	if game.Board.Size != model.StandardBoardSize {
+		return "", fmt.Errorf("UCI-движки играют только на доске %dx%d", model.StandardBoardSize, model.StandardBoardSize)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ready(ctx); err != nil {
+		return "", err
+	}
+	if err := c.send(positionCommand(game)); err != nil {
+		return "", err
+	}
+	if err := c.send(fmt.Sprintf("go movetime %d", moveTime.Milliseconds())); err != nil {
+		return "", err
+	}
+
+	var best string
+	err := c.waitFor(ctx, moveTime+bestMoveGrace, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "bestmove" {
+			return false
+		}
+		best = fields[1]
+		return true
+	})
+	if err != nil {
+		// Просим движок остановиться, чтобы его поздний ответ не попал в следующий ход.
+		c.send("stop")
+		return "", fmt.Errorf("bestmove: %w", err)
+	}
+	return best, nil
+}
+
+// positionCommand описывает игру начальной позицией и сделанными ходами.
+func positionCommand(game *model.Game) string {
+	if game.StartFEN == "" {
+		return "position fen " + game.FEN()
+	}
+	var sb strings.Builder
+	sb.WriteString("position fen ")
+	sb.WriteString(game.StartFEN)
+	if len(game.Moves) > 0 {
+		sb.WriteString(" moves")
+		for _, move := range game.Moves {
+			sb.WriteByte(' ')
+			sb.WriteString(move.CoordinateNotation(game.Board.Size))
+		}
+	}
+	return sb.String()
+}
+
+// Close просит движок завершиться и убивает процесс, если тот не успел.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.send("quit")
+	c.stdin.Close()
+	c.stopReader()
+
+	// Wait закрывает stdout, поэтому вызывается только после того, как
+	// чтение закончилось: движок закрыл вывод или убит.
+	timer := time.NewTimer(quitTimeout)
+	defer timer.Stop()
+	killed := false
+	select {
+	case <-c.readerDone:
+	case <-timer.C:
+		c.cmd.Process.Kill()
+		killed = true
+		<-c.readerDone
+	}
+	exited := make(chan struct{})
+	go func() {
+		c.cmd.Wait()
+		close(exited)
+	}()
+	if !killed {
+		select {
+		case <-exited:
+		case <-timer.C:
+			c.cmd.Process.Kill()
+			killed = true
+		}
+	}
+	<-exited
+	if killed {
+		return fmt.Errorf("quit: %w", ErrEngineTimeout)
+	}
+	return nil
+}
+
+func (c *Client) kill() {
+	c.stopReader()
+	c.cmd.Process.Kill()
+	<-c.readerDone
+	c.cmd.Wait()
+}
+
+// stopReader отпускает читающую горутину, даже если она ждет отправки
+// строки, которую уже никто не прочитает.
+func (c *Client) stopReader() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+func (c *Client) send(command string) error {
+	_, err := io.WriteString(c.stdin, command+"\n")
+	return err
+}
+
+// waitFor читает строки движка, пока done не вернет true.
+func (c *Client) waitFor(ctx context.Context, timeout time.Duration, done func(line string) bool) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return ErrEngineTimeout
+		case line, ok := <-c.lines:
+			if !ok {
+				return ErrEngineExited
+			}
+			if done(strings.TrimSpace(line)) {
+				return nil
+			}
+		}
+	}
+}
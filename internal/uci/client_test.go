@@ -0,0 +1,261 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+// Заглушка движка — сам тестовый бинарник, запущенный с GO_UCI_STUB.
+// Значение выбирает поведение, а в UCI_STUB_LOG заглушка записывает
+// полученные команды.
+const (
+	stubEngine   = "engine"   // отвечает по протоколу, на go — bestmove e7e5
+	stubSilent   = "silent"   // рукопожатие есть, на go не отвечает
+	stubMute     = "mute"     // не отвечает ни на что
+	stubCrash    = "crash"    // завершается сразу после uci
+	stubStubborn = "stubborn" // не завершается по quit
+	stubChatty   = "chatty"   // после bestmove печатает больше строк, чем читает клиент
+)
+
+func TestUCIStub(t *testing.T) {
+	mode := os.Getenv("GO_UCI_STUB")
+	if mode == "" {
+		return
+	}
+	runStub(mode, os.Getenv("UCI_STUB_LOG"))
+	os.Exit(0)
+}
+
+func runStub(mode, logPath string) {
+	log, err := os.Create(logPath)
+	if err != nil {
+		os.Exit(3)
+	}
+	defer log.Close()
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		command := scanner.Text()
+		fmt.Fprintln(log, command)
+		if mode == stubMute {
+			continue
+		}
+		switch fields := strings.Fields(command); fields[0] {
+		case "uci":
+			if mode == stubCrash {
+				os.Exit(1)
+			}
+			fmt.Println("id name Stub Engine")
+			fmt.Println("id author go_hw tests")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "go":
+			if mode == stubEngine || mode == stubChatty {
+				fmt.Println("info depth 1 score cp 20 pv e7e5")
+				fmt.Println("bestmove e7e5 ponder g1f3")
+			}
+			if mode == stubChatty {
+				for i := range 500 {
+					fmt.Printf("info string %d\n", i)
+				}
+			}
+		case "quit":
+			if mode != stubStubborn {
+				return
+			}
+		}
+	}
+	if mode == stubStubborn {
+		time.Sleep(time.Minute)
+	}
+}
+
+// startStub запускает заглушку и возвращает клиента и путь к журналу команд.
+func startStub(t *testing.T, ctx context.Context, mode string) (*Client, string, error) {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "commands.log")
+	t.Setenv("GO_UCI_STUB", mode)
+	t.Setenv("UCI_STUB_LOG", logPath)
+	client, err := Start(ctx, os.Args[0], "-test.run=^TestUCIStub$")
+	return client, logPath, err
+}
+
+func readCommands(t *testing.T, logPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestClientBestMove(t *testing.T) {
+	ctx := context.Background()
+	client, logPath, err := startStub(t, ctx, stubEngine)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	if name := client.Name(); name != "Stub Engine" {
+		t.Errorf("Name() = %q, ожидается имя из id name", name)
+	}
+
+	game, err := model.NewGameFromFEN("Белые", "Черные", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	move, err := game.ParseMoveText("e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := game.MakeMove(move); err != nil {
+		t.Fatal(err)
+	}
+
+	best, err := client.BestMove(ctx, game, 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("BestMove() = %v", err)
+	}
+	if best != "e7e5" {
+		t.Errorf("BestMove() = %q, ожидается e7e5", best)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	want := []string{
+		"uci",
+		"isready",
+		"isready",
+		"position fen " + model.StartFEN + " moves e2e4",
+		"go movetime 150",
+		"quit",
+	}
+	got := readCommands(t, logPath)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("команды движку:\n%s\nожидаются:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestClientStartFailures(t *testing.T) {
+	tests := []struct {
+		mode string
+		want error
+	}{
+		{stubMute, context.DeadlineExceeded},
+		{stubCrash, ErrEngineExited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			client, _, err := startStub(t, ctx, tt.mode)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Start() = %v, ожидается %v", err, tt.want)
+			}
+			if client != nil {
+				t.Errorf("Start() вернул клиента вместе с ошибкой")
+			}
+		})
+	}
+}
+
+func TestClientEngineNeverAnswers(t *testing.T) {
+	client, logPath, err := startStub(t, context.Background(), stubSilent)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	game, err := model.NewGameFromFEN("Белые", "Черные", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := client.BestMove(ctx, game, 100*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("BestMove() = %v, ожидается истечение контекста", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("BestMove ждал %v после отмены контекста", elapsed)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	commands := readCommands(t, logPath)
+	if !containsCommand(commands, "stop") {
+		t.Errorf("после таймаута движку не отправлен stop: %v", commands)
+	}
+}
+
+func TestClientCloseKillsStubbornEngine(t *testing.T) {
+	client, _, err := startStub(t, context.Background(), stubStubborn)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	started := time.Now()
+	if err := client.Close(); !errors.Is(err, ErrEngineTimeout) {
+		t.Fatalf("Close() = %v, ожидается %v", err, ErrEngineTimeout)
+	}
+	if elapsed := time.Since(started); elapsed > quitTimeout+time.Second {
+		t.Errorf("Close ждал %v, дольше quitTimeout", elapsed)
+	}
+	if client.cmd.ProcessState == nil {
+		t.Errorf("процесс движка не завершен после Close")
+	}
+}
+
+func TestClientCloseReleasesReader(t *testing.T) {
+	before := runtime.NumGoroutine()
+	client, _, err := startStub(t, context.Background(), stubChatty)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	game, err := model.NewGameFromFEN("Белые", "Черные", model.StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	if _, err := client.BestMove(context.Background(), game, 50*time.Millisecond); err != nil {
+		t.Fatalf("BestMove() = %v", err)
+	}
+
+	// Непрочитанные строки переполняют очередь клиента: читающая горутина
+	// должна выйти после Close, а не ждать отправки.
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	select {
+	case <-client.readerDone:
+	case <-time.After(time.Second):
+		t.Fatal("читающая горутина не вышла после Close")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("горутин после Close: %d, до Start: %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func containsCommand(commands []string, command string) bool {
+	for _, c := range commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
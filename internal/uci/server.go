@@ -0,0 +1,345 @@
+// Package uci реализует протокол Universal Chess Interface, чтобы движок
+// можно было подключать к шахматным оболочкам.
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/engine"
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+const (
+	engineName   = "go_hw"
+	engineAuthor = "imyakin"
+
+	// defaultMovesToGo — на сколько ходов делится оставшееся время, если
+	// оболочка не прислала movestogo.
+	defaultMovesToGo = 30
+	// moveOverhead — запас на передачу хода оболочке.
+	moveOverhead = 50 * time.Millisecond
+)
+
+// Server отвечает на команды UCI, читая их из in и печатая ответы в out.
+type Server struct {
+	in  io.Reader
+	out io.Writer
+
+	outMu sync.Mutex
+	game  *model.Game
+	level engine.Level
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: in, out: out, level: engine.LevelFull}
+}
+
+// Run обрабатывает команды до quit, конца ввода или отмены ctx.
+func (s *Server) Run(ctx context.Context) error {
+	lines := make(chan string)
+	errCh := make(chan error, 1)
+	// stop отпускает читающую горутину после возврата из Run: иначе после
+	// quit она навсегда застряла бы на отправке строки, которую никто не
+	// прочитает.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(s.in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-stop:
+				return
+			}
+		}
+		errCh <- scanner.Err()
+	}()
+
+	if err := s.newGame(); err != nil {
+		return err
+	}
+	defer s.stopSearch()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				// Конец ввода: даем начатому поиску напечатать bestmove.
+				s.waitSearch()
+				return <-errCh
+			}
+			if quit := s.handle(ctx, line); quit {
+				return nil
+			}
+		}
+	}
+}
+
+// handle выполняет одну команду и сообщает, нужно ли завершить работу.
+func (s *Server) handle(ctx context.Context, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case "uci":
+		s.send("id name " + engineName)
+		s.send("id author " + engineAuthor)
+		s.send(fmt.Sprintf("option name Level type spin default %d min %d max %d",
+			engine.LevelFull, engine.LevelRandom, engine.LevelFull))
+		s.send("uciok")
+	case "isready":
+		s.send("readyok")
+	case "setoption":
+		s.setOption(fields[1:])
+	case "ucinewgame":
+		s.stopSearch()
+		if err := s.newGame(); err != nil {
+			s.info(err)
+		}
+	case "position":
+		s.stopSearch()
+		if err := s.setPosition(fields[1:]); err != nil {
+			s.info(err)
+		}
+	case "go":
+		s.stopSearch()
+		s.startSearch(ctx, parseGoParams(fields[1:]))
+	case "stop":
+		s.stopSearch()
+	case "quit":
+		return true
+	default:
+		s.info(fmt.Errorf("неизвестная команда %s", fields[0]))
+	}
+	return false
+}
+
+func (s *Server) newGame() error {
+	game, err := model.NewGameFromFEN("Белые", "Черные", model.StartFEN)
+	if err != nil {
+		return err
+	}
+	game.Start()
+	s.game = game
+	return nil
+}
+
+// setPosition разбирает "startpos [moves ...]" или "fen <FEN> [moves ...]".
+func (s *Server) setPosition(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана позиция")
+	}
+
+	fen := model.StartFEN
+	rest := args[1:]
+	switch args[0] {
+	case "startpos":
+	case "fen":
+		end := len(args)
+		for i, arg := range args {
+			if arg == "moves" {
+				end = i
+				break
+			}
+		}
+		fen = strings.Join(args[1:end], " ")
+		rest = args[end:]
+	default:
+		return fmt.Errorf("неизвестная позиция %s", args[0])
+	}
+
+	game, err := model.NewGameFromFEN("Белые", "Черные", fen)
+	if err != nil {
+		return err
+	}
+	game.Start()
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, text := range rest[1:] {
+			move, err := game.ParseMoveText(text)
+			if err != nil {
+				return fmt.Errorf("ход %s: %w", text, err)
+			}
+			if err := game.ValidateMove(move); err != nil {
+				return fmt.Errorf("ход %s: %w", text, err)
+			}
+			// Конец партии определяет оболочка, поэтому ходы применяются без
+			// автоматического завершения игры, как при переборе.
+			game.Push(move)
+		}
+	}
+	s.game = game
+	return nil
+}
+
+func (s *Server) setOption(args []string) {
+	// setoption name <имя> value <значение>
+	if len(args) < 4 || args[0] != "name" || args[2] != "value" {
+		s.info(fmt.Errorf("ожидается setoption name <имя> value <значение>"))
+		return
+	}
+	if !strings.EqualFold(args[1], "Level") {
+		s.info(fmt.Errorf("неизвестная настройка %s", args[1]))
+		return
+	}
+	level, err := engine.ParseLevel(args[3])
+	if err != nil {
+		s.info(err)
+		return
+	}
+	s.level = level
+}
+
+type goParams struct {
+	wtime, btime, winc, binc time.Duration
+	movesToGo                int
+	moveTime                 time.Duration
+	depth                    int
+	infinite                 bool
+}
+
+func parseGoParams(args []string) goParams {
+	var p goParams
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			p.infinite = true
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+		ms := time.Duration(n) * time.Millisecond
+		switch args[i] {
+		case "wtime":
+			p.wtime = ms
+		case "btime":
+			p.btime = ms
+		case "winc":
+			p.winc = ms
+		case "binc":
+			p.binc = ms
+		case "movestogo":
+			p.movesToGo = n
+		case "movetime":
+			p.moveTime = ms
+		case "depth":
+			p.depth = n
+		default:
+			continue
+		}
+		i++
+	}
+	return p
+}
+
+// timeLimit вычисляет время на ход: movetime, либо доля оставшегося времени
+// игрока с половиной добавки. Ноль означает поиск без ограничения по времени.
+func (p goParams) timeLimit(color model.PlayerColor) time.Duration {
+	if p.infinite {
+		return 0
+	}
+	if p.moveTime > 0 {
+		return max(p.moveTime-moveOverhead, time.Millisecond)
+	}
+
+	remaining, increment := p.wtime, p.winc
+	if color == model.Black {
+		remaining, increment = p.btime, p.binc
+	}
+	if remaining <= 0 {
+		return 0
+	}
+	movesToGo := p.movesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+	limit := remaining/time.Duration(movesToGo) + increment/2
+	limit = min(limit, remaining/2)
+	return max(limit-moveOverhead, time.Millisecond)
+}
+
+// startSearch запускает поиск в отдельной горутине; по его окончании
+// печатается bestmove. stop и отмена ctx прерывают поиск досрочно.
+func (s *Server) startSearch(ctx context.Context, params goParams) {
+	position := s.game.Clone()
+	boardSize := position.Board.Size
+	limit := params.timeLimit(position.CurrentPlayer.Color)
+	level := s.level
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+
+	go func() {
+		defer close(done)
+		startTime := time.Now()
+
+		var best *model.Move
+		var err error
+		if level == engine.LevelFull {
+			var result engine.Result
+			result, err = engine.Search(searchCtx, position, engine.Options{MaxDepth: params.depth, TimeLimit: limit})
+			if err == nil {
+				best = result.Move
+				s.send(fmt.Sprintf("info depth %d score cp %d nodes %d time %d pv %s",
+					result.Depth, result.Score, result.Nodes,
+					time.Since(startTime).Milliseconds(), best.CoordinateNotation(boardSize)))
+			}
+		} else {
+			best, err = engine.ChooseMove(searchCtx, position, level, limit)
+		}
+
+		if err != nil {
+			s.info(err)
+			// По протоколу на каждый go нужен bestmove; 0000 — нулевой ход.
+			s.send("bestmove 0000")
+			return
+		}
+		s.send("bestmove " + best.CoordinateNotation(boardSize))
+	}()
+}
+
+// stopSearch прерывает текущий поиск и дожидается его bestmove.
+func (s *Server) stopSearch() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel, s.done = nil, nil
+}
+
+func (s *Server) waitSearch() {
+	if s.done != nil {
+		<-s.done
+	}
+}
+
+func (s *Server) send(line string) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintln(s.out, line)
+}
+
+func (s *Server) info(err error) {
+	s.send("info string " + err.Error())
+}
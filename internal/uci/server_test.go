@@ -0,0 +1,217 @@
+package uci
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+)
+
+func TestServerQuitReleasesReader(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// Строки после quit читает горутина, которую Run уже не слушает: она
+	// должна выйти, а не зависнуть на отправке.
+	in := strings.NewReader("uci\nquit\nisready\nisready\n")
+	var out strings.Builder
+	if err := NewServer(in, &out).Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if !strings.Contains(out.String(), "uciok") {
+		t.Errorf("ответ на uci: %q", out.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("горутин после quit: %d, до Run: %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// session — сервер UCI, которому тест пишет команды и читает ответы по
+// строкам.
+type session struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+	done  chan error
+}
+
+// lineWriter передает напечатанные сервером строки в канал.
+type lineWriter struct {
+	lines chan<- string
+}
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.lines <- line
+	}
+	return len(p), nil
+}
+
+func startSession(t *testing.T) *session {
+	t.Helper()
+	r, w := io.Pipe()
+	s := &session{t: t, in: w, lines: make(chan string, 256), done: make(chan error, 1)}
+	srv := NewServer(r, lineWriter{s.lines})
+	go func() { s.done <- srv.Run(context.Background()) }()
+	t.Cleanup(func() {
+		w.Close()
+		select {
+		case <-s.done:
+		case <-time.After(10 * time.Second):
+			t.Error("Run не завершился после конца ввода")
+		}
+	})
+	return s
+}
+
+func (s *session) send(commands ...string) {
+	s.t.Helper()
+	for _, command := range commands {
+		if _, err := io.WriteString(s.in, command+"\n"); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+// expect читает ответы до строки, начинающейся с prefix, и возвращает ее.
+func (s *session) expect(prefix string) string {
+	s.t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line := <-s.lines:
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-timeout:
+			s.t.Fatalf("нет ответа %q", prefix)
+		}
+	}
+}
+
+// checkLegal проверяет, что bestmove — допустимый ход в позиции fen после
+// ходов moves.
+func checkLegal(t *testing.T, line, fen string, moves ...string) {
+	t.Helper()
+	game, err := model.NewGameFromFEN("Белые", "Черные", fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	for _, text := range append(moves, strings.Fields(line)[1]) {
+		move, err := game.ParseMoveText(text)
+		if err == nil {
+			err = game.MakeMove(move)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+}
+
+func TestServerHandshake(t *testing.T) {
+	s := startSession(t)
+	s.send("uci")
+	if line := s.expect("id name"); line != "id name "+engineName {
+		t.Errorf("имя движка %q", line)
+	}
+	s.expect("option name Level")
+	s.expect("uciok")
+	s.send("isready")
+	s.expect("readyok")
+	s.send("ход e2e4", "isready")
+	if line := s.expect("info string"); !strings.Contains(line, "неизвестная команда") {
+		t.Errorf("ответ на неизвестную команду %q", line)
+	}
+	s.expect("readyok")
+}
+
+func TestServerPosition(t *testing.T) {
+	const after = "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"
+	const kiwipete = "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	tests := []struct {
+		name     string
+		position string
+		want     string // FEN после команды
+		err      bool   // ожидается info string с ошибкой
+	}{
+		{"начальная с ходами", "startpos moves e2e4 e7e5", after, false},
+		{"FEN", "fen " + kiwipete, kiwipete, false},
+		{"FEN с ходами", "fen " + kiwipete + " moves e1g1", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R4RK1 b kq - 1 1", false},
+		{"недопустимый ход", "startpos moves e2e5", model.StartFEN, true},
+		{"неверный FEN", "fen 8/8 w", model.StartFEN, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startSession(t)
+			s.send("position "+tt.position, "go depth 1")
+			if tt.err {
+				s.expect("info string")
+			}
+			checkLegal(t, s.expect("bestmove"), tt.want)
+		})
+	}
+}
+
+func TestServerGo(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+	}{
+		{"глубина", "go depth 2"},
+		{"время на ход", "go movetime 100"},
+		{"часы", "go wtime 1000 btime 1000 winc 10 binc 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startSession(t)
+			s.send("position startpos moves e2e4", tt.cmd)
+			s.expect("info depth")
+			checkLegal(t, s.expect("bestmove"), model.StartFEN, "e2e4")
+		})
+	}
+}
+
+func TestServerStopInfinite(t *testing.T) {
+	s := startSession(t)
+	s.send("position startpos", "go infinite")
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case line := <-s.lines:
+		if strings.HasPrefix(line, "bestmove") {
+			t.Fatalf("go infinite закончился без stop: %s", line)
+		}
+	default:
+	}
+	s.send("stop")
+	checkLegal(t, s.expect("bestmove"), model.StartFEN)
+	s.send("isready")
+	s.expect("readyok")
+}
+
+func TestServerNoMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"мат", "7k/6Q1/5K2/8/8/8/8/8 b - - 0 1"},
+		{"пат", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startSession(t)
+			s.send("position fen "+tt.fen, "go depth 3")
+			if line := s.expect("bestmove"); line != "bestmove 0000" {
+				t.Errorf("%s, ожидается bestmove 0000", line)
+			}
+		})
+	}
+}
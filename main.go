@@ -3,10 +3,12 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"math/rand"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/imyakin/go_hw/internal/model"
 	"github.com/imyakin/go_hw/internal/repository"
+	"github.com/imyakin/go_hw/internal/uci"
 )
 
 var whitePieces = map[string]string{
@@ -34,14 +37,20 @@ var blackPieces = map[string]string{
 	"pawn":   "♟",
 }
 
+// stdin is shared by the setup prompts and gameLoop so that buffered input
+// read during setup is not lost when the game starts.
+var stdin = bufio.NewReader(os.Stdin)
+
 type GameManager struct {
 	games []*model.Game
 	mu    sync.RWMutex
+	repo  repository.Repository
 }
 
-func NewGameManager() *GameManager {
+func NewGameManager(repo repository.Repository) *GameManager {
 	return &GameManager{
 		games: make([]*model.Game, 0),
+		repo:  repo,
 	}
 }
 
@@ -49,7 +58,7 @@ func (m *GameManager) AddGame(game *model.Game) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.games = append(m.games, game)
-	repository.LogSliceChange("games", "add", fmt.Sprintf("added game %p", game))
+	m.repo.LogChange("games", "add", fmt.Sprintf("added game %s", game.ID))
 }
 
 func (m *GameManager) GetGames() []*model.Game {
@@ -72,7 +81,9 @@ func (m *GameManager) RemoveFinishedGames() {
 		finished := game.IsFinished()
 		game.Mu.RUnlock()
 		if finished {
-			repository.RemoveGame(game)
+			// Keep the finished game in the repository so its result is persisted
+			saveGame(m.repo, game)
+			rateGame(m.repo, game, false)
 			continue
 		}
 		remaining = append(remaining, game)
@@ -87,64 +98,149 @@ func (m *GameManager) GetGameCount() int {
 }
 
 func main() {
+	storage := flag.String("storage", repository.BackendCSV, "storage backend: csv or memory")
+	dataDir := flag.String("data", repository.DefaultDataDir, "directory with data files")
+	listen := flag.String("listen", "", "also serve the API and live boards on this address, e.g. localhost:8080")
+	flag.Parse()
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	if err := repository.LoadAll(); err != nil {
-		fmt.Printf("Предупреждение: ошибка загрузки данных из CSV: %v\n", err)
-	} else {
-		fmt.Println("Данные из предыдущих сессий загружены из CSV файлов.")
-		repository.PrintStats()
+	// go_hw uci: work as an engine for chess GUIs over stdin/stdout
+	if flag.Arg(0) == "uci" {
+		if err := uci.NewServer(stdin, os.Stdout).Run(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "uci: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	repo, err := repository.Open(*storage, *dataDir)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		os.Exit(2)
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			fmt.Printf("Ошибка сохранения данных: %v\n", err)
+		}
+	}()
+	loadErr := repo.Load()
+	if loadErr != nil {
+		// Whatever could be read is loaded even when some data is damaged
+		fmt.Printf("Предупреждение: ошибка загрузки данных: %v\n", loadErr)
+	}
+
+	// go_hw games [flags]: search the stored games
+	if flag.Arg(0) == "games" {
+		if err := listGames(repo, flag.Args()[1:], os.Stdout); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
+			repo.Close()
+			os.Exit(2)
+		}
+		return
+	}
+
+	// go_hw stats [flags] or go_hw leaderboard: ratings and statistics
+	if flag.Arg(0) == "stats" || flag.Arg(0) == "leaderboard" {
+		if err := showStats(repo, flag.Args()[1:], os.Stdout); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
+			repo.Close()
+			os.Exit(2)
+		}
+		return
+	}
+
+	// go_hw tournament new|list|show|run: tournaments
+	if flag.Arg(0) == "tournament" {
+		var wg sync.WaitGroup
+		if *listen != "" {
+			listenInBackground(ctx, repo, *listen, &wg)
+		}
+		err := tournamentCommand(ctx, repo, flag.Args()[1:], os.Stdout)
+		stop()
+		wg.Wait()
+		closeAutoPlayers()
+		if err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
+			repo.Close()
+			os.Exit(2)
+		}
+		return
 	}
 
-	games := startGames()
+	// go_hw serve [-addr host:port]: HTTP JSON API
+	if flag.Arg(0) == "serve" {
+		if err := serve(ctx, repo, flag.Args()[1:], os.Stdout); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
+			repo.Close()
+			os.Exit(2)
+		}
+		return
+	}
+
+	if loadErr == nil {
+		fmt.Println("Данные из предыдущих сессий загружены.")
+	}
+	printLeaderboard(repo, os.Stdout, sessionLeaderboardSize)
+
+	games, resumed := resumeGames(ctx, repo)
+	if !resumed {
+		games = startGames(ctx)
+	}
+	defer closeAutoPlayers()
 	if len(games) == 0 {
 		return
 	}
 
-	manager := NewGameManager()
+	manager := NewGameManager(repo)
 	for _, game := range games {
-		placePieces(game.Board, game.Board.Size)
-		game.Start()
+		// Games imported from PGN are already started and may even be finished
+		if game.Status == model.StatusNotStarted {
+			game.Start()
+		}
 		manager.AddGame(game)
-		repository.Store(game)
-		repository.Store(game.Board)
-		repository.Store(game.WhitePlayer)
-		repository.Store(game.BlackPlayer)
+		registerPlayers(repo, game)
+		reportSaveError(repo.Transaction(func(tx repository.Tx) error {
+			tx.Store(game)
+			tx.Store(game.Board)
+			tx.Store(game.WhitePlayer)
+			tx.Store(game.BlackPlayer)
+			for _, move := range game.Moves {
+				tx.Store(move)
+			}
+			return nil
+		}))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		sliceLogger(ctx)
+		sliceLogger(ctx, repo)
 	}()
+	if *listen != "" {
+		listenInBackground(ctx, repo, *listen, &wg)
+		for _, game := range manager.GetGames() {
+			publishGame(game)
+		}
+	}
 
 	if manager.GetGameCount() == 1 {
 		game := manager.GetGames()[0]
 		displayBoard(game, 1)
-		gameLoop(ctx, game)
+		gameLoop(ctx, repo, game)
+		rateGame(repo, game, true)
 	} else {
-		updateCh := make(chan []*model.Game, 16)
-		wg.Add(2)
-		go func() {
-			defer wg.Done()
-			gameSimulator(ctx, manager, updateCh)
-		}()
-		go func() {
-			defer wg.Done()
-			boardRenderer(ctx, manager, updateCh)
-		}()
-
-		for manager.GetGameCount() > 0 {
-			select {
-			case <-ctx.Done():
-				goto waitWorkers
-			default:
-				time.Sleep(200 * time.Millisecond)
-			}
-		}
-	waitWorkers:
+		runSimulation(ctx, manager)
 	}
 
 	exitedBySignal := (ctx.Err() != nil)
@@ -152,17 +248,61 @@ func main() {
 	wg.Wait()
 
 	if !exitedBySignal {
-		repository.PrintStats()
+		printLeaderboard(repo, os.Stdout, sessionLeaderboardSize)
 	}
 }
 
-func startGames() []*model.Game {
-	var player1Name, player2Name string
-	var size int
+// resumeGames offers to continue the games left in progress in the repository.
+// resumed is false when there is nothing to resume or new boards are wanted.
+func resumeGames(ctx context.Context, repo repository.Repository) (games []*model.Game, resumed bool) {
+	unfinished := repo.FindGames(repository.GameQuery{Status: model.StatusInProgress}).Games
+	if len(unfinished) == 0 {
+		return nil, false
+	}
+
+	fmt.Println("Незаконченные партии:")
+	for i, game := range unfinished {
+		fmt.Printf("%d. [%.8s] %s — %s, доска %dx%d, ходов: %d, ход %s, обновлена %s\n",
+			i+1,
+			game.ID,
+			game.WhitePlayer.GetDisplayName(),
+			game.BlackPlayer.GetDisplayName(),
+			game.Board.Size, game.Board.Size,
+			game.GetMoveCount(),
+			game.CurrentPlayer.GetDisplayName(),
+			game.UpdatedAt.Local().Format("02.01.2006 15:04"),
+		)
+	}
+	fmt.Print("Введите номер партии, 'все' чтобы продолжить все в режиме симуляции или 'новая' для новых досок: ")
+	input := readLine()
+	switch {
+	case strings.EqualFold(input, "новая"):
+		return nil, false
+	case strings.EqualFold(input, "все"):
+		games = unfinished
+	default:
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(unfinished) {
+			fmt.Printf("Ошибка: номер партии должен быть от 1 до %d\n", len(unfinished))
+			return nil, true
+		}
+		games = unfinished[n-1 : n]
+	}
+
+	for _, game := range games {
+		game.Resume()
+		if !configurePlayers(ctx, game, len(games) == 1) {
+			return nil, true
+		}
+	}
+	return games, true
+}
+
+func startGames(ctx context.Context) []*model.Game {
 	var gameCount int
 
 	fmt.Print("Введите количество досок: ")
-	fmt.Scan(&gameCount)
+	fmt.Fscan(stdin, &gameCount)
 	if gameCount <= 0 {
 		fmt.Println("Ошибка: количество досок должно быть больше 0")
 		return nil
@@ -171,24 +311,109 @@ func startGames() []*model.Game {
 	games := make([]*model.Game, 0, gameCount)
 	for i := 0; i < gameCount; i++ {
 		fmt.Printf("Доска %d\n", i+1)
-		fmt.Print("Введите размер доски: ")
-		fmt.Scan(&size)
-		if size <= 0 {
-			fmt.Println("Ошибка: размер доски должен быть больше 0")
+		game := setupGame()
+		if game == nil {
+			return nil
+		}
+		if !setupClock(game) {
+			return nil
+		}
+		if !configurePlayers(ctx, game, gameCount == 1) {
 			return nil
 		}
-		fmt.Print("Введите имя игрока 1: ")
-		fmt.Scan(&player1Name)
-		fmt.Print("Введите имя игрока 2: ")
-		fmt.Scan(&player2Name)
-
-		game := model.NewGame(player1Name, player2Name, size)
 		games = append(games, game)
 	}
 
 	return games
 }
 
+// setupGame reads one board: its size, a FEN position or a .pgn file to import.
+func setupGame() *model.Game {
+	var player1Name, player2Name string
+
+	fmt.Print("Введите размер доски, позицию в FEN или путь к файлу .pgn: ")
+	setup := readLine()
+	if strings.HasSuffix(strings.ToLower(setup), ".pgn") {
+		game, err := importPGN(setup)
+		if err != nil {
+			fmt.Printf("Ошибка импорта PGN: %v\n", err)
+			return nil
+		}
+		return game
+	}
+	size, sizeErr := strconv.Atoi(setup)
+	if sizeErr == nil && size <= 0 {
+		fmt.Println("Ошибка: размер доски должен быть больше 0")
+		return nil
+	}
+	fmt.Print("Введите имя игрока 1: ")
+	fmt.Fscan(stdin, &player1Name)
+	fmt.Print("Введите имя игрока 2: ")
+	fmt.Fscan(stdin, &player2Name)
+
+	if sizeErr == nil {
+		game := model.NewGame(player1Name, player2Name, size)
+		placePieces(game.Board, size)
+		return game
+	}
+
+	game, err := model.NewGameFromFEN(player1Name, player2Name, setup)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return nil
+	}
+	return game
+}
+
+// setupClock asks for the time control of the game; "нет" plays without clocks.
+func setupClock(game *model.Game) bool {
+	fmt.Print("Контроль времени (например 5+3, 15+10, 40/90:30+30, 5d3 или 'нет'): ")
+	input := readLine()
+	if strings.EqualFold(input, "нет") || input == "-" {
+		return true
+	}
+	control, err := model.ParseTimeControl(input)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return false
+	}
+	game.SetTimeControl(control)
+	return true
+}
+
+func importPGN(path string) (*model.Game, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParsePGN(string(data))
+}
+
+// stdinLines returns the lines typed during games. Lines are read by a single
+// goroutine so that games played one after another do not compete for stdin.
+var stdinLines = sync.OnceValue(func() <-chan string {
+	lines := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	return lines
+})
+
+// readLine returns the next non-empty line from stdin without surrounding spaces.
+func readLine() string {
+	for {
+		line, err := stdin.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" || err != nil {
+			return line
+		}
+	}
+}
+
 func placePieces(board *model.Board, size int) {
 	// Only place pieces on 8x8 board or larger
 	if size < 8 {
@@ -252,6 +477,16 @@ func displayBoard(game *model.Game, index int) {
 		game.BlackPlayer.GetDisplayName(),
 		game.LastBlackTime,
 	)
+	if game.Clock != nil {
+		now := time.Now()
+		fmt.Printf("Часы (%s): %s %s | %s %s\n",
+			game.Clock.Control,
+			game.WhitePlayer.GetDisplayName(),
+			formatClock(game.TimeLeft(model.White, now)),
+			game.BlackPlayer.GetDisplayName(),
+			formatClock(game.TimeLeft(model.Black, now)),
+		)
+	}
 
 	// Print column header
 	fmt.Print(makeColumnHeader(size, rowNumberWidth))
@@ -288,51 +523,304 @@ func displayBoard(game *model.Game, index int) {
 
 		fmt.Println()
 	}
+
+	if status := gameStatusLine(game); status != "" {
+		fmt.Println(status)
+	}
 }
 
-func gameLoop(ctx context.Context, game *model.Game) {
-	inputCh := make(chan string, 1)
-	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			inputCh <- scanner.Text()
+// formatClock shows the remaining time as h:mm:ss or m:ss, with tenths of a
+// second in the last ten seconds.
+func formatClock(d time.Duration) string {
+	if d < 10*time.Second {
+		return fmt.Sprintf("0:%04.1f", d.Seconds())
+	}
+	d = d.Truncate(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// gameStatusLine describes check or the final result; the caller holds game.Mu.
+func gameStatusLine(game *model.Game) string {
+	switch {
+	case game.IsInProgress() && game.IsCheck():
+		return fmt.Sprintf("Шах! %s, защитите короля", game.CurrentPlayer.GetDisplayName())
+	case !game.IsFinished():
+		return ""
+	}
+
+	reason := resultReasonNames[game.ResultReason]
+	switch {
+	case game.Winner != nil && reason != "":
+		return fmt.Sprintf("%s! Победил %s", reason, game.Winner.GetDisplayName())
+	case game.Result == model.ResultDraw && reason != "":
+		return fmt.Sprintf("Ничья: %s", strings.ToLower(reason))
+	case game.Result == model.ResultDraw:
+		return "Ничья"
+	case game.Winner != nil:
+		return fmt.Sprintf("Игра окончена. Победил %s", game.Winner.GetDisplayName())
+	}
+	return "Игра окончена"
+}
+
+var resultReasonNames = map[model.ResultReason]string{
+	model.ReasonCheckmate:            "Мат",
+	model.ReasonResignation:          "Соперник сдался",
+	model.ReasonStalemate:            "Пат",
+	model.ReasonFiftyMoves:           "Правило 50 ходов",
+	model.ReasonThreefoldRepetition:  "Троекратное повторение позиции",
+	model.ReasonInsufficientMaterial: "Недостаточно материала для мата",
+	model.ReasonAgreement:            "По соглашению сторон",
+	model.ReasonTimeout:              "Время истекло",
+}
+
+// takeBack undoes the current player's last move together with the opponent's
+// reply, once the opponent agrees.
+func takeBack(ctx context.Context, repo repository.Repository, game *model.Game, inputCh <-chan string) {
+	game.Mu.RLock()
+	requester := game.CurrentPlayer
+	count := min(2, game.GetMoveCount())
+	canUndo := game.CanUndo()
+	game.Mu.RUnlock()
+	if !canUndo {
+		fmt.Printf("Ошибка: %v\n", model.ErrNothingToUndo)
+		return
+	}
+
+	opponent := game.WhitePlayer
+	if requester == game.WhitePlayer {
+		opponent = game.BlackPlayer
+	}
+	// The computer always agrees, so only a human opponent is asked
+	if !isComputer(opponent) {
+		fmt.Printf("%s просит вернуть ход. %s, согласны? (да/нет): ", requester.GetDisplayName(), opponent.GetDisplayName())
+		select {
+		case <-ctx.Done():
+			return
+		case answer, ok := <-inputCh:
+			if !ok || !strings.EqualFold(strings.TrimSpace(answer), "да") {
+				fmt.Println("Отмена хода отклонена")
+				return
+			}
 		}
-		close(inputCh)
-	}()
+	}
 
-	for game.IsInProgress() {
-		fmt.Printf("\n%s, ваш ход (формат: e2-e4 или 'exit' для выхода или 'Автоход' или 'Сдался'): ", game.CurrentPlayer.GetDisplayName())
+	game.Mu.Lock()
+	var undone []*model.Move
+	for i := 0; i < count; i++ {
+		move, err := game.Undo()
+		if err != nil {
+			break
+		}
+		undone = append(undone, move)
+	}
+	game.Mu.Unlock()
+
+	// Undone moves are removed so that the repository holds only moves of the game
+	reportSaveError(repo.Transaction(func(tx repository.Tx) error {
+		for _, move := range undone {
+			tx.Remove(move)
+		}
+		tx.Store(game)
+		return nil
+	}))
+	publishGame(game)
+	for _, move := range undone {
+		fmt.Printf("Ход %s отменен\n", move.GetNotation())
+	}
+	displayBoard(game, 1)
+}
+
+// formatMoveHistory returns the moves in SAN numbered by full moves; the caller holds game.Mu.
+func formatMoveHistory(game *model.Game) string {
+	if game.GetMoveCount() == 0 {
+		return "Ходов пока нет"
+	}
+	var sb strings.Builder
+	number, blackFirst := 1, false
+	if fields := strings.Fields(game.StartFEN); len(fields) == 6 {
+		number, _ = strconv.Atoi(fields[5])
+		blackFirst = fields[1] == "b"
+	}
+	for i, move := range game.GetMoveHistory() {
+		whiteMove := (i%2 == 0) != blackFirst
+		switch {
+		case whiteMove:
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			fmt.Fprintf(&sb, "%d. ", number)
+		case i == 0:
+			fmt.Fprintf(&sb, "%d... ", number)
+		default:
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(move.GetNotation())
+		if !whiteMove {
+			number++
+		}
+	}
+	return sb.String()
+}
+
+func gameLoop(ctx context.Context, repo repository.Repository, game *model.Game) {
+	inputCh := stdinLines()
+
+	for {
+		inProgress, player := turnState(game)
+		if !inProgress {
+			break
+		}
+		if isComputer(player) {
+			if !computerMove(ctx, repo, game) {
+				return
+			}
+			continue
+		}
+
+		// Think time runs from the prompt to the answer
+		startTime := time.Now()
+		fmt.Printf("\n%s, ваш ход (формат: e4, Nf3, O-O, e8=Q, e2-e4 или 'exit' для выхода, 'Отложить' чтобы продолжить позже или 'Автоход', 'Ничья', 'Сдался', 'назад', 'вперед', 'История', 'FEN', 'PGN [файл]'): ", player.GetDisplayName())
 
 		var input string
 		select {
 		case <-ctx.Done():
 			return
+		case <-flagTimer(game):
+			game.Mu.Lock()
+			flagged := game.CheckFlag(time.Now())
+			game.Mu.Unlock()
+			if flagged {
+				saveGame(repo, game)
+				fmt.Println()
+				displayBoard(game, 1)
+			}
+			continue
 		case line, ok := <-inputCh:
 			if !ok {
 				return
 			}
-			input = line
+			input = strings.TrimSpace(line)
+		}
+		if input == "" {
+			continue
 		}
-
-		startTime := time.Now()
 
 		// 1. exit / quit
 		if input == "exit" || input == "quit" {
+			game.Mu.Lock()
 			game.Finish()
+			moves := game.GetMoveCount()
+			game.Mu.Unlock()
+			saveGame(repo, game)
 			fmt.Println("Игра завершена!")
-			fmt.Printf("Всего ходов: %d\n", game.GetMoveCount())
+			fmt.Printf("Всего ходов: %d\n", moves)
 			break
 		}
 
+		// Отложить: the game stays in progress and is offered for resuming at startup
+		if strings.EqualFold(input, "Отложить") {
+			saveGame(repo, game)
+			fmt.Println("Партия отложена, её можно продолжить при следующем запуске")
+			return
+		}
+
 		// 2. Сдался
 		if strings.EqualFold(input, "Сдался") {
-			resigned := game.CurrentPlayer
+			game.Mu.Lock()
 			game.Resign()
-			fmt.Printf("%s сдался! Победил %s!\n", resigned.GetDisplayName(), game.Winner.GetDisplayName())
+			winner := game.Winner
+			game.Mu.Unlock()
+			saveGame(repo, game)
+			fmt.Printf("%s сдался! Победил %s!\n", player.GetDisplayName(), winner.GetDisplayName())
+			break
+		}
+
+		// 3. FEN текущей позиции
+		if strings.EqualFold(input, "FEN") {
+			game.Mu.RLock()
+			fmt.Println(game.FEN())
+			game.Mu.RUnlock()
+			continue
+		}
+
+		// 4. PGN партии: вывод на экран или запись в файл
+		if fields := strings.Fields(input); len(fields) > 0 && strings.EqualFold(fields[0], "PGN") {
+			game.Mu.RLock()
+			pgn := game.PGN()
+			game.Mu.RUnlock()
+			if len(fields) == 1 {
+				fmt.Print(pgn)
+				continue
+			}
+			if err := os.WriteFile(fields[1], []byte(pgn), 0644); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			fmt.Printf("Партия сохранена в %s\n", fields[1])
+			continue
+		}
+
+		// 5. История ходов
+		if strings.EqualFold(input, "История") || strings.EqualFold(input, "history") {
+			game.Mu.RLock()
+			fmt.Println(formatMoveHistory(game))
+			game.Mu.RUnlock()
+			continue
+		}
+
+		// 6. Отмена и повтор ходов
+		if strings.EqualFold(input, "назад") || strings.EqualFold(input, "undo") {
+			takeBack(ctx, repo, game, inputCh)
+			continue
+		}
+		if strings.EqualFold(input, "вперед") || strings.EqualFold(input, "redo") {
+			game.Mu.Lock()
+			move, err := game.Redo()
+			game.Mu.Unlock()
+			if err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			storeMove(repo, game, move)
+			fmt.Printf("Ход %s повторен\n", move.GetNotation())
+			displayBoard(game, 1)
+			continue
+		}
+
+		// 7. Ничья по соглашению
+		if strings.EqualFold(input, "Ничья") {
+			opponent := game.BlackPlayer
+			if player == game.BlackPlayer {
+				opponent = game.WhitePlayer
+			}
+			fmt.Printf("%s, принимаете ничью? (да/нет): ", opponent.GetDisplayName())
+			var answer string
+			select {
+			case <-ctx.Done():
+				return
+			case line, ok := <-inputCh:
+				if !ok {
+					return
+				}
+				answer = line
+			}
+			if !strings.EqualFold(strings.TrimSpace(answer), "да") {
+				fmt.Println("Ничья отклонена, игра продолжается")
+				continue
+			}
+			game.Mu.Lock()
+			game.AgreeDraw()
+			game.Mu.Unlock()
+			saveGame(repo, game)
+			fmt.Println("Ничья по соглашению сторон!")
 			break
 		}
 
-		// 3. Автоход
+		// 8. Автоход
 		if strings.EqualFold(input, "Автоход") {
 			fmt.Print("Сколько автоходов сделать: ")
 			var count int
@@ -349,7 +837,7 @@ func gameLoop(ctx context.Context, game *model.Game) {
 				}
 			}
 			for i := 0; i < count; i++ {
-				if !game.IsInProgress() {
+				if inProgress, _ := turnState(game); !inProgress {
 					break
 				}
 				select {
@@ -357,168 +845,140 @@ func gameLoop(ctx context.Context, game *model.Game) {
 					return
 				default:
 				}
-				duration, notation, mover, err := autoMove(ctx, game)
-				if err != nil {
+				if !computerMove(ctx, repo, game) {
 					if ctx.Err() != nil {
 						return
 					}
-					fmt.Printf("Ошибка автохода: %v\n", err)
 					break
 				}
-				recordMoveTime(game, mover, duration)
-				fmt.Println(notation)
-				displayBoard(game, 1)
 			}
 			continue
 		}
 
-		// 4. Обычный ход
+		// 9. Обычный ход
 		game.Mu.Lock()
-		move, err := parseMove(input, game.CurrentPlayer, game.Board)
+		move, err := parseMove(input, game)
 		if err != nil {
 			fmt.Printf("Ошибка: %v\n", err)
 			game.Mu.Unlock()
 			continue
 		}
 
-		if !move.IsValid(game.Board) {
-			fmt.Println("Ошибка: неверные координаты хода")
-			game.Mu.Unlock()
-			continue
-		}
-
-		if err := applyMove(game.Board, move); err != nil {
-			fmt.Printf("Ошибка: %v\n", err)
+		// Validate against the rules, apply to the board and switch player
+		if err := game.MakeMove(move); err != nil {
+			var illegal *model.IllegalMoveError
+			if errors.As(err, &illegal) {
+				fmt.Printf("Недопустимый ход: %s\n", illegal.Reason)
+			} else {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
 			game.Mu.Unlock()
+			if errors.Is(err, model.ErrTimeExpired) {
+				saveGame(repo, game)
+				displayBoard(game, 1)
+			}
 			continue
 		}
 
-		// Add move to game history and switch player
-		game.MakeMove(move)
 		duration := time.Since(startTime)
+		move.ThinkTime = duration
 		setMoveTimeUnsafe(game, move.Player, duration)
 		game.Mu.Unlock()
-		repository.Store(move)
+		storeMove(repo, game, move)
 
 		fmt.Println()
 		displayBoard(game, 1)
 	}
 }
 
-func isPlayerPiece(piece string, player *model.Player) bool {
-	if player.IsWhite() {
-		return piece == "♔" || piece == "♕" || piece == "♖" || piece == "♗" || piece == "♘" || piece == "♙"
-	}
-	return piece == "♚" || piece == "♛" || piece == "♜" || piece == "♝" || piece == "♞" || piece == "♟"
+// turnState reports whether the game goes on and whose move it is.
+func turnState(game *model.Game) (inProgress bool, player *model.Player) {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	return game.IsInProgress(), game.CurrentPlayer
 }
 
-func autoMove(ctx context.Context, game *model.Game) (time.Duration, string, *model.Player, error) {
-	startTime := time.Now()
-	delay := time.Duration(2+rand.Intn(3)) * time.Second
-	timer := time.NewTimer(delay)
-	select {
-	case <-ctx.Done():
-		timer.Stop()
-		return 0, "", nil, ctx.Err()
-	case <-timer.C:
+// flagTimer fires when the current player's time runs out; without clocks it never fires.
+func flagTimer(game *model.Game) <-chan time.Time {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	if game.Clock == nil || !game.IsInProgress() {
+		return nil
 	}
+	return time.After(game.TimeLeft(game.CurrentPlayer.Color, time.Now()))
+}
 
-	game.Mu.Lock()
-	defer game.Mu.Unlock()
-	board := game.Board
-	player := game.CurrentPlayer
-
-	for row := 0; row < board.Size; row++ {
-		for col := 0; col < board.Size; col++ {
-			piece := board.GetCell(row, col)
-			if piece == "" || !isPlayerPiece(piece, player) {
-				continue
-			}
-
-			// Determine target row
-			var targetRow int
-			if player.IsWhite() {
-				targetRow = row - 1
-			} else {
-				targetRow = row + 1
-			}
-
-			if !board.IsValidPosition(targetRow, col) {
-				continue
-			}
-
-			targetPiece := board.GetCell(targetRow, col)
-			if targetPiece != "" && isPlayerPiece(targetPiece, player) {
-				continue
-			}
-
-			move := model.NewMove(row, col, targetRow, col, player, piece)
-
-			if err := applyMove(board, move); err != nil {
-				continue
-			}
-			game.MakeMove(move)
-			repository.Store(move)
-
-			fromCol := string(rune('a' + col))
-			fromRow := board.Size - row
-			toCol := string(rune('a' + col))
-			toRow := board.Size - targetRow
-			duration := time.Since(startTime)
-			notation := fmt.Sprintf("Автоход: %s%d-%s%d", fromCol, fromRow, toCol, toRow)
-			return duration, notation, player, nil
+// computerMove plays the move of a computer-controlled player in gameLoop.
+// It returns false when the game cannot continue.
+func computerMove(ctx context.Context, repo repository.Repository, game *model.Game) bool {
+	duration, notation, mover, err := autoMove(ctx, repo, game)
+	if errors.Is(err, model.ErrTimeExpired) {
+		saveGame(repo, game)
+		displayBoard(game, 1)
+		return true
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Printf("Ошибка автохода: %v\n", err)
 		}
+		return false
 	}
-
-	return 0, "", player, fmt.Errorf("нет доступных ходов для %s", player.GetDisplayName())
+	recordMoveTime(game, mover, duration)
+	fmt.Println(notation)
+	displayBoard(game, 1)
+	return true
 }
 
-func parseMove(input string, player *model.Player, board *model.Board) (*model.Move, error) {
-	if len(input) < 5 || input[2] != '-' {
-		return nil, fmt.Errorf("неверный формат хода. Используйте формат: e2-e4")
-	}
-
-	fromCol := convertColumnToIndex(input[0])
-	fromRow := int(input[1] - '1')
-	toCol := convertColumnToIndex(input[3])
-	toRow := int(input[4] - '1')
-
-	if fromCol < 0 || toCol < 0 {
-		return nil, fmt.Errorf("неверная колонка")
+// reportSaveError tells the user that a change was not saved; the game goes on
+// since it is still held in memory.
+func reportSaveError(err error) {
+	if err != nil {
+		fmt.Printf("Ошибка сохранения: %v\n", err)
 	}
+}
 
-	// Convert display row to actual board row (inverted)
-	fromRowActual := board.Size - 1 - fromRow
-	toRowActual := board.Size - 1 - toRow
-
-	piece := board.GetCell(fromRowActual, fromCol)
-	if piece == "" {
-		return nil, fmt.Errorf("на клетке %c%d нет фигуры", input[0], fromRow+1)
+// registerPlayers links both players of the game to their persistent profiles.
+func registerPlayers(repo repository.Repository, game *model.Game) {
+	for _, player := range []*model.Player{game.WhitePlayer, game.BlackPlayer} {
+		_, err := repository.RegisterPlayer(repo, player)
+		reportSaveError(err)
 	}
-
-	return model.NewMove(fromRowActual, fromCol, toRowActual, toCol, player, piece), nil
 }
 
-func applyMove(board *model.Board, move *model.Move) error {
-	piece := board.GetCell(move.From.Row, move.From.Col)
-	if piece == "" {
-		return fmt.Errorf("нет фигуры на начальной позиции")
+// rateGame updates the players' ratings once the game has a result; verbose
+// prints the new ratings.
+func rateGame(repo repository.Repository, game *model.Game, verbose bool) {
+	changes, err := repository.RateGame(repo, game)
+	reportSaveError(err)
+	if !verbose {
+		return
 	}
+	for _, change := range changes {
+		profile, ok := repo.Profile(change.ProfileID)
+		if !ok {
+			continue
+		}
+		fmt.Printf("Рейтинг %s: %d → %d (%+d)\n", profile.Name, change.Before, change.After, change.After-change.Before)
+	}
+}
 
-	board.SetCell(move.To.Row, move.To.Col, piece)
-	board.SetCell(move.From.Row, move.From.Col, "")
-
-	return nil
+// storeMove saves a move together with the game it was made in. The game is
+// stored after every move so that an unfinished game can be resumed.
+func storeMove(repo repository.Repository, game *model.Game, move *model.Move) {
+	reportSaveError(repo.Transaction(func(tx repository.Tx) error {
+		tx.Store(move)
+		tx.Store(game)
+		return nil
+	}))
+	publishGame(game)
 }
 
-func convertColumnToIndex(col byte) int {
-	if col >= 'a' && col <= 'z' {
-		return int(col - 'a')
+func parseMove(input string, game *model.Game) (*model.Move, error) {
+	move, err := game.ParseMoveText(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w. Примеры: e4, Nf3, exd5, O-O, e8=Q, e2-e4, e2e4", err)
 	}
-	if col >= 'A' && col <= 'Z' {
-		return int(col - 'A')
-	}
-	return -1
+	return move, nil
 }
 
 func recordMoveTime(game *model.Game, player *model.Player, duration time.Duration) {
@@ -545,6 +1005,31 @@ func displayAllBoards(games []*model.Game) {
 	}
 }
 
+// runSimulation plays all games of the manager with automatic moves and
+// renders the boards until the games finish or ctx is cancelled.
+func runSimulation(ctx context.Context, manager *GameManager) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updateCh := make(chan []*model.Game, 16)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		gameSimulator(ctx, manager, updateCh)
+	}()
+	go func() {
+		defer wg.Done()
+		boardRenderer(ctx, manager, updateCh)
+	}()
+
+	for manager.GetGameCount() > 0 && ctx.Err() == nil {
+		time.Sleep(200 * time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+}
+
 func gameSimulator(ctx context.Context, manager *GameManager, updateCh chan<- []*model.Game) {
 	var wg sync.WaitGroup
 	for _, game := range manager.GetGames() {
@@ -573,7 +1058,7 @@ func simulateGame(ctx context.Context, game *model.Game, manager *GameManager, u
 			return
 		}
 
-		duration, _, mover, err := autoMove(ctx, game)
+		duration, _, mover, err := autoMove(ctx, manager.repo, game)
 		if err != nil {
 			if ctx.Err() != nil {
 				return
@@ -616,12 +1101,12 @@ func boardRenderer(ctx context.Context, manager *GameManager, updateCh <-chan []
 	}
 }
 
-func sliceLogger(ctx context.Context) {
+func sliceLogger(ctx context.Context, repo repository.Repository) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case change := <-repository.SliceChangeChan:
+		case change := <-repo.Changes():
 			fmt.Printf("[SLICE] %s %s at %s (%s)\n",
 				change.SliceType,
 				change.Operation,
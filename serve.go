@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+	"github.com/imyakin/go_hw/internal/server"
+)
+
+// shutdownTimeout is how long requests in flight may finish after an interrupt.
+const shutdownTimeout = 5 * time.Second
+
+// serve implements "go_hw serve [-addr host:port]": the HTTP JSON API over
+// the repository, until the program is interrupted.
+func serve(ctx context.Context, repo repository.Repository, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(out)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return listenAndServe(ctx, *addr, server.New(repo), out)
+}
+
+// listenAndServe serves the API until ctx is cancelled, then lets the requests
+// in flight finish. The live board stream runs for as long as the server.
+func listenAndServe(ctx context.Context, addr string, api *server.Server, out io.Writer) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Streams stay open until the spectator leaves; they must not hold
+		// up the shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	go api.Hub().Run(ctx)
+	fmt.Fprintf(out, "API слушает http://%s/api/\n", addr)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// spectators streams the games played in this process to the API clients when
+// the program runs with -listen; nil otherwise.
+var spectators *server.Hub
+
+// listenInBackground serves the API with the live board stream while games
+// are played on the terminal, until ctx is cancelled. The terminal games are
+// read-only over the API; only games created through it accept moves.
+func listenInBackground(ctx context.Context, repo repository.Repository, addr string, wg *sync.WaitGroup) {
+	api := server.NewShared(repo)
+	spectators = api.Hub()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := listenAndServe(ctx, addr, api, os.Stdout); err != nil {
+			fmt.Printf("Ошибка сервера: %v\n", err)
+		}
+	}()
+}
+
+// publishGame tells the spectators about new moves and the result of the game.
+func publishGame(game *model.Game) {
+	if spectators != nil {
+		spectators.Update(game)
+	}
+}
+
+// saveGame stores the game and publishes the change to the spectators.
+func saveGame(repo repository.Repository, game *model.Game) {
+	reportSaveError(repo.Store(game))
+	publishGame(game)
+}
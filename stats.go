@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/imyakin/go_hw/internal/repository"
+)
+
+const (
+	// historyShown is how many recent rating changes the player report lists.
+	historyShown = 10
+	// sessionLeaderboardSize is how many places are shown when a session
+	// starts and ends.
+	sessionLeaderboardSize = 5
+)
+
+// showStats implements "go_hw stats [flags]" (also "go_hw leaderboard"): the
+// leaderboard with a summary of all games, or the report of one player.
+func showStats(repo repository.Repository, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(out)
+	player := fs.String("player", "", "show the statistics of this player")
+	top := fs.Int("top", 20, "number of leaderboard places, 0 for all players")
+	openings := fs.Int("openings", 5, "number of most frequent openings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *player == "" {
+		printLeaderboard(repo, out, *top)
+		fmt.Fprintln(out)
+		printGameStats(out, repository.Summary(repo, *openings), true)
+		return nil
+	}
+	profile, ok := repo.ProfileByName(*player)
+	if !ok {
+		return fmt.Errorf("игрок %q не найден", *player)
+	}
+	printPlayerStats(out, repository.PlayerSummary(repo, profile, *openings))
+	return nil
+}
+
+// printLeaderboard prints the top places of the leaderboard; top <= 0 prints all.
+func printLeaderboard(repo repository.Repository, out io.Writer, top int) {
+	standings := repository.Leaderboard(repo)
+	fmt.Fprintln(out, "=== Рейтинг игроков ===")
+	if len(standings) == 0 {
+		fmt.Fprintln(out, "Оцененных партий пока нет")
+		return
+	}
+	if top > 0 && len(standings) > top {
+		standings = standings[:top]
+	}
+	for _, s := range standings {
+		fmt.Fprintf(out, "%3d. %-20s %5d  +%d −%d =%d  побед %s\n",
+			s.Rank, s.Name, s.Rating, s.Wins, s.Losses, s.Draws, percent(s.WinRate()))
+	}
+}
+
+// printGameStats prints a summary of games; overall reports the color results
+// from White's side only, since Black's mirror them.
+func printGameStats(out io.Writer, stats repository.GameStats, overall bool) {
+	fmt.Fprintf(out, "Партий: %d, законченных: %d\n", stats.Games, stats.Finished)
+	if overall {
+		fmt.Fprintf(out, "Белые: побед %s, ничьих %s, поражений %s\n",
+			share(stats.White.Wins, stats.White.Games()),
+			share(stats.White.Draws, stats.White.Games()),
+			share(stats.White.Losses, stats.White.Games()))
+	} else {
+		fmt.Fprintf(out, "Белыми: %s побед (+%d −%d =%d)\n",
+			percent(stats.White.WinRate()), stats.White.Wins, stats.White.Losses, stats.White.Draws)
+		fmt.Fprintf(out, "Черными: %s побед (+%d −%d =%d)\n",
+			percent(stats.Black.WinRate()), stats.Black.Wins, stats.Black.Losses, stats.Black.Draws)
+	}
+	if stats.Finished > 0 {
+		fmt.Fprintf(out, "Средняя длина партии: %.1f полуходов\n", stats.AverageLength)
+	}
+	if stats.AverageThinkTime > 0 {
+		fmt.Fprintf(out, "Среднее время на ход: %.2f с\n", stats.AverageThinkTime.Seconds())
+	}
+	if len(stats.Openings) > 0 {
+		fmt.Fprintln(out, "Частые дебюты:")
+		for _, o := range stats.Openings {
+			fmt.Fprintf(out, "  %-28s %d\n", o.Moves, o.Games)
+		}
+	}
+}
+
+func printPlayerStats(out io.Writer, stats repository.PlayerStats) {
+	fmt.Fprintf(out, "=== %s ===\n", stats.Name)
+	fmt.Fprintf(out, "Рейтинг: %d, место %d\n", stats.Rating, stats.Rank)
+	fmt.Fprintf(out, "Оцененные партии: +%d −%d =%d, побед %s\n",
+		stats.Wins, stats.Losses, stats.Draws, percent(stats.WinRate()))
+	printGameStats(out, stats.GameStats, false)
+
+	if len(stats.HeadToHead) > 0 {
+		fmt.Fprintln(out, "Личные встречи:")
+		for _, h := range stats.HeadToHead {
+			fmt.Fprintf(out, "  %-20s +%d −%d =%d\n", h.Opponent, h.Wins, h.Losses, h.Draws)
+		}
+	}
+	if len(stats.History) > 0 {
+		history := stats.History[max(len(stats.History)-historyShown, 0):]
+		fmt.Fprintln(out, "Последние изменения рейтинга:")
+		for _, c := range history {
+			fmt.Fprintf(out, "  %s  %d → %d (%+d)\n",
+				c.CreatedAt.Local().Format("02.01.2006 15:04"), c.Before, c.After, c.After-c.Before)
+		}
+	}
+}
+
+func percent(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+func share(n, total int) string {
+	if total == 0 {
+		return percent(0)
+	}
+	return percent(float64(n) / float64(total))
+}
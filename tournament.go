@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imyakin/go_hw/internal/engine"
+	"github.com/imyakin/go_hw/internal/model"
+	"github.com/imyakin/go_hw/internal/repository"
+	"github.com/imyakin/go_hw/internal/tournament"
+)
+
+var formatNames = map[model.TournamentFormat]string{
+	model.RoundRobin: "круговой",
+	model.Swiss:      "швейцарская система",
+	model.Knockout:   "на выбывание",
+}
+
+// tournamentCommand implements "go_hw tournament new|list|show|run".
+func tournamentCommand(ctx context.Context, repo repository.Repository, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("укажите команду турнира: new, list, show или run")
+	}
+	switch args[0] {
+	case "new":
+		return newTournament(repo, args[1:], out)
+	case "list":
+		listTournaments(repo, out)
+		return nil
+	case "show":
+		t, err := findTournament(repo, args[1:])
+		if err != nil {
+			return err
+		}
+		printTournament(out, t)
+		return nil
+	case "run":
+		t, err := findTournament(repo, args[1:])
+		if err != nil {
+			return err
+		}
+		return runTournament(ctx, repo, t)
+	}
+	return fmt.Errorf("неизвестная команда турнира %q: доступны new, list, show и run", args[0])
+}
+
+// newTournament implements "go_hw tournament new [flags] participant...".
+// A participant is a name for a human player, name=level for the built-in
+// engine or name=command for a UCI engine.
+func newTournament(repo repository.Repository, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("tournament new", flag.ContinueOnError)
+	fs.SetOutput(out)
+	name := fs.String("name", "", "tournament name")
+	format := fs.String("format", string(model.RoundRobin), "round_robin, swiss or knockout")
+	rounds := fs.Int("rounds", 0, "number of Swiss rounds, 0 to derive from the number of participants")
+	control := fs.String("time", "", "time control of the games, e.g. 5+3; empty for no clocks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *control != "" {
+		if _, err := model.ParseTimeControl(*control); err != nil {
+			return err
+		}
+	}
+	if *name == "" {
+		*name = "Турнир " + time.Now().Format("02.01.2006 15:04")
+	}
+
+	var entrants []tournament.Entrant
+	for _, arg := range fs.Args() {
+		playerName, engineSpec, _ := strings.Cut(arg, "=")
+		playerName, engineSpec = strings.TrimSpace(playerName), strings.TrimSpace(engineSpec)
+		if playerName == "" {
+			return fmt.Errorf("участник %q: не указано имя", arg)
+		}
+		if _, err := strconv.Atoi(engineSpec); err == nil {
+			if _, err := engine.ParseLevel(engineSpec); err != nil {
+				return fmt.Errorf("участник %s: %w", playerName, err)
+			}
+		}
+		profile, err := repository.EnsureProfile(repo, playerName)
+		if err != nil {
+			return err
+		}
+		profile.Mu.RLock()
+		entrants = append(entrants, tournament.Entrant{
+			ProfileID: profile.ID,
+			Name:      profile.Name,
+			Engine:    engineSpec,
+			Rating:    profile.Rating,
+		})
+		profile.Mu.RUnlock()
+	}
+
+	t, err := tournament.New(*name, model.TournamentFormat(*format), *rounds, entrants)
+	if err != nil {
+		return err
+	}
+	t.TimeControl = *control
+	err = repo.Transaction(func(tx repository.Tx) error {
+		tx.Store(t)
+		for _, p := range t.Participants {
+			tx.Store(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Создан турнир [%.8s] %s: %s, туров: %d\n", t.ID, t.Name, formatNames[t.Format], t.Rounds)
+	fmt.Fprintf(out, "Запуск: go_hw tournament run %.8s\n", t.ID)
+	return nil
+}
+
+func listTournaments(repo repository.Repository, out io.Writer) {
+	tournaments := repo.Tournaments()
+	if len(tournaments) == 0 {
+		fmt.Fprintln(out, "Турниров пока нет")
+		return
+	}
+	for _, t := range tournaments {
+		t.Mu.RLock()
+		fmt.Fprintf(out, "[%.8s] %s  %s  тур %d из %d  участников: %d  %s\n",
+			t.ID, t.Name, formatNames[t.Format], t.Round, t.Rounds, len(t.Participants), t.Status)
+		t.Mu.RUnlock()
+	}
+}
+
+// findTournament looks a tournament up by its ID or the beginning of it.
+func findTournament(repo repository.Repository, args []string) (*model.Tournament, error) {
+	if len(args) != 1 {
+		return nil, errors.New("укажите ID турнира")
+	}
+	var found []*model.Tournament
+	for _, t := range repo.Tournaments() {
+		if strings.HasPrefix(t.ID, args[0]) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("турнир %q не найден", args[0])
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("ID %q подходит к нескольким турнирам", args[0])
+}
+
+// printTournament prints the standings and the pairings of the current round.
+func printTournament(out io.Writer, t *model.Tournament) {
+	t.Mu.RLock()
+	defer t.Mu.RUnlock()
+
+	fmt.Fprintf(out, "=== %s (%s), тур %d из %d ===\n", t.Name, formatNames[t.Format], t.Round, t.Rounds)
+	fmt.Fprintf(out, "%-5s %-20s %5s  %-12s %8s %8s\n", "Место", "Участник", "Очки", "+/−/=", "Бухгольц", "З-Б")
+	for _, s := range tournament.Standings(t) {
+		name := s.Participant.Name
+		if s.Eliminated {
+			name += " (выбыл)"
+		}
+		fmt.Fprintf(out, "%5d %-20s %5.1f  %-12s %8.1f %8.2f\n",
+			s.Rank, name, s.Points, fmt.Sprintf("+%d −%d =%d", s.Wins, s.Losses, s.Draws), s.Buchholz, s.SonnebornBerger)
+	}
+	if t.Round > 0 {
+		fmt.Fprintf(out, "Тур %d:\n", t.Round)
+		printPairings(out, t, t.Round)
+	}
+	if winner := tournament.Winner(t); winner != nil {
+		fmt.Fprintf(out, "Победитель: %s\n", winner.Name)
+	}
+}
+
+// printPairings prints the games of a round; the caller holds t.Mu.
+func printPairings(out io.Writer, t *model.Tournament, round int) {
+	for _, p := range t.Pairings {
+		if p.Round != round {
+			continue
+		}
+		white := tournament.Participant(t, p.WhiteID)
+		if p.IsBye() {
+			fmt.Fprintf(out, "  Доска %d: %s пропускает тур\n", p.Board, white.Name)
+			continue
+		}
+		result := string(p.Result)
+		if result == "" {
+			result = "не сыграна"
+		}
+		fmt.Fprintf(out, "  Доска %d: %s — %s  %s\n", p.Board, white.Name, tournament.Participant(t, p.BlackID).Name, result)
+	}
+}
+
+// runTournament plays the tournament round by round until it is finished, a
+// game is postponed or the program is interrupted; it can be run again to
+// continue from where it stopped.
+func runTournament(ctx context.Context, repo repository.Repository, t *model.Tournament) error {
+	for ctx.Err() == nil {
+		t.Mu.Lock()
+		if t.Status == model.StatusFinished {
+			t.Mu.Unlock()
+			break
+		}
+		var created []*model.Pairing
+		var err error
+		if t.Round == 0 || tournament.RoundComplete(t) {
+			created, err = tournament.NextRound(t)
+		}
+		pending := tournament.Pending(t)
+		if len(created) > 0 {
+			fmt.Printf("Тур %d из %d\n", t.Round, t.Rounds)
+			printPairings(os.Stdout, t, t.Round)
+		}
+		t.Mu.Unlock()
+		if errors.Is(err, tournament.ErrFinished) {
+			reportSaveError(saveTournament(repo, t))
+			break
+		}
+		if err != nil {
+			return err
+		}
+		reportSaveError(saveTournament(repo, t, created...))
+
+		if !playRound(ctx, repo, t, pending) {
+			break
+		}
+	}
+	fmt.Println()
+	printTournament(os.Stdout, t)
+	return nil
+}
+
+// saveTournament stores the tournament together with the given pairings.
+func saveTournament(repo repository.Repository, t *model.Tournament, pairings ...*model.Pairing) error {
+	return repo.Transaction(func(tx repository.Tx) error {
+		tx.Store(t)
+		for _, p := range pairings {
+			tx.Store(p)
+		}
+		return nil
+	})
+}
+
+// playRound plays the pending games of the round: games with a human one by
+// one at the board, engine games all at once through the GameManager. It
+// returns false when some game is left unfinished.
+func playRound(ctx context.Context, repo repository.Repository, t *model.Tournament, pending []*model.Pairing) bool {
+	defer closeAutoPlayers()
+
+	manager := NewGameManager(repo)
+	games := make(map[*model.Pairing]*model.Game, len(pending))
+	var interactive []*model.Game
+	for _, p := range pending {
+		game, human, err := pairingGame(ctx, repo, t, p)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return false
+		}
+		games[p] = game
+		switch {
+		case !game.IsInProgress():
+		case human:
+			interactive = append(interactive, game)
+		default:
+			manager.AddGame(game)
+		}
+	}
+
+	for _, game := range interactive {
+		fmt.Printf("\n%s — %s\n", game.WhitePlayer.Name, game.BlackPlayer.Name)
+		displayBoard(game, 1)
+		gameLoop(ctx, repo, game)
+		rateGame(repo, game, true)
+		if inProgress, _ := turnState(game); ctx.Err() != nil || inProgress {
+			break
+		}
+	}
+	if manager.GetGameCount() > 0 && ctx.Err() == nil {
+		runSimulation(ctx, manager)
+	}
+
+	complete := ctx.Err() == nil
+	for _, p := range pending {
+		game := games[p]
+		game.Mu.RLock()
+		result, finished := game.Result, game.IsFinished()
+		game.Mu.RUnlock()
+		switch {
+		case result != model.ResultNone:
+			rateGame(repo, game, false)
+			t.Mu.Lock()
+			replays := tournament.RecordResult(t, p, result)
+			t.Mu.Unlock()
+			reportSaveError(saveTournament(repo, t, append(replays, p)...))
+		case finished:
+			// A game stopped without a result is played again
+			fmt.Printf("Партия %s — %s прервана без результата и будет сыграна заново\n",
+				game.WhitePlayer.Name, game.BlackPlayer.Name)
+			p.GameID = ""
+			p.Touch()
+			reportSaveError(repo.Store(p))
+			complete = false
+		default:
+			fmt.Printf("Партия %s — %s не доиграна\n", game.WhitePlayer.Name, game.BlackPlayer.Name)
+			complete = false
+		}
+	}
+	if !complete {
+		fmt.Printf("Продолжить турнир: go_hw tournament run %.8s\n", t.ID)
+	}
+	return complete
+}
+
+// pairingGame returns the game of the pairing, resuming the one left from an
+// earlier run or creating a new one, and sets up who makes the moves. human
+// reports that a person plays in the game.
+func pairingGame(ctx context.Context, repo repository.Repository, t *model.Tournament, p *model.Pairing) (game *model.Game, human bool, err error) {
+	t.Mu.RLock()
+	white, black := tournament.Participant(t, p.WhiteID), tournament.Participant(t, p.BlackID)
+	control := t.TimeControl
+	t.Mu.RUnlock()
+
+	game, ok := repo.Game(p.GameID)
+	if ok {
+		game.Mu.Lock()
+		game.Resume()
+		game.Mu.Unlock()
+	} else {
+		game = model.NewGame(white.Name, black.Name, model.StandardBoardSize)
+		placePieces(game.Board, model.StandardBoardSize)
+		game.WhitePlayer.ProfileID = white.ProfileID
+		game.BlackPlayer.ProfileID = black.ProfileID
+		if control != "" {
+			tc, err := model.ParseTimeControl(control)
+			if err != nil {
+				return nil, false, err
+			}
+			game.SetTimeControl(tc)
+		}
+		game.Start()
+		p.GameID = game.ID
+		p.Touch()
+		err := repo.Transaction(func(tx repository.Tx) error {
+			tx.Store(game)
+			tx.Store(game.Board)
+			tx.Store(game.WhitePlayer)
+			tx.Store(game.BlackPlayer)
+			tx.Store(p)
+			return nil
+		})
+		reportSaveError(err)
+	}
+
+	for _, seat := range []struct {
+		player      *model.Player
+		participant *model.Participant
+	}{{game.WhitePlayer, white}, {game.BlackPlayer, black}} {
+		if seat.participant.IsHuman() {
+			human = true
+			setAutoPlayer(seat.player, defaultAutoPlayer)
+			continue
+		}
+		chooser, err := parseChooser(ctx, seat.participant.Engine)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", seat.participant.Name, err)
+		}
+		setAutoPlayer(seat.player, autoPlayer{chooser: chooser, computer: true})
+	}
+	return game, human, nil
+}